@@ -0,0 +1,661 @@
+package main
+
+import (
+	"atm-simulation/internal/approval"
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/operator"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/urfave/cli/v2"
+)
+
+// maxPasswordLength is the longest password bcrypt takes into account
+const maxPasswordLength = 72
+
+// auditViewLimit is the number of audit log entries shown in the admin console
+const auditViewLimit = 50
+
+// adminSource identifies the admin console in the audit log
+func adminSource() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "admin"
+	}
+	return "admin@" + host
+}
+
+// adminCommand builds the `atm admin` command, the console of the bank staff
+func adminCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "admin",
+		Usage: "Konsol operator untuk mengelola akun nasabah",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Action: func(c *cli.Context) error {
+			stdin = newConsole(os.Stdin)
+			op, err := operatorLogin()
+			if err != nil {
+				if isInputClosed(err) || err == errInputCancelled {
+					return nil
+				}
+				return err
+			}
+			adminMenu(op)
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "dormancy",
+				Usage: "Jadikan dormant akun aktif yang lama tidak digunakan; dijalankan berkala, misalnya dari cron",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "months", Value: user.DefaultDormancyMonths, EnvVars: []string{"ATM_DORMANT_AFTER"}, Usage: "jumlah bulan tanpa aktivitas nasabah"},
+				},
+				Action: func(c *cli.Context) error {
+					marked, err := user.MarkDormant(c.Int("months"), "system", adminSource())
+					if err != nil {
+						return err
+					}
+					for _, id := range marked {
+						fmt.Printf("Akun %d sekarang dormant.\n", id)
+					}
+					fmt.Printf("%d akun dijadikan dormant.\n", len(marked))
+					return nil
+				},
+			},
+			{
+				Name:  "expire-transfers",
+				Usage: "Tandai kedaluwarsa transfer besar yang tidak disetujui tepat waktu; dijalankan berkala, misalnya dari cron",
+				Action: func(c *cli.Context) error {
+					n, err := transaction.ExpireTransfers()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%d transfer kedaluwarsa, dananya dilepas.\n", n)
+					return nil
+				},
+			},
+			{
+				Name:  "hash-pins",
+				Usage: "Ganti PIN yang masih tersimpan apa adanya dengan hash bcrypt; dijalankan sekali setelah upgrade database",
+				Action: func(c *cli.Context) error {
+					n, err := user.HashStoredPINs()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%d PIN di-hash.\n", n)
+					return nil
+				},
+			},
+			{
+				Name:  "operator",
+				Usage: "Kelola operator konsol admin",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "add",
+						Usage: "Tambah operator; selain operator pertama (supervisor) harus login sebagai supervisor, password diminta tanpa ditampilkan",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "username", Required: true, Usage: "username operator"},
+							&cli.StringFlag{Name: "role", Value: string(authz.RoleTeller), Usage: "peran operator (" + strings.Join(operator.Roles(), ", ") + ")"},
+						},
+						Action: func(c *cli.Context) error {
+							stdin = newConsole(os.Stdin)
+							// Only the first operator is added without logging in
+							count, err := operator.Count()
+							if err != nil {
+								return err
+							}
+							var creator *authz.Principal
+							if count > 0 {
+								supervisor, err := operatorLogin()
+								if err != nil {
+									return err
+								}
+								p := supervisor.Principal()
+								creator = &p
+							}
+							password, err := askNewPassword()
+							if err != nil {
+								return err
+							}
+							op, err := operator.Create(c.String("username"), password, c.String("role"), creator, adminSource())
+							if err != nil {
+								return err
+							}
+							fmt.Printf("Operator %s (%s) ditambahkan dengan ID %d.\n", op.Username, op.Role, op.ID)
+							return nil
+						},
+					},
+					{
+						Name:  "list",
+						Usage: "Tampilkan semua operator",
+						Action: func(c *cli.Context) error {
+							operators, err := operator.List()
+							if err != nil {
+								return err
+							}
+							for _, op := range operators {
+								fmt.Printf("%-4d %-20s %-11s %s\n", op.ID, op.Username, op.Role, op.CreatedAt)
+							}
+							fmt.Printf("%d operator terdaftar.\n", len(operators))
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// askPassword reads a password without echo
+func askPassword(prompt string) (string, error) {
+	return askWith(nil, prompt, func(timeout time.Duration) (string, error) {
+		return stdin.readMasked(timeout, unicode.IsPrint, maxPasswordLength)
+	})
+}
+
+// askNewPassword asks for a new operator password twice
+func askNewPassword() (string, error) {
+	for {
+		password, err := askPassword("Password: ")
+		if err != nil {
+			return "", err
+		}
+		if len(password) < operator.MinPasswordLength {
+			fmt.Println(operator.ErrPasswordTooShort)
+			continue
+		}
+		confirm, err := askPassword("Ulangi password: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm != password {
+			fmt.Println("Password tidak sama, silakan ulangi.")
+			continue
+		}
+		return password, nil
+	}
+}
+
+// operatorLogin asks for the credentials of an operator until they are accepted
+func operatorLogin() (*operator.Operator, error) {
+	fmt.Println("===== Konsol Operator =====")
+	for {
+		username, err := ask(nil, "Username: ")
+		if err != nil {
+			return nil, err
+		}
+		password, err := askPassword("Password: ")
+		if err != nil {
+			return nil, err
+		}
+		op, err := operator.Login(username, password, adminSource())
+		if err == nil {
+			fmt.Printf("Selamat datang, %s (%s).\n", op.Username, op.Role)
+			return op, nil
+		}
+		if !errors.Is(err, operator.ErrInvalidCredentials) {
+			return nil, err
+		}
+		fmt.Println("Login gagal:", err)
+	}
+}
+
+// adminMenuItem is an entry of the admin console menu
+type adminMenuItem struct {
+	label  string
+	perm   authz.Permission
+	action string // audit action recorded when the operator is not permitted
+	run    func(op *operator.Operator) error
+}
+
+// adminMenuItems are the operations of the admin console, in menu order
+var adminMenuItems = []adminMenuItem{
+	{"Cari akun", authz.PermSearch, audit.ActionAccountSearch, adminSearch},
+	{"Lihat profil dan riwayat", authz.PermView, audit.ActionAccountView, adminView},
+	{"Bekukan akun", authz.PermFreeze, audit.ActionFreeze, adminFreeze},
+	{"Cairkan akun", authz.PermFreeze, audit.ActionUnfreeze, adminUnfreeze},
+	{"Aktifkan kembali akun dormant", authz.PermReactivate, audit.ActionReactivate, adminReactivate},
+	{"Ajukan penutupan akun", authz.PermClose, audit.ActionClose, adminClose},
+	{"Reset PIN", authz.PermResetPIN, audit.ActionPINReset, adminResetPIN},
+	{"Ajukan koreksi saldo", authz.PermAdjust, audit.ActionAdjustBalance, adminAdjust},
+	{"Buka kunci akun", authz.PermUnlock, audit.ActionUnlock, adminUnlock},
+	{"Antrian persetujuan", authz.PermApprove, audit.ActionApprove, adminApprovals},
+	{"Lihat audit log", authz.PermAuditLog, audit.ActionAuditView, adminAuditLog},
+}
+
+// adminMenu runs the menu of the admin console until the operator leaves
+func adminMenu(op *operator.Operator) {
+	exit := len(adminMenuItems) + 1
+	for {
+		fmt.Println()
+		fmt.Printf("===== Konsol Operator: %s (%s) =====\n", op.Username, op.Role)
+		for i, item := range adminMenuItems {
+			fmt.Printf("%d. %s\n", i+1, item.label)
+		}
+		fmt.Printf("%d. Keluar\n", exit)
+
+		choice, err := askInt(nil, fmt.Sprintf("Pilih menu (1-%d): ", exit))
+		if isInputClosed(err) || choice == exit {
+			fmt.Println("Sampai jumpa.")
+			return
+		}
+		if err != nil || choice < 1 || choice > exit {
+			fmt.Println("Pilihan tidak valid, silakan coba lagi.")
+			continue
+		}
+
+		item := adminMenuItems[choice-1]
+		if err := op.Authorize(item.perm); err != nil {
+			if err := audit.Record(op.Username, item.action, 0, audit.OutcomeFailure, adminSource(), err.Error()); err != nil {
+				fmt.Println("Gagal mencatat audit log:", err)
+			}
+			fmt.Println("Ditolak:", errors.Unwrap(err))
+			continue
+		}
+		err = item.run(op)
+		if isInputClosed(err) {
+			return
+		}
+		if authz.IsDenied(err) {
+			fmt.Println("Ditolak:", errors.Unwrap(err))
+		} else if err != nil && err != errInputCancelled {
+			fmt.Println("Gagal:", err)
+		}
+	}
+}
+
+// askAccountID asks for the ID of the account to work on
+func askAccountID() (int, error) {
+	for {
+		id, err := askInt(nil, "ID akun: ")
+		if isInputClosed(err) {
+			return 0, err
+		}
+		if err == nil && id > 0 {
+			return id, nil
+		}
+		fmt.Println("ID akun tidak valid.")
+	}
+}
+
+// askReason asks for the mandatory reason of an operation
+func askReason() (string, error) {
+	for {
+		reason, err := ask(nil, "Alasan: ")
+		if err != nil {
+			return "", err
+		}
+		if reason != "" {
+			return reason, nil
+		}
+		fmt.Println(user.ErrReasonRequired)
+	}
+}
+
+// confirmAction asks the operator to confirm an operation with y
+func confirmAction(prompt string) (bool, error) {
+	answer, err := ask(nil, prompt+" (y/n): ")
+	if err != nil {
+		return false, err
+	}
+	return answer == "y" || answer == "Y", nil
+}
+
+// printAccount shows the full profile of an account
+func printAccount(a *user.Account) {
+	locked := "tidak"
+	if a.LockedAt != nil {
+		locked = "ya, sejak " + *a.LockedAt
+	}
+	fmt.Printf("ID            : %d\n", a.ID)
+	fmt.Printf("Nama          : %s\n", a.Name)
+	fmt.Printf("Status        : %s\n", a.Status)
+	if a.ClosedAt != nil {
+		fmt.Printf("Ditutup       : %s\n", *a.ClosedAt)
+	}
+	fmt.Printf("Saldo         : %s\n", currency.Format(a.Balance, a.Currency))
+	fmt.Printf("Bank          : %s\n", a.BankCode)
+	fmt.Printf("Dibuat        : %s\n", a.CreatedAt)
+	fmt.Printf("Terkunci      : %s\n", locked)
+	fmt.Printf("PIN salah     : %d kali\n", a.FailedAttempts)
+}
+
+// adminSearch lists the accounts matching an ID or a part of the name
+func adminSearch(op *operator.Operator) error {
+	query, err := ask(nil, "ID atau nama akun: ")
+	if err != nil {
+		return err
+	}
+	accounts, err := user.Search(query, op.Username, adminSource())
+	if err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		fmt.Printf("%-5d %-25s %-7s %20s\n", a.ID, a.Name, a.Status, currency.Format(a.Balance, a.Currency))
+	}
+	fmt.Printf("%d akun ditemukan.\n", len(accounts))
+	return nil
+}
+
+// adminView shows the full profile and the transaction history of an account
+func adminView(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	account, err := user.View(id, op.Username, adminSource())
+	if err != nil {
+		return err
+	}
+	printAccount(account)
+
+	changes, err := user.StatusHistory(id)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		fmt.Println("Riwayat status:")
+		for _, c := range changes {
+			fmt.Printf("  %s %s -> %s oleh %s: %s\n", c.CreatedAt, c.FromStatus, c.ToStatus, c.Actor, c.Reason)
+		}
+	}
+
+	history, err := transaction.ViewTransactionHistory(id, "all")
+	if errors.Is(err, transaction.ErrNoHistory) {
+		fmt.Println("Belum ada transaksi.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Riwayat transaksi:")
+	for _, t := range history {
+		target := "-"
+		if targetID, ok := t["target_id"].(*int); ok && targetID != nil {
+			target = strconv.Itoa(*targetID)
+		}
+		fmt.Printf("  %s %-18s %20s tujuan=%s\n", t["created_at"], t["type"], currency.Format(t["amount"].(float64), account.Currency), target)
+	}
+	return nil
+}
+
+// adminFreeze freezes an account
+func adminFreeze(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	reason, err := askReason()
+	if err != nil {
+		return err
+	}
+	if err := user.Freeze(id, reason, op.Principal(), adminSource()); err != nil {
+		return err
+	}
+	fmt.Printf("Akun %d dibekukan.\n", id)
+	return nil
+}
+
+// adminUnfreeze lifts the freeze of an account
+func adminUnfreeze(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	reason, err := askReason()
+	if err != nil {
+		return err
+	}
+	if err := user.Unfreeze(id, reason, op.Principal(), adminSource()); err != nil {
+		return err
+	}
+	fmt.Printf("Akun %d aktif kembali.\n", id)
+	return nil
+}
+
+// adminReactivate gives a dormant account back to the customer
+func adminReactivate(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	reason, err := askReason()
+	if err != nil {
+		return err
+	}
+	if err := user.Reactivate(id, reason, op.Principal(), adminSource()); err != nil {
+		return err
+	}
+	fmt.Printf("Akun %d aktif kembali.\n", id)
+	return nil
+}
+
+// adminClose asks for an account to be closed; the closure waits for the approval of a supervisor
+func adminClose(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	account, err := user.View(id, op.Username, adminSource())
+	if err != nil {
+		return err
+	}
+	printAccount(account)
+	reason, err := askReason()
+	if err != nil {
+		return err
+	}
+	ok, err := confirmAction(fmt.Sprintf("Ajukan penutupan akun %d dan pembayaran saldonya?", id))
+	if err != nil || !ok {
+		return err
+	}
+	r, err := approval.Submit(op.Principal(), approval.OperationCloseAccount, id, 0, reason, adminSource())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Permintaan A%d menunggu persetujuan supervisor lain.\n", r.ID)
+	return nil
+}
+
+// adminResetPIN gives an account a new random PIN
+func adminResetPIN(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	ok, err := confirmAction(fmt.Sprintf("Reset PIN akun %d?", id))
+	if err != nil || !ok {
+		return err
+	}
+	pin, err := user.ResetPIN(id, op.Principal(), adminSource())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("PIN baru akun %d: %s\n", id, pin)
+	fmt.Println("Serahkan PIN ini kepada nasabah secara langsung; PIN tidak akan ditampilkan lagi.")
+	return nil
+}
+
+// adminAdjust asks for a correction of the balance of an account; the correction waits for the approval of a supervisor
+func adminAdjust(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	account, err := user.View(id, op.Username, adminSource())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saldo saat ini: %s\n", currency.Format(account.Balance, account.Currency))
+
+	var amount float64
+	for {
+		line, err := ask(nil, "Jumlah koreksi (negatif untuk debit): ")
+		if err != nil {
+			return err
+		}
+		amount, err = strconv.ParseFloat(line, 64)
+		if err == nil && amount != 0 {
+			break
+		}
+		fmt.Println(user.ErrInvalidAmount)
+	}
+	reason, err := askReason()
+	if err != nil {
+		return err
+	}
+	ok, err := confirmAction(fmt.Sprintf("Ajukan koreksi saldo akun %d sebesar %s?", id, currency.Format(amount, account.Currency)))
+	if err != nil || !ok {
+		return err
+	}
+	r, err := approval.Submit(op.Principal(), approval.OperationAdjustBalance, id, amount, reason, adminSource())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Permintaan A%d menunggu persetujuan supervisor lain.\n", r.ID)
+	return nil
+}
+
+// adminUnlock unlocks an account locked after too many wrong PINs
+func adminUnlock(op *operator.Operator) error {
+	id, err := askAccountID()
+	if err != nil {
+		return err
+	}
+	if err := user.Unlock(id, op.Principal(), adminSource()); err != nil {
+		return err
+	}
+	fmt.Printf("Akun %d tidak terkunci lagi.\n", id)
+	return nil
+}
+
+// printApproval shows a request of the approval queue
+func printApproval(r approval.Request) {
+	detail := ""
+	if r.Operation == approval.OperationAdjustBalance || r.Operation == approval.OperationReconcile {
+		detail = fmt.Sprintf(" %+.2f", r.Amount)
+	}
+	fmt.Printf("A%-4d %s %-17s akun=%-5d%s oleh %s: %s\n", r.ID, r.CreatedAt, r.Operation, r.AccountID, detail, r.Maker, r.Reason)
+}
+
+// adminApprovals lets a supervisor approve or reject the requests made by other members of staff
+// and the transfers held for being above the approval threshold
+func adminApprovals(op *operator.Operator) error {
+	requests, err := approval.Pending()
+	if err != nil {
+		return err
+	}
+	transfers, err := transaction.PendingTransfers(0)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 && len(transfers) == 0 {
+		fmt.Println("Tidak ada permintaan yang menunggu persetujuan.")
+		return nil
+	}
+	if len(requests) > 0 {
+		fmt.Println("Permintaan staf (A):")
+		for _, r := range requests {
+			printApproval(r)
+		}
+	}
+	if len(transfers) > 0 {
+		fmt.Println("Transfer besar (T):")
+		for _, t := range transfers {
+			fmt.Printf("T%-4d %s akun=%-5d ke %-9s %15.2f berlaku sampai %s\n", t.ID, t.CreatedAt, t.AccountID, t.Target(), t.Amount, t.ExpiresAt)
+		}
+	}
+
+	line, err := ask(nil, "Permintaan, misalnya A3 atau T5 (kosongkan untuk kembali): ")
+	if err != nil || line == "" {
+		return err
+	}
+	kind, number := strings.ToUpper(line[:1]), line[1:]
+	id, err := strconv.Atoi(number)
+	if err != nil || (kind != "A" && kind != "T") {
+		fmt.Println("Permintaan tidak valid.")
+		return nil
+	}
+
+	decision, err := ask(nil, "Setujui (s) atau tolak (t)? ")
+	if err != nil {
+		return err
+	}
+	switch decision {
+	case "s", "S":
+		if kind == "T" {
+			if err := transaction.ApproveTransfer(id, op.Principal(), adminSource()); err != nil {
+				return err
+			}
+			fmt.Printf("Transfer T%d disetujui dan dibukukan.\n", id)
+			return nil
+		}
+		result, err := approval.Approve(id, op.Principal(), adminSource())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Permintaan A%d disetujui dan dijalankan.\n", id)
+		if result.Payout > 0 {
+			fmt.Printf("Saldo yang dibayarkan kepada nasabah: %s\n", currency.Format(result.Payout, result.Currency))
+		}
+	case "t", "T":
+		reason, err := askReason()
+		if err != nil {
+			return err
+		}
+		if kind == "T" {
+			err = transaction.RejectTransfer(id, op.Principal(), reason, adminSource())
+		} else {
+			err = approval.Reject(id, op.Principal(), reason, adminSource())
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Permintaan %s%d ditolak.\n", kind, id)
+	default:
+		fmt.Println("Pilihan tidak valid.")
+	}
+	return nil
+}
+
+// adminAuditLog shows the latest audit log entries, optionally of one account
+func adminAuditLog(op *operator.Operator) error {
+	line, err := ask(nil, "ID akun (kosongkan untuk semua): ")
+	if err != nil {
+		return err
+	}
+	filter := audit.Filter{Limit: auditViewLimit}
+	if line != "" {
+		if filter.TargetAccountID, err = strconv.Atoi(line); err != nil {
+			return fmt.Errorf("ID akun tidak valid")
+		}
+	}
+	entries, err := audit.Query(filter)
+	if err != nil {
+		return err
+	}
+	if err := audit.Record(op.Username, audit.ActionAuditView, filter.TargetAccountID, audit.OutcomeSuccess, adminSource(), ""); err != nil {
+		fmt.Println("Gagal mencatat audit log:", err)
+	}
+	for _, e := range entries {
+		target := "-"
+		if e.TargetAccountID != nil {
+			target = strconv.Itoa(*e.TargetAccountID)
+		}
+		fmt.Printf("%s %-16s %-12s akun=%-5s %-8s %s\n", e.CreatedAt, e.Action, e.Actor, target, e.Outcome, e.Detail)
+	}
+	fmt.Printf("%d entri ditampilkan.\n", len(entries))
+	return nil
+}
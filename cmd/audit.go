@@ -0,0 +1,97 @@
+package main
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// auditFilterFlags are the flags shared by the audit subcommands
+func auditFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "actor", Usage: "pelaku aksi"},
+		&cli.StringFlag{Name: "action", Usage: "jenis aksi, misalnya login atau pin_change"},
+		&cli.IntFlag{Name: "account", Usage: "ID akun yang menjadi target"},
+		&cli.StringFlag{Name: "outcome", Usage: "hasil aksi (success/failure)"},
+		&cli.TimestampFlag{Name: "from", Layout: time.DateTime, Usage: "waktu mulai"},
+		&cli.TimestampFlag{Name: "to", Layout: time.DateTime, Usage: "waktu akhir"},
+		&cli.IntFlag{Name: "limit", Usage: "jumlah entri maksimum"},
+	}
+}
+
+// auditFilter builds an audit filter from the command flags
+func auditFilter(c *cli.Context) audit.Filter {
+	filter := audit.Filter{
+		Actor:           c.String("actor"),
+		Action:          c.String("action"),
+		TargetAccountID: c.Int("account"),
+		Outcome:         c.String("outcome"),
+		Limit:           c.Int("limit"),
+	}
+	if t := c.Timestamp("from"); t != nil {
+		filter.From = *t
+	}
+	if t := c.Timestamp("to"); t != nil {
+		filter.To = *t
+	}
+	return filter
+}
+
+// auditCommand builds the `atm audit` command for querying security events
+func auditCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "audit",
+		Usage: "Periksa audit log keamanan akun",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "Tampilkan entri audit log",
+				Flags: auditFilterFlags(),
+				Action: func(c *cli.Context) error {
+					entries, err := audit.Query(auditFilter(c))
+					if err != nil {
+						return err
+					}
+					for _, e := range entries {
+						target := "-"
+						if e.TargetAccountID != nil {
+							target = fmt.Sprint(*e.TargetAccountID)
+						}
+						fmt.Printf("%s %-12s %-10s akun=%-5s %-8s %s %s\n", e.CreatedAt, e.Action, e.Actor, target, e.Outcome, e.Source, e.Detail)
+					}
+					fmt.Printf("%d entri ditemukan.\n", len(entries))
+					return nil
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "Ekspor audit log sebagai JSON lines",
+				Flags: append(auditFilterFlags(), &cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "berkas tujuan (default stdout)"}),
+				Action: func(c *cli.Context) error {
+					entries, err := audit.Query(auditFilter(c))
+					if err != nil {
+						return err
+					}
+
+					out := os.Stdout
+					if path := c.String("output"); path != "" {
+						f, err := os.Create(path)
+						if err != nil {
+							return err
+						}
+						defer f.Close()
+						out = f
+					}
+					return audit.ExportJSONLines(out, entries)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"atm-simulation/internal/clearing"
+	"atm-simulation/internal/host"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+)
+
+// bank performs the money movements of a session, either directly on the
+// ledger or by sending ISO 8583 requests to a remote host
+type bank interface {
+	CheckBalance(sess *session.Session) (float64, error)
+	AvailableBalance(sess *session.Session) (float64, error)
+	Deposit(sess *session.Session, amount float64) error
+	Withdraw(sess *session.Session, amount float64) error
+	FindTarget(sess *session.Session, targetID int) (*user.Account, error)
+	Transfer(sess *session.Session, targetID int, amount float64) error
+	TransferFX(sess *session.Session, targetID int, quote currency.Quote) error
+	TransferInterbank(sess *session.Session, bankCode string, accountID int, amount float64) (*clearing.Transfer, error)
+}
+
+// core is the bank used by the interactive terminal
+var core bank = localBank{}
+
+// localBank calls the transaction package directly
+type localBank struct{}
+
+func (localBank) CheckBalance(sess *session.Session) (float64, error) {
+	return user.CheckBalance(sess.AccountID())
+}
+
+func (localBank) AvailableBalance(sess *session.Session) (float64, error) {
+	return transaction.AvailableBalance(sess.AccountID())
+}
+
+func (localBank) Deposit(sess *session.Session, amount float64) error {
+	return transaction.Deposit(sess.AccountID(), amount)
+}
+
+func (localBank) Withdraw(sess *session.Session, amount float64) error {
+	return transaction.Withdraw(sess.AccountID(), amount)
+}
+
+// FindTarget looks up the target account of a transfer within this bank
+func (localBank) FindTarget(sess *session.Session, targetID int) (*user.Account, error) {
+	var target user.Account
+	err := db.DB.Get(&target, "SELECT id, name, currency FROM accounts WHERE id = ? AND bank_code = ?", targetID, user.BankCode)
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (localBank) Transfer(sess *session.Session, targetID int, amount float64) error {
+	return transaction.Transfer(sess.AccountID(), targetID, amount)
+}
+
+func (localBank) TransferFX(sess *session.Session, targetID int, quote currency.Quote) error {
+	return transaction.TransferFX(sess.AccountID(), targetID, quote)
+}
+
+func (localBank) TransferInterbank(sess *session.Session, bankCode string, accountID int, amount float64) (*clearing.Transfer, error) {
+	return clearing.Submit(sess.AccountID(), bankCode, accountID, amount)
+}
+
+// hostBank sends the requests to a remote host, authenticated with the PIN block built at login
+type hostBank struct {
+	client *host.Client
+}
+
+// card returns the card of the customer of the session
+func (b hostBank) card(sess *session.Session) host.Card {
+	return host.Card{AccountID: sess.AccountID(), PINBlock: sess.PINBlock, Currency: sess.Currency()}
+}
+
+func (b hostBank) CheckBalance(sess *session.Session) (float64, error) {
+	return b.client.CheckBalance(b.card(sess))
+}
+
+func (b hostBank) AvailableBalance(sess *session.Session) (float64, error) {
+	_, available, err := b.client.Balances(b.card(sess))
+	return available, err
+}
+
+func (b hostBank) Deposit(sess *session.Session, amount float64) error {
+	return b.client.Deposit(b.card(sess), amount)
+}
+
+func (b hostBank) Withdraw(sess *session.Session, amount float64) error {
+	return b.client.Withdraw(b.card(sess), amount)
+}
+
+func (b hostBank) FindTarget(sess *session.Session, targetID int) (*user.Account, error) {
+	return b.client.Account(b.card(sess), targetID)
+}
+
+func (b hostBank) Transfer(sess *session.Session, targetID int, amount float64) error {
+	return b.client.Transfer(b.card(sess), targetID, amount)
+}
+
+func (b hostBank) TransferFX(*session.Session, int, currency.Quote) error {
+	return errFXNotSupportedByHost
+}
+
+func (b hostBank) TransferInterbank(*session.Session, string, int, float64) (*clearing.Transfer, error) {
+	return nil, errNotSupportedByHost
+}
+
+// Errors returned for operations the ISO 8583 host interface does not carry
+var (
+	errNotSupportedByHost   = i18n.NewError("error.interbank_not_supported_by_host", "transfer antarbank belum didukung melalui host")
+	errFXNotSupportedByHost = i18n.NewError("error.fx_not_supported_by_host", "transfer antar mata uang belum didukung melalui host")
+)
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"atm-simulation/internal/clearing"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// clearingCommand builds the `atm clearing` command operating the simulated clearing network
+func clearingCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "clearing",
+		Usage: "Jalankan jaringan kliring antarbank",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "banks",
+				Usage: "Tampilkan bank peserta kliring",
+				Action: func(c *cli.Context) error {
+					banks, err := clearing.Banks()
+					if err != nil {
+						return err
+					}
+					for _, b := range banks {
+						fmt.Printf("%s  %s\n", b.Code, b.Name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "Tampilkan transfer antarbank",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "status", Usage: "hanya status ini (pending, settled, rejected)"},
+					&cli.IntFlag{Name: "limit", Value: 50, Usage: "jumlah transfer maksimum"},
+				},
+				Action: func(c *cli.Context) error {
+					transfers, err := clearing.List(c.String("status"), c.Int("limit"))
+					if err != nil {
+						return err
+					}
+					for _, t := range transfers {
+						fmt.Printf("%s %s %s/%-5d -> %s/%-5d %15s %-8s %s\n", t.CreatedAt, t.Reference, t.SourceBank, t.SourceAccountID,
+							t.DestBank, t.DestAccountID, currency.Format(t.Amount, currency.Base), t.Status, t.Reason)
+					}
+					fmt.Printf("%d transfer ditemukan.\n", len(transfers))
+					return nil
+				},
+			},
+			{
+				Name:  "run",
+				Usage: "Jalankan satu siklus kliring untuk transfer yang masih pending",
+				Action: func(c *cli.Context) error {
+					settled, rejected, err := clearing.Process()
+					fmt.Printf("%d transfer settled, %d ditolak.\n", settled, rejected)
+					return err
+				},
+			},
+			{
+				Name:  "settle",
+				Usage: "Hitung setelmen neto akhir hari antarbank",
+				Action: func(c *cli.Context) error {
+					id, positions, err := clearing.Settle()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Settlement #%d\n", id)
+					for _, p := range positions {
+						direction := "menerima"
+						amount := p.Net
+						if amount < 0 {
+							direction = "membayar"
+							amount = -amount
+						}
+						fmt.Printf("Bank %s %s %s\n", p.BankCode, direction, currency.Format(amount, currency.Base))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"atm-simulation/internal/dump"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// dumpFormatFlag is the format of the files of `atm export` and `atm import`
+var dumpFormatFlag = &cli.StringFlag{Name: "format", Value: dump.FormatJSONL, Usage: "format berkas (" + strings.Join(dump.Formats, ", ") + ")"}
+
+// exportCommand builds the `atm export` command writing the accounts and transactions to files
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Ekspor akun dan transaksi ke accounts.<format> dan transactions.<format>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "dir", Value: "export", Usage: "direktori tujuan"},
+			dumpFormatFlag,
+			&cli.BoolFlag{Name: "pin-hashes", Usage: "sertakan hash PIN; tanpa flag ini PIN tidak diekspor dan akun hasil impor perlu reset PIN"},
+		},
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Action: func(c *cli.Context) error {
+			counts, err := dump.Export(c.String("dir"), c.String("format"), c.Bool("pin-hashes"))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%d akun dan %d transaksi diekspor ke %s.\n", counts.Accounts, counts.Transactions, c.String("dir"))
+			return nil
+		},
+	}
+}
+
+// importCommand builds the `atm import` command loading accounts and transactions written by `atm export`
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Impor akun dan transaksi hasil ekspor, lalu hitung ulang saldo dari transaksi",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "dir", Required: true, Usage: "direktori berisi accounts.<format> dan transactions.<format>"},
+			dumpFormatFlag,
+			&cli.BoolFlag{Name: "remap-ids", Usage: "beri akun dan transaksi ID baru, alih-alih ID dari berkas"},
+			&cli.BoolFlag{Name: "keep-balances", Usage: "pertahankan saldo dari berkas; selisih dengan transaksi hanya dilaporkan"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "periksa dan laporkan tanpa menyimpan apa pun"},
+		},
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Action: func(c *cli.Context) error {
+			accounts, transactions, err := dump.Read(c.String("dir"), c.String("format"))
+			if err != nil {
+				return err
+			}
+			report, err := dump.Import(accounts, transactions, dump.Options{
+				RemapIDs:     c.Bool("remap-ids"),
+				KeepBalances: c.Bool("keep-balances"),
+				DryRun:       c.Bool("dry-run"),
+			})
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("dry-run") {
+				fmt.Println("Uji coba, tidak ada yang disimpan.")
+			}
+			fmt.Printf("Akun: %d ditambahkan, %d duplikat dilewati.\n", report.Accounts, report.DuplicateAccounts)
+			fmt.Printf("Transaksi: %d ditambahkan, %d duplikat dilewati.\n", report.Transactions, report.DuplicateTransactions)
+			if report.WithoutPIN > 0 {
+				fmt.Printf("%d akun tanpa PIN, PIN perlu direset lewat konsol admin (`atm admin`) sebelum akun dipakai.\n", report.WithoutPIN)
+			}
+			if c.Bool("remap-ids") {
+				for _, a := range accounts {
+					if id, ok := report.IDs[a.ID]; ok && id != a.ID {
+						fmt.Printf("  akun %d -> %d\n", a.ID, id)
+					}
+				}
+			}
+
+			if len(report.Mismatches) == 0 {
+				fmt.Println("Saldo semua akun sesuai dengan transaksinya.")
+				return nil
+			}
+			fmt.Printf("%d akun dengan saldo berbeda dari transaksinya:\n", len(report.Mismatches))
+			for _, m := range report.Mismatches {
+				if m.Existing {
+					fmt.Printf("  akun %d (%s): sudah ada sebelumnya, saldo tersimpan %.2f, menurut transaksi %.2f, selisih %.2f; saldo tidak diubah, periksa dengan `atm reconcile`\n",
+						m.ID, m.Name, m.Exported, m.Rebuilt, m.Rebuilt-m.Exported)
+					continue
+				}
+				fmt.Printf("  akun %d (%s): saldo berkas %.2f, menurut transaksi %.2f, selisih %.2f\n", m.ID, m.Name, m.Exported, m.Rebuilt, m.Rebuilt-m.Exported)
+			}
+			if c.Bool("keep-balances") {
+				fmt.Println("Saldo berkas dipertahankan.")
+			}
+			return nil
+		},
+	}
+}
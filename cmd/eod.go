@@ -0,0 +1,163 @@
+package main
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/eod"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// eodCommand builds the `atm eod` command managing the business dates and the end-of-day run
+func eodCommand() *cli.Command {
+	cutoffFlag := &cli.StringFlag{Name: "cutoff", Value: "17:00", EnvVars: []string{"ATM_EOD_CUTOFF"}, Usage: "jam cut-off tanggal bisnis yang dibuka (HH:MM); transaksi setelahnya dibukukan pada tanggal bisnis berikutnya"}
+	return &cli.Command{
+		Name:  "eod",
+		Usage: "Kelola tanggal bisnis dan jalankan proses akhir hari",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "start",
+				Usage: "Buka tanggal bisnis pertama",
+				Flags: []cli.Flag{
+					&cli.TimestampFlag{Name: "date", Layout: time.DateOnly, Timezone: time.Local, Usage: "tanggal bisnis (YYYY-MM-DD); default hari ini"},
+					cutoffFlag,
+				},
+				Action: func(c *cli.Context) error {
+					if err := setCutoff(c.String("cutoff")); err != nil {
+						return err
+					}
+					date := time.Now()
+					if t := c.Timestamp("date"); t != nil {
+						date = *t
+					}
+					day, err := businessday.Start(date)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Tanggal bisnis %s dibuka, cut-off %s.\n", day.Date, day.CutoffAt)
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "Tampilkan tanggal bisnis terakhir dan langkah proses akhir harinya",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "limit", Value: 5, Usage: "jumlah tanggal bisnis yang ditampilkan"},
+				},
+				Action: func(c *cli.Context) error {
+					days, err := businessday.Recent(c.Int("limit"))
+					if err != nil {
+						return err
+					}
+					if len(days) == 0 {
+						return businessday.ErrNoBusinessDate
+					}
+					for _, d := range days {
+						closed := ""
+						if d.ClosedAt != nil {
+							closed = ", selesai " + *d.ClosedAt
+						}
+						fmt.Printf("%s  %-7s  cut-off %s%s\n", d.Date, d.Status, d.CutoffAt, closed)
+						steps, err := eod.Steps(d.Date)
+						if err != nil {
+							return err
+						}
+						for _, s := range steps {
+							fmt.Printf("    %-15s %-7s %s\n", s.Step, s.Status, s.Detail)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "run",
+				Usage: "Tutup tanggal bisnis dan jalankan langkah akhir hari; setelah gagal, jalankan lagi untuk melanjutkan",
+				Flags: []cli.Flag{
+					cutoffFlag,
+					&cli.Float64Flag{Name: "interest-rate", EnvVars: []string{"ATM_INTEREST_RATE"}, Usage: "bunga tabungan dalam persen setahun, dihitung harian dan dikreditkan akhir bulan; 0 tanpa bunga"},
+					&cli.StringSliceFlag{Name: "monthly-fee", EnvVars: []string{"ATM_MONTHLY_FEE"}, Usage: "biaya administrasi bulanan per mata uang, misalnya IDR=5000; dapat diulang"},
+					&cli.IntFlag{Name: "dormant-after", Value: user.DefaultDormancyMonths, Usage: "jumlah bulan tanpa aktivitas sebelum akun menjadi dormant; 0 tanpa pemeriksaan"},
+					&cli.StringFlag{Name: "report-dir", Value: "reports", EnvVars: []string{"ATM_EOD_REPORT_DIR"}, Usage: "direktori laporan harian; kosong tanpa laporan"},
+					&cli.StringFlag{Name: "statement-dir", EnvVars: []string{"ATM_STATEMENT_DIR"}, Usage: "direktori rekening koran bulanan yang dibuat akhir bulan; kosong tanpa rekening koran"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := setCutoff(c.String("cutoff")); err != nil {
+						return err
+					}
+					if c.Float64("interest-rate") < 0 {
+						return fmt.Errorf("--interest-rate tidak boleh negatif")
+					}
+					if c.Int("dormant-after") < 0 {
+						return fmt.Errorf("--dormant-after tidak boleh negatif")
+					}
+					fees, err := parseMonthlyFees(c.StringSlice("monthly-fee"))
+					if err != nil {
+						return err
+					}
+
+					result, err := eod.Run(eod.Config{
+						InterestRate: c.Float64("interest-rate"),
+						MonthlyFees:  fees,
+						DormantAfter: c.Int("dormant-after"),
+						ReportDir:    c.String("report-dir"),
+						StatementDir: c.String("statement-dir"),
+						Source:       adminSource(),
+					})
+					if result != nil {
+						if result.Resumed {
+							fmt.Printf("Melanjutkan proses akhir hari %s.\n", result.Date)
+						}
+						for _, s := range result.Steps {
+							fmt.Printf("  %-15s %-7s %s\n", s.Step, s.Status, s.Detail)
+						}
+					}
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Tanggal bisnis %s ditutup, tanggal bisnis %s dibuka.\n", result.Date, result.Next)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// setCutoff sets the cut-off time, written as HH:MM, of the business dates opened from now on
+func setCutoff(value string) error {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return fmt.Errorf("jam cut-off %q tidak valid, gunakan HH:MM", value)
+	}
+	businessday.Cutoff = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return nil
+}
+
+// parseMonthlyFees reads the monthly fees written as CURRENCY=AMOUNT
+func parseMonthlyFees(values []string) (map[string]float64, error) {
+	fees := make(map[string]float64)
+	for _, v := range values {
+		code, amount, ok := strings.Cut(v, "=")
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !ok {
+			return nil, fmt.Errorf("biaya bulanan %q tidak valid, gunakan MATA_UANG=JUMLAH", v)
+		}
+		if _, err := currency.Lookup(code); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, code)
+		}
+		fee, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil || fee <= 0 {
+			return nil, fmt.Errorf("biaya bulanan %q tidak valid, jumlah harus lebih dari 0", v)
+		}
+		fees[code] = fee
+	}
+	return fees, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"atm-simulation/internal/rpc"
+	"atm-simulation/pkg/db"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// grpcCommand builds the `atm grpc` command exposing the banking core over gRPC
+func grpcCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "grpc",
+		Usage: "Jalankan server gRPC BankingService",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "listen", Value: ":9090", EnvVars: []string{"ATM_GRPC_ADDR"}, Usage: "alamat yang didengarkan server gRPC"},
+		},
+		Action: func(c *cli.Context) error {
+			if err := db.InitDB(); err != nil {
+				return err
+			}
+
+			service := rpc.NewServer(c.String("terminal-id"), c.Duration("idle-timeout"), c.Duration("session-timeout"))
+			server := service.NewGRPCServer()
+
+			ln, err := net.Listen("tcp", c.String("listen"))
+			if err != nil {
+				return err
+			}
+
+			stop := make(chan struct{})
+			go service.SweepSessions(30*time.Second, stop)
+
+			// Stop gracefully on Ctrl+C
+			go func() {
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+				<-signals
+				close(stop)
+				server.GracefulStop()
+			}()
+
+			log.Printf("Server gRPC berjalan di %s", ln.Addr())
+			return server.Serve(ln)
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// holdCommand builds the `atm hold` command managing funds holds, e.g. for card purchases
+// authorized now and settled later. Placing, capturing and releasing a hold move the available
+// balance, so they ask for the login of an operator whose role may manage holds
+func holdCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "hold",
+		Usage: "Tahan, debet atau lepas dana nasabah (otorisasi dan capture)",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "place",
+				Usage: "Tahan dana pada akun sehingga saldo tersedia berkurang",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "account", Required: true, Usage: "ID akun"},
+					&cli.Float64Flag{Name: "amount", Required: true, Usage: "jumlah yang ditahan, dalam mata uang akun"},
+					&cli.StringFlag{Name: "reference", Usage: "referensi penahanan, misalnya nomor otorisasi merchant"},
+					&cli.DurationFlag{Name: "ttl", Value: transaction.DefaultHoldTTL, Usage: "lama dana ditahan sebelum dilepas otomatis"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Duration("ttl") <= 0 {
+						return fmt.Errorf("--ttl harus lebih dari 0")
+					}
+					transaction.HoldTTL = c.Duration("ttl")
+					op, err := operatorLogin()
+					if err != nil {
+						return err
+					}
+					h, err := transaction.PlaceHold(c.Int("account"), c.Float64("amount"), c.String("reference"), op.Principal(), adminSource())
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Dana %s ditahan sebagai hold #%d sampai %s.\n", holdAmount(h), h.ID, h.ExpiresAt)
+					return nil
+				},
+			},
+			{
+				Name:  "capture",
+				Usage: "Debet dana yang ditahan; sisanya dilepas",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "id", Required: true, Usage: "ID hold"},
+					&cli.Float64Flag{Name: "amount", Usage: "jumlah yang didebet; tanpa flag ini seluruh dana yang ditahan"},
+				},
+				Action: func(c *cli.Context) error {
+					op, err := operatorLogin()
+					if err != nil {
+						return err
+					}
+					h, err := transaction.CaptureHold(c.Int("id"), c.Float64("amount"), op.Principal(), adminSource())
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Hold #%d: %s didebet dari akun %d.\n", h.ID, formatAccountAmount(h.AccountID, h.Captured), h.AccountID)
+					if released := h.Amount - h.Captured; released > 0 {
+						fmt.Printf("Sisa %s dilepas.\n", formatAccountAmount(h.AccountID, released))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "release",
+				Usage: "Lepas dana yang ditahan tanpa mendebet",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "id", Required: true, Usage: "ID hold"},
+				},
+				Action: func(c *cli.Context) error {
+					op, err := operatorLogin()
+					if err != nil {
+						return err
+					}
+					if err := transaction.ReleaseHold(c.Int("id"), op.Principal(), adminSource()); err != nil {
+						return err
+					}
+					fmt.Printf("Hold #%d dilepas.\n", c.Int("id"))
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "Tampilkan dana yang masih ditahan",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "account", Usage: "hanya akun ini"},
+				},
+				Action: func(c *cli.Context) error {
+					holds, err := transaction.Holds(c.Int("account"))
+					if err != nil {
+						return err
+					}
+					for _, h := range holds {
+						fmt.Printf("#%-5d %-5d %15s  %s s/d %s  %s\n", h.ID, h.AccountID, holdAmount(&h), h.CreatedAt, h.ExpiresAt, h.Reference)
+					}
+					fmt.Printf("%d hold aktif.\n", len(holds))
+					return nil
+				},
+			},
+			{
+				Name:  "expire",
+				Usage: "Tandai kedaluwarsa hold yang melewati batas waktunya; dijalankan berkala, misalnya dari cron",
+				Action: func(c *cli.Context) error {
+					n, err := transaction.ExpireHolds()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%d hold kedaluwarsa, dananya dilepas.\n", n)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// holdAmount formats the amount of a hold in the currency of its account
+func holdAmount(h *transaction.Hold) string {
+	return formatAccountAmount(h.AccountID, h.Amount)
+}
+
+// formatAccountAmount formats an amount in the currency of the account, or the base currency
+// when the account cannot be read
+func formatAccountAmount(accountID int, amount float64) string {
+	code := currency.Base
+	if account, err := user.Get(accountID); err == nil {
+		code = account.Currency
+	}
+	return currency.Format(amount, code)
+}
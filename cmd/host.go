@@ -0,0 +1,88 @@
+package main
+
+import (
+	"atm-simulation/internal/host"
+	"atm-simulation/pkg/db"
+	"atm-simulation/pkg/iso8583"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+)
+
+// loadISOSpec returns the ISO 8583 field spec configured by the --iso-spec flag
+func loadISOSpec(c *cli.Context) (iso8583.Spec, error) {
+	if path := c.String("iso-spec"); path != "" {
+		return iso8583.LoadSpec(path)
+	}
+	return iso8583.DefaultSpec(), nil
+}
+
+// hostClient is the connection of the interactive terminal to the remote host, nil without --host
+var hostClient *host.Client
+
+// connectHost switches the terminal to client mode when --host is given
+// Customers then log in, look up accounts and post transactions through the host, without a database
+func connectHost(c *cli.Context) error {
+	addr := c.String("host")
+	if addr == "" {
+		return nil
+	}
+	if err := checkTerminalID(c, c.String("terminal-id")); err != nil {
+		return err
+	}
+	spec, err := loadISOSpec(c)
+	if err != nil {
+		return err
+	}
+
+	hostClient = host.NewClient(strings.Split(addr, ","), c.String("terminal-id"), spec)
+	if err := hostClient.OpenReversals(c.String("reversal-file")); err != nil {
+		return err
+	}
+	if err := hostClient.Echo(); err != nil {
+		log.Println("Echo test ke host gagal:", err)
+	}
+	core = hostBank{client: hostClient}
+	return nil
+}
+
+// hostCommand builds the `atm host` command running the ISO 8583 host interface
+func hostCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "host",
+		Usage: "Jalankan host ISO 8583 yang mengotorisasi permintaan dari terminal",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "listen", Value: ":5000", EnvVars: []string{"ATM_HOST_LISTEN"}, Usage: "alamat TCP yang didengarkan host"},
+		},
+		Action: func(c *cli.Context) error {
+			spec, err := loadISOSpec(c)
+			if err != nil {
+				return err
+			}
+			if err := db.InitDB(); err != nil {
+				return err
+			}
+
+			ln, err := net.Listen("tcp", c.String("listen"))
+			if err != nil {
+				return err
+			}
+
+			// Stop accepting connections on Ctrl+C
+			go func() {
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+				<-signals
+				ln.Close()
+			}()
+
+			log.Printf("Host ISO 8583 berjalan di %s", ln.Addr())
+			return host.NewServer(spec).Serve(ln)
+		},
+	}
+}
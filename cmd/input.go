@@ -0,0 +1,192 @@
+package main
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/journal"
+	"atm-simulation/internal/session"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// errInputTimeout is returned when no input arrives before the deadline
+var errInputTimeout = errors.New("waktu input habis")
+
+// errSessionTimeout is returned when the session was ended because of a timeout
+var errSessionTimeout = i18n.NewError("error.session_timeout", "sesi berakhir karena tidak ada aktivitas")
+
+// moreTimeCountdown is how long the customer has to answer the "more time?" prompt
+var moreTimeCountdown = 15 * time.Second
+
+// console reads keyboard input in the background so that reads can time out
+type console struct {
+	runes chan rune
+	fd    int // file descriptor of the terminal, -1 when the input is not a terminal
+}
+
+// stdin is the console attached to the standard input
+var stdin *console
+
+// newConsole starts reading runes from the reader in a background goroutine
+func newConsole(r io.Reader) *console {
+	c := &console{runes: make(chan rune, 256), fd: -1}
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		c.fd = int(f.Fd())
+	}
+	go func() {
+		reader := bufio.NewReader(r)
+		for {
+			ch, _, err := reader.ReadRune()
+			if err != nil {
+				close(c.runes)
+				return
+			}
+			c.runes <- ch
+		}
+	}()
+	return c
+}
+
+// readLine waits for a line of input, at most for the given timeout
+// A timeout of zero waits forever
+func (c *console) readLine(timeout time.Duration) (string, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var line []rune
+	for {
+		select {
+		case ch, ok := <-c.runes:
+			if !ok {
+				if len(line) > 0 {
+					return strings.TrimSpace(string(line)), nil
+				}
+				return "", io.EOF
+			}
+			if ch == '\n' {
+				return strings.TrimSpace(string(line)), nil
+			}
+			line = append(line, ch)
+		case <-deadline:
+			return "", errInputTimeout
+		}
+	}
+}
+
+// ask prints the prompt and waits for a line of input
+// While a customer is logged in the wait is bounded by the session timeouts,
+// and an idle customer is asked whether more time is needed before being logged out
+func ask(sess *session.Session, prompt string) (string, error) {
+	return askWith(sess, prompt, stdin.readLine)
+}
+
+// askWith is ask with the reader of the input, so that a PIN can be read without echo
+func askWith(sess *session.Session, prompt string, read func(time.Duration) (string, error)) (string, error) {
+	for {
+		fmt.Print(prompt)
+		if sess == nil || sess.Ended() {
+			return read(0)
+		}
+
+		wait := sess.IdleRemaining()
+		if remaining := sess.Remaining(); remaining < wait {
+			wait = remaining
+		}
+		if wait > 0 {
+			line, err := read(wait)
+			if err != errInputTimeout {
+				if err == nil {
+					sess.Touch()
+				}
+				return line, err
+			}
+		}
+		fmt.Println()
+
+		if sess.Remaining() <= 0 {
+			say(sess, "session.max_duration")
+			expireSession(sess, session.ReasonAbsoluteTimeout)
+			return "", errSessionTimeout
+		}
+		if !askMoreTime(sess) {
+			expireSession(sess, session.ReasonIdleTimeout)
+			return "", errSessionTimeout
+		}
+		sess.Touch()
+	}
+}
+
+// askMoreTime shows a countdown asking whether the customer needs more time
+func askMoreTime(sess *session.Session) bool {
+	for left := int(moreTimeCountdown / time.Second); left > 0; left-- {
+		fmt.Print("\r" + tr(sess).T("session.more_time_prompt", left))
+		answer, err := stdin.readLine(time.Second)
+		if err == errInputTimeout {
+			continue
+		}
+		fmt.Println()
+		return err == nil && (answer == "y" || answer == "Y")
+	}
+	fmt.Println()
+	return false
+}
+
+// expireSession logs the customer out automatically after a timeout
+func expireSession(sess *session.Session, reason string) {
+	logEvent(sess, journal.EventTimeout, reason)
+	endSession(sess, reason)
+	say(sess, "session.logged_out")
+}
+
+// endSession closes the current session and clears the logged-in state
+func endSession(sess *session.Session, reason string) {
+	if sess == nil || sess.Ended() {
+		return
+	}
+	logEvent(sess, journal.EventCardOut, reason)
+	if err := sess.End(reason); err != nil {
+		log.Println("Gagal mencatat akhir sesi:", err)
+	}
+}
+
+// askInt asks for a whole number
+func askInt(sess *session.Session, prompt string) (int, error) {
+	line, err := ask(sess, prompt)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(line)
+}
+
+// askAmount asks for a positive amount of money until a valid one is entered
+func askAmount(sess *session.Session, prompt string) (float64, error) {
+	for {
+		line, err := ask(sess, prompt)
+		if err != nil {
+			return 0, err
+		}
+		amount, err := strconv.ParseFloat(line, 64)
+		if err != nil || amount <= 0 {
+			say(sess, "input.invalid_amount")
+			continue
+		}
+		return amount, nil
+	}
+}
+
+// isInputClosed reports whether the error means that standard input was closed
+func isInputClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, os.ErrClosed)
+}
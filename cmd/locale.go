@@ -0,0 +1,49 @@
+package main
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/session"
+	"fmt"
+)
+
+// lang is the language of the terminal while nobody is logged in, set by --lang
+var lang = i18n.Default
+
+// tr returns the printer for the language chosen in the session,
+// or for the terminal's language when nobody is logged in
+func tr(sess *session.Session) *i18n.Printer {
+	if sess != nil && sess.Language != "" {
+		return i18n.New(sess.Language)
+	}
+	return i18n.New(lang)
+}
+
+// say prints the translation of a catalog message on its own line
+func say(sess *session.Session, key string, args ...interface{}) {
+	fmt.Println(tr(sess).T(key, args...))
+}
+
+// fail prints a catalog message followed by the translated error
+func fail(sess *session.Session, key string, err error) {
+	say(sess, key, tr(sess).Error(err))
+}
+
+// chooseLanguage asks for the language of the session before the customer identifies,
+// the same way a real ATM does right after the card is inserted
+func chooseLanguage() (string, error) {
+	for {
+		fmt.Println()
+		for i, l := range i18n.Languages {
+			fmt.Printf("%d. %s\n", i+1, l.Name)
+		}
+		choice, err := askInt(nil, tr(nil).T("language.prompt", len(i18n.Languages)))
+		if isInputClosed(err) {
+			return "", err
+		}
+		if err != nil || choice < 1 || choice > len(i18n.Languages) {
+			say(nil, "menu.invalid_choice")
+			continue
+		}
+		return i18n.Languages[choice-1].Code, nil
+	}
+}
@@ -1,383 +1,683 @@
 package main
 
 import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/clearing"
+	"atm-simulation/internal/host"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/journal"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/statement"
 	"atm-simulation/internal/transaction"
 	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
 	"atm-simulation/pkg/db"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
 
-// currentUser stores the account that is currently logged in
-var currentUser *user.Account
+// terminalID identifies this terminal in the journal and the audit log
+var terminalID string
+
+// rates is the foreign exchange rate table, nil when no rate file was found
+var rates *currency.Rates
+
+// miniStatementLength is the number of transactions on a mini-statement
+var miniStatementLength = statement.DefaultMiniLength
+
+// Session timeouts configured by the command line flags
+var (
+	idleTimeout     = session.DefaultIdleTimeout
+	absoluteTimeout = session.DefaultAbsoluteTimeout
+)
 
 // Displays the main menu of the ATM application
-func mainMenu() {
-	fmt.Println("\n===== Menu Utama =====")
-	fmt.Println("1. Register")
-	fmt.Println("2. Login")
-	fmt.Println("3. Check Balance")
-	fmt.Println("4. Deposit")
-	fmt.Println("5. Withdraw")
-	fmt.Println("6. Transfer")
-	if currentUser != nil {
+func mainMenu(sess *session.Session) {
+	fmt.Println()
+	say(sess, "menu.title")
+	say(sess, "menu.register")
+	say(sess, "menu.login")
+	say(sess, "menu.balance")
+	say(sess, "menu.deposit")
+	say(sess, "menu.withdraw")
+	say(sess, "menu.transfer")
+	if sess != nil {
 		// Options available only after login
-		fmt.Println("7. View Profile")
-		fmt.Println("8. Change PIN")
-		fmt.Println("9. Log Out")
-		fmt.Println("10. View Transaction History")
+		say(sess, "menu.profile")
+		say(sess, "menu.change_pin")
+		say(sess, "menu.logout")
+		say(sess, "menu.history")
+		say(sess, "menu.mini_statement")
 	}
-	fmt.Println("11. Exit")
+	say(sess, "menu.exit")
 }
 
 // Handles user input and operations based on the menu choice
+// The session of the logged-in customer is passed explicitly to every handler
 func handleChoice(c *cli.Context) {
+	var sess *session.Session
 	for {
-		mainMenu() // Display the main menu
-		fmt.Print("Pilih menu (1-11): ")
-		var choice int
-		_, err := fmt.Scanln(&choice)
+		// Forget a session that was ended by a timeout or a log out
+		if sess != nil && sess.Ended() {
+			sess = nil
+		}
+
+		mainMenu(sess) // Display the main menu
+		choice, err := askInt(sess, tr(sess).T("menu.prompt", 12))
+		if isInputClosed(err) {
+			endSession(sess, session.ReasonShutdown)
+			return
+		}
 
 		// Validate input choice
-		if err != nil || choice < 1 || choice > 11 {
-			fmt.Println("Pilihan tidak valid, coba lagi.")
+		if err == errSessionTimeout {
+			continue
+		}
+		if err != nil || choice < 1 || choice > 12 {
+			say(sess, "menu.invalid_choice")
 			continue
 		}
+		logEvent(sess, journal.EventMenu, fmt.Sprintf("pilihan %d", choice))
 
 		// Call the corresponding function based on user choice
 		switch choice {
 		case 1:
-			register()
+			register(sess)
 		case 2:
-			login()
+			sess = login(sess)
 		case 3:
-			checkBalance()
+			checkBalance(sess)
 		case 4:
-			deposit()
+			deposit(sess)
 		case 5:
-			withdraw()
+			withdraw(sess)
 		case 6:
-			transfer()
+			transfer(sess)
 		case 7:
-			viewProfile()
+			viewProfile(sess)
 		case 8:
-			changePIN()
+			changePIN(sess)
 		case 9:
-			logOut()
+			logOut(sess)
 		case 10:
-			viewTransactionHistory()
+			viewTransactionHistory(sess)
 		case 11:
-			fmt.Println("Terima kasih telah menggunakan aplikasi ATM!")
+			miniStatement(sess)
+		case 12:
+			say(sess, "menu.goodbye")
+			endSession(sess, session.ReasonShutdown)
 			return
 		default:
-			fmt.Println("Pilihan tidak valid, coba lagi.")
+			say(sess, "menu.invalid_choice")
 		}
 	}
 }
 
+// authorize checks that a customer is logged in and allowed to perform the operation
+// The catalog message with the given key is shown when nobody is logged in
+func authorize(sess *session.Session, perm session.Permission, key string) bool {
+	if sess == nil || sess.Ended() {
+		say(nil, key)
+		return false
+	}
+	if !sess.Can(perm) {
+		say(sess, "common.not_permitted")
+		return false
+	}
+	return true
+}
+
 // Registers a new account
-func register() {
-	fmt.Print("Masukkan nama: ")
-	var name string
-	fmt.Scanln(&name)
-	fmt.Print("Masukkan PIN: ")
-	var pin string
-	fmt.Scanln(&pin)
+func register(sess *session.Session) {
+	// Accounts are opened at the bank, a terminal working through a remote host has no database
+	if hostClient != nil {
+		say(sess, "register.unavailable_remote")
+		return
+	}
+	name, err := ask(sess, tr(sess).T("prompt.name"))
+	if err != nil {
+		return
+	}
+	pin, err := askNewPIN(sess, tr(sess), "prompt.pin")
+	if err != nil {
+		return
+	}
+	currencyCode, err := ask(sess, tr(sess).T("register.currency_prompt", strings.Join(currency.Codes(), "/"), currency.Base))
+	if err != nil {
+		return
+	}
 
 	// Call the register function from the user package
-	account, err := user.Register(name, pin)
+	account, err := user.Register(name, pin, strings.ToUpper(currencyCode), terminalID)
 	if err != nil {
-		fmt.Println("Gagal membuat akun:", err)
+		fail(sess, "register.failed", err)
 		return
 	}
 
 	// Display account ID after successful registration
-	fmt.Printf("Akun berhasil dibuat! ID Akun: %d\n", account.ID)
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "register.success", account.ID)
+	say(sess, "common.back_to_menu")
+}
+
+// Logs in to the application and returns the new session
+func login(sess *session.Session) *session.Session {
+	if sess != nil {
+		say(sess, "login.already")
+		return sess
+	}
+
+	// The customer picks the language of the session before identifying
+	language, err := chooseLanguage()
+	if err != nil {
+		return nil
+	}
+	p := i18n.New(language)
+
+	name, err := ask(sess, p.T("prompt.name"))
+	if err != nil {
+		return nil
+	}
+	pin, err := askPIN(sess, p, "prompt.pin")
+	if err != nil {
+		return nil
+	}
+
+	sess, err = openSession(name, pin, language)
+	if err != nil {
+		fmt.Println(p.T("login.failed", p.Error(err)))
+		return nil
+	}
+
+	say(sess, "login.welcome", sess.Account.Name)
+	if sess.Offline {
+		say(sess, "login.offline_notice")
+	}
+	if sess.Account.Status == user.StatusFrozen {
+		say(sess, "login.frozen_notice")
+	}
+	say(sess, "common.back_to_menu")
+	return sess
 }
 
-// Logs in to the application
-func login() {
-	fmt.Print("Masukkan nama: ")
-	var name string
-	fmt.Scanln(&name)
-	fmt.Print("Masukkan PIN: ")
-	var pin string
-	fmt.Scanln(&pin)
+// openSession authenticates the customer and starts a session in the chosen language,
+// through the remote host when there is one, and against the cached snapshot while the database is down
+func openSession(name, pin, language string) (*session.Session, error) {
+	if standIn != nil && !online() {
+		return openOfflineSession(name, pin, language)
+	}
+	if hostClient != nil {
+		return openRemoteSession(name, pin, language)
+	}
+
+	logEvent(nil, journal.EventCardIn, "nama="+name)
 
 	// Call the login function from the user package
-	account, err := user.Login(name, pin)
+	account, err := user.Login(name, pin, terminalID)
 	if err != nil {
-		fmt.Println("Login gagal:", err)
-		return
+		logEvent(nil, journal.EventPINFail, err.Error())
+		return nil, err
+	}
+
+	// Start a session so an abandoned terminal is logged out automatically
+	sess, err := session.Start(account, session.Terminal{ID: terminalID}, idleTimeout, absoluteTimeout)
+	if err != nil {
+		logEvent(nil, journal.EventError, "sesi: "+err.Error())
+		return nil, err
 	}
+	sess.Language = language
 
-	currentUser = account
-	fmt.Printf("Login berhasil! Selamat datang, %s.\n", account.Name)
-	fmt.Println("Kembali ke menu utama...\n")
+	logEvent(sess, journal.EventPINOK, "bahasa="+language)
+	return sess, nil
 }
 
-// Formats the currency with thousand separators (e.g., "Rp 1,000")
-func formatCurrencyWithSeparator(amount float64) string {
-	amountStr := fmt.Sprintf("%.0f", amount)
-	result := ""
-	count := 0
-
-	// Format the amount to include thousand separators
-	for i := len(amountStr) - 1; i >= 0; i-- {
-		result = string(amountStr[i]) + result
-		count++
-		if count%3 == 0 && i > 0 {
-			result = "." + result
-		}
+// openRemoteSession has the remote host authenticate the customer
+// Only the PIN block built here is kept in the session, not the PIN
+func openRemoteSession(name, pin, language string) (*session.Session, error) {
+	logEvent(nil, journal.EventCardIn, "nama="+name)
+
+	accountID, err := hostClient.LookupCard(name)
+	if err != nil {
+		logEvent(nil, journal.EventPINFail, err.Error())
+		return nil, err
+	}
+	card, err := host.NewCard(accountID, pin)
+	if err != nil {
+		return nil, err
+	}
+	account, err := hostClient.Login(card)
+	if err != nil {
+		logEvent(nil, journal.EventPINFail, err.Error())
+		return nil, err
 	}
 
-	return "Rp " + result
+	sess, err := session.StartRemote(account, card.PINBlock, session.Terminal{ID: terminalID}, idleTimeout, absoluteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	sess.Language = language
+
+	logEvent(sess, journal.EventPINOK, "bahasa="+language)
+	return sess, nil
+}
+
+// Formats an amount in the currency of the session's account, e.g. "Rp 1.500.000" or "$1,250.50"
+func formatMoney(sess *session.Session, amount float64) string {
+	return currency.Format(amount, sess.Currency())
 }
 
 // Checks the balance of the logged-in account
-func checkBalance() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melihat saldo.")
+func checkBalance(sess *session.Session) {
+	if !authorize(sess, session.PermBalance, "balance.login_first") {
 		return
 	}
 
 	// Get the balance of the current account
-	balance, err := user.CheckBalance(currentUser.ID)
+	balance, err := core.CheckBalance(sess)
 	if err != nil {
-		fmt.Println("Gagal memeriksa saldo:", err)
+		fail(sess, "balance.failed", err)
 		return
 	}
 
 	// Display the balance in currency format
-	fmt.Printf("Saldo Anda saat ini: %s\n", formatCurrencyWithSeparator(balance))
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "balance.current", formatMoney(sess, balance))
+	sayAvailable(sess, balance)
+	say(sess, "common.back_to_menu")
 }
 
-// Retrieves the updated balance after a transaction (deposit, withdraw, transfer)
-func getUpdatedBalance(accountID int) (float64, error) {
-	var balance float64
-	err := db.DB.Get(&balance, "SELECT balance FROM accounts WHERE id = ?", accountID)
-	if err != nil {
-		return 0, err
+// sayAvailable shows the available balance when amounts are held on the account
+func sayAvailable(sess *session.Session, balance float64) {
+	available, err := core.AvailableBalance(sess)
+	if err == nil && available != balance {
+		say(sess, "balance.available", formatMoney(sess, available))
+	}
+}
+
+// transferHeld reports a transfer that was held for the approval of a supervisor instead of posted
+func transferHeld(sess *session.Session, targetID int, amount float64, err error) {
+	logEvent(sess, journal.EventTransfer, fmt.Sprintf("tujuan=%d jumlah=%.2f ditahan: %v", targetID, amount, err))
+	say(sess, "transfer.pending_approval", formatMoney(sess, amount))
+	var pending *transaction.PendingApprovalError
+	if errors.As(err, &pending) {
+		say(sess, "transfer.pending_number", pending.Transfer.ID, pending.Transfer.ExpiresAt)
 	}
-	return balance, nil
+	if balance, err := core.CheckBalance(sess); err == nil {
+		sayAvailable(sess, balance)
+	}
+	say(sess, "common.back_to_menu")
 }
 
 // Deposits money into the account
-func deposit() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk deposit.")
+func deposit(sess *session.Session) {
+	if !authorize(sess, session.PermDeposit, "deposit.login_first") {
 		return
 	}
 
-	var amount float64
-	for {
-		fmt.Print("Masukkan jumlah deposit: ")
-		_, err := fmt.Scanln(&amount)
-		if err != nil || amount <= 0 {
-			fmt.Println("Jumlah uang tidak valid, coba lagi.")
-			continue
-		}
-		break
+	amount, err := askAmount(sess, tr(sess).T("deposit.amount_prompt"))
+	if err != nil {
+		return
 	}
 
-	// Call the deposit function from the transaction package
-	err := transaction.Deposit(currentUser.ID, amount)
+	// Send the deposit to the ledger or the remote host
+	err = core.Deposit(sess, amount)
 	if err != nil {
-		fmt.Println("Gagal melakukan deposit:", err)
+		logEvent(sess, journal.EventError, "deposit: "+err.Error())
+		fail(sess, "deposit.failed", err)
 		return
 	}
 
-	// Retrieve the updated balance after deposit
-	updatedBalance, err := getUpdatedBalance(currentUser.ID)
+	logEvent(sess, journal.EventDeposit, fmt.Sprintf("jumlah=%.2f", amount))
+
+	// Retrieve the updated balance after deposit; the deposit went through even if this fails
+	updatedBalance, err := core.CheckBalance(sess)
 	if err != nil {
-		fmt.Println("Gagal memeriksa saldo:", err)
+		say(sess, "deposit.success_no_balance")
+		fail(sess, "balance.failed", err)
 		return
 	}
 
 	// Display the updated balance after deposit
-	fmt.Printf("Deposit berhasil! Saldo Anda sekarang: %s\n", formatCurrencyWithSeparator(updatedBalance))
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "deposit.success", formatMoney(sess, updatedBalance))
+	offerReceipt(sess, "DEPOSIT", amount, updatedBalance)
+	say(sess, "common.back_to_menu")
 }
 
 // Withdraws money from the account
-func withdraw() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melakukan penarikan.")
+func withdraw(sess *session.Session) {
+	if !authorize(sess, session.PermWithdraw, "withdraw.login_first") {
 		return
 	}
 
-	var amount float64
-	for {
-		fmt.Print("Masukkan jumlah penarikan: ")
-		_, err := fmt.Scanln(&amount)
-		if err != nil || amount <= 0 {
-			fmt.Println("Jumlah uang tidak valid, coba lagi.")
-			continue
-		}
-		break
+	amount, err := askAmount(sess, tr(sess).T("withdraw.amount_prompt"))
+	if err != nil {
+		return
 	}
 
-	// Call the withdraw function from the transaction package
-	err := transaction.Withdraw(currentUser.ID, amount)
+	// Send the withdrawal to the ledger or the remote host
+	err = core.Withdraw(sess, amount)
 	if err != nil {
-		fmt.Println("Gagal melakukan penarikan:", err)
+		logEvent(sess, journal.EventDispenseFail, fmt.Sprintf("jumlah=%.2f: %v", amount, err))
+		fail(sess, "withdraw.failed", err)
 		return
 	}
 
-	// Retrieve the updated balance after withdrawal
-	updatedBalance, err := getUpdatedBalance(currentUser.ID)
+	logEvent(sess, journal.EventDispense, fmt.Sprintf("jumlah=%.2f", amount))
+
+	// Retrieve the updated balance after withdrawal; the cash is dispensed even if this fails
+	updatedBalance, err := core.CheckBalance(sess)
 	if err != nil {
-		fmt.Println("Gagal memeriksa saldo:", err)
+		say(sess, "withdraw.success_no_balance")
+		fail(sess, "balance.failed", err)
 		return
 	}
 
 	// Display the updated balance after withdrawal
-	fmt.Printf("Penarikan berhasil! Saldo Anda sekarang: %s\n", formatCurrencyWithSeparator(updatedBalance))
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "withdraw.success", formatMoney(sess, updatedBalance))
+	offerReceipt(sess, "PENARIKAN", amount, updatedBalance)
+	say(sess, "common.back_to_menu")
 }
 
 // Transfers money to another account
-func transfer() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melakukan transfer.")
+func transfer(sess *session.Session) {
+	if !authorize(sess, session.PermTransfer, "transfer.login_first") {
+		return
+	}
+
+	// Enter the bank of the target account, transfers to other banks go through clearing
+	bankCode, err := ask(sess, tr(sess).T("transfer.bank_prompt", user.BankCode))
+	if err != nil {
+		return
+	}
+	if bankCode != "" && bankCode != user.BankCode {
+		transferInterbank(sess, bankCode)
 		return
 	}
 
 	// Enter the target account ID
-	fmt.Print("Masukkan ID akun tujuan: ")
-	var targetID int
-	fmt.Scanln(&targetID)
+	targetID, err := askInt(sess, tr(sess).T("transfer.target_prompt"))
+	if err == errSessionTimeout || isInputClosed(err) {
+		return
+	}
 
 	// Check if the target account exists
-	var targetAccount user.Account
-	err := db.DB.Get(&targetAccount, "SELECT id, name FROM accounts WHERE id = ?", targetID)
+	targetAccount, err := core.FindTarget(sess, targetID)
 	if err != nil {
 		// If the target account is not found
-		fmt.Println("User ID tujuan tidak terdaftar.")
+		say(sess, "transfer.target_not_found")
 		return
 	}
 
 	// Display target account information
-	fmt.Printf("Akun tujuan ditemukan: %s (ID: %d)\n", targetAccount.Name, targetAccount.ID)
+	say(sess, "transfer.target_found", targetAccount.Name, targetAccount.ID)
+
+	// Accounts in another currency are credited the converted amount
+	if targetAccount.Currency != sess.Currency() {
+		transferFX(sess, *targetAccount)
+		return
+	}
 
 	// Ask for user confirmation before transfer
-	var confirm string
-	fmt.Print("Apakah Anda yakin ingin mentransfer ke akun ini? (y/n): ")
-	fmt.Scanln(&confirm)
+	confirm, err := ask(sess, tr(sess).T("transfer.confirm_prompt"))
+	if err != nil {
+		return
+	}
 
 	if confirm != "y" && confirm != "Y" {
-		fmt.Println("Transfer dibatalkan. Kembali ke menu utama.")
+		say(sess, "transfer.cancelled")
 		return
 	}
 
 	// Enter the transfer amount
-	var amount float64
-	for {
-		fmt.Print("Masukkan jumlah transfer: ")
-		_, err := fmt.Scanln(&amount)
-		if err != nil || amount <= 0 {
-			fmt.Println("Jumlah uang tidak valid, coba lagi.")
-			continue
-		}
-		break
+	amount, err := askAmount(sess, tr(sess).T("transfer.amount_prompt"))
+	if err != nil {
+		return
 	}
 
 	// Perform the transfer
-	err = transaction.Transfer(currentUser.ID, targetID, amount)
+	err = core.Transfer(sess, targetID, amount)
+	if errors.Is(err, transaction.ErrPendingApproval) {
+		transferHeld(sess, targetID, amount, err)
+		return
+	}
 	if err != nil {
-		fmt.Println("Gagal melakukan transfer:", err)
+		logEvent(sess, journal.EventError, fmt.Sprintf("transfer ke %d: %v", targetID, err))
+		fail(sess, "transfer.failed", err)
 		return
 	}
 
-	// Retrieve the updated balance after transfer
-	updatedBalance, err := getUpdatedBalance(currentUser.ID)
+	logEvent(sess, journal.EventTransfer, fmt.Sprintf("tujuan=%d jumlah=%.2f", targetID, amount))
+
+	// Retrieve the updated balance after transfer; the transfer went through even if this fails
+	updatedBalance, err := core.CheckBalance(sess)
 	if err != nil {
-		fmt.Println("Gagal memeriksa saldo:", err)
+		say(sess, "transfer.success_no_balance")
+		fail(sess, "balance.failed", err)
 		return
 	}
 
 	// Display the updated balance after transfer
-	fmt.Printf("Transfer berhasil! Saldo Anda sekarang: %s\n", formatCurrencyWithSeparator(updatedBalance))
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "transfer.success", formatMoney(sess, updatedBalance))
+	offerReceipt(sess, "TRANSFER", amount, updatedBalance)
+	say(sess, "common.back_to_menu")
+}
+
+// Transfers money to an account in another currency at the quoted rate
+// The rate and the converted amount are shown before the customer confirms
+func transferFX(sess *session.Session, target user.Account) {
+	if rates == nil {
+		say(sess, "fx.unavailable")
+		return
+	}
+
+	amount, err := askAmount(sess, tr(sess).T("fx.amount_prompt", sess.Currency()))
+	if err != nil {
+		return
+	}
+
+	quote, err := rates.Quote(sess.Currency(), target.Currency, amount)
+	if err != nil {
+		fail(sess, "fx.quote_failed", err)
+		return
+	}
+	say(sess, "fx.rate", quote.From, tr(sess).Number(quote.Rate, 6), quote.To)
+	say(sess, "fx.summary", currency.Format(quote.Amount, quote.From), target.Name, currency.Format(quote.Converted, quote.To))
+
+	confirm, err := ask(sess, tr(sess).T("fx.confirm_prompt"))
+	if err != nil {
+		return
+	}
+	if confirm != "y" && confirm != "Y" {
+		say(sess, "transfer.cancelled")
+		return
+	}
+
+	err = core.TransferFX(sess, target.ID, quote)
+	if errors.Is(err, transaction.ErrPendingApproval) {
+		transferHeld(sess, target.ID, quote.Amount, err)
+		return
+	}
+	if err != nil {
+		logEvent(sess, journal.EventError, fmt.Sprintf("transfer %s ke %d: %v", quote.To, target.ID, err))
+		fail(sess, "transfer.failed", err)
+		return
+	}
+
+	logEvent(sess, journal.EventTransfer, fmt.Sprintf("tujuan=%d jumlah=%.2f %s kurs=%g diterima=%.2f %s",
+		target.ID, quote.Amount, quote.From, quote.Rate, quote.Converted, quote.To))
+
+	updatedBalance, err := core.CheckBalance(sess)
+	if err != nil {
+		say(sess, "transfer.success_no_balance")
+		fail(sess, "balance.failed", err)
+		return
+	}
+	say(sess, "transfer.success", formatMoney(sess, updatedBalance))
+	offerReceipt(sess, "TRANSFER", amount, updatedBalance)
+	say(sess, "common.back_to_menu")
+}
+
+// Transfers money to an account at another bank through the clearing network
+func transferInterbank(sess *session.Session, bankCode string) {
+	accountID, err := askInt(sess, tr(sess).T("interbank.account_prompt"))
+	if err == errSessionTimeout || isInputClosed(err) {
+		return
+	}
+
+	// Ask the beneficiary bank for the account holder's name before confirming
+	name, err := clearing.Inquire(bankCode, accountID)
+	if err != nil {
+		fail(sess, "interbank.inquiry_failed", err)
+		return
+	}
+	say(sess, "interbank.account_found", name, bankCode, accountID)
+
+	confirm, err := ask(sess, tr(sess).T("interbank.confirm_prompt"))
+	if err != nil {
+		return
+	}
+	if confirm != "y" && confirm != "Y" {
+		say(sess, "transfer.cancelled")
+		return
+	}
+
+	amount, err := askAmount(sess, tr(sess).T("transfer.amount_prompt"))
+	if err != nil {
+		return
+	}
+
+	t, err := core.TransferInterbank(sess, bankCode, accountID, amount)
+	if errors.Is(err, transaction.ErrPendingApproval) {
+		transferHeld(sess, accountID, amount, err)
+		return
+	}
+	if err != nil {
+		logEvent(sess, journal.EventError, fmt.Sprintf("transfer antarbank ke %s/%d: %v", bankCode, accountID, err))
+		fail(sess, "transfer.failed", err)
+		return
+	}
+
+	logEvent(sess, journal.EventTransfer, fmt.Sprintf("bank=%s tujuan=%d jumlah=%.2f ref=%s", bankCode, accountID, amount, t.Reference))
+	say(sess, "interbank.accepted", t.Reference)
+
+	updatedBalance, err := core.CheckBalance(sess)
+	if err != nil {
+		fail(sess, "balance.failed", err)
+		return
+	}
+	say(sess, "interbank.balance", formatMoney(sess, updatedBalance))
+	offerReceipt(sess, "TRANSFER", amount, updatedBalance)
+	say(sess, "common.back_to_menu")
 }
 
 // Displays the profile of the logged-in account
-func viewProfile() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melihat profil.")
+func viewProfile(sess *session.Session) {
+	if !authorize(sess, session.PermProfile, "profile.login_first") {
 		return
 	}
 
 	// Display the account profile information
-	fmt.Printf("\n===== Profil Akun =====\n")
-	fmt.Printf("ID Akun: %d\n", currentUser.ID)
-	fmt.Printf("Nama: %s\n", currentUser.Name)
-	balance, err := user.CheckBalance(currentUser.ID)
+	fmt.Println()
+	say(sess, "profile.title")
+	say(sess, "profile.account_id", sess.AccountID())
+	say(sess, "profile.name", sess.Account.Name)
+	balance, err := core.CheckBalance(sess)
 	if err != nil {
-		fmt.Println("Gagal memeriksa saldo:", err)
+		fail(sess, "balance.failed", err)
 		return
 	}
-	fmt.Printf("Saldo: %s\n", formatCurrencyWithSeparator(balance))
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "profile.balance", formatMoney(sess, balance))
+	say(sess, "common.back_to_menu")
 }
 
 // Changes the PIN of the logged-in account
-func changePIN() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk mengganti PIN.")
+func changePIN(sess *session.Session) {
+	if !authorize(sess, session.PermChangePIN, "pin.login_first") {
 		return
 	}
 
 	// Ask for the old PIN to verify the user
-	fmt.Print("Masukkan PIN lama: ")
-	var oldPIN string
-	fmt.Scanln(&oldPIN)
+	oldPIN, err := askPIN(sess, tr(sess), "pin.old_prompt")
+	if err != nil {
+		return
+	}
 
-	// Verify the old PIN
-	if currentUser.PIN != oldPIN {
-		fmt.Println("PIN lama salah. Gagal mengganti PIN.")
+	// Verify the old PIN against the stored one, the session does not keep the PIN
+	match, err := user.MatchPIN(sess.Principal.ID, oldPIN)
+	if err != nil {
+		fail(sess, "pin.failed", err)
+		return
+	}
+	if !match {
+		audit.RecordOrLog(sess.Principal.Name, audit.ActionPINChange, sess.AccountID(), audit.OutcomeFailure, sess.Terminal.String(), "PIN lama salah")
+		say(sess, "pin.old_wrong")
 		return
 	}
 
 	// Ask for the new PIN
-	fmt.Print("Masukkan PIN baru: ")
-	var newPIN string
-	fmt.Scanln(&newPIN)
+	newPIN, err := askNewPIN(sess, tr(sess), "pin.new_prompt")
+	if err != nil {
+		return
+	}
 
 	// Update the PIN in the database
-	err := user.ChangePIN(currentUser.ID, newPIN)
+	err = user.ChangePIN(sess.AccountID(), newPIN, sess.Terminal.String())
 	if err != nil {
-		fmt.Println("Gagal mengganti PIN:", err)
+		fail(sess, "pin.failed", err)
 		return
 	}
+	if err := sess.Refresh(); err != nil {
+		log.Println("Gagal memuat ulang sesi:", err)
+	}
 
-	fmt.Println("PIN berhasil diganti.")
+	say(sess, "pin.success")
+}
+
+// historyTypeLabels are the catalog keys of the names of the transaction types
+var historyTypeLabels = map[string]string{
+	"deposit":           "history.type_deposit",
+	"withdraw":          "history.type_withdraw",
+	"transfer_in":       "history.type_transfer_in",
+	"transfer_out":      "history.type_transfer_out",
+	"interbank_out":     "history.type_interbank_out",
+	"interbank_in":      "history.type_interbank_in",
+	"interbank_refund":  "history.type_interbank_refund",
+	"adjustment_credit": "history.type_adjustment_credit",
+	"adjustment_debit":  "history.type_adjustment_debit",
+	"payout":            "history.type_payout",
+	"capture":           "history.type_capture",
+	"hold":              "history.type_hold",
+	"interest":          "history.type_interest",
+	"fee":               "history.type_fee",
 }
 
 // Displays transaction history based on type (deposit, withdrawal, etc.)
-func viewTransactionHistory() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melihat riwayat transaksi.")
+func viewTransactionHistory(sess *session.Session) {
+	if !authorize(sess, session.PermHistory, "history.login_first") {
 		return
 	}
 
 	// Display transaction type options
-	fmt.Println("\n===== Pilih Jenis Transaksi =====")
-	fmt.Println("1. Transferan Masuk")
-	fmt.Println("2. Transferan Keluar")
-	fmt.Println("3. Withdraw")
-	fmt.Println("4. Deposit")
-	fmt.Println("5. Kembali ke menu utama")
-
-	var choice int
-	fmt.Print("Pilih menu (1-5): ")
-	_, err := fmt.Scanln(&choice)
-
-	if err != nil || choice < 1 || choice > 5 {
-		fmt.Println("Pilihan tidak valid, coba lagi.")
+	fmt.Println()
+	say(sess, "history.menu_title")
+	say(sess, "history.menu_transfer_in")
+	say(sess, "history.menu_transfer_out")
+	say(sess, "history.menu_withdraw")
+	say(sess, "history.menu_deposit")
+	say(sess, "history.menu_hold")
+	say(sess, "history.menu_back")
+
+	choice, err := askInt(sess, tr(sess).T("menu.prompt", 6))
+	if err == errSessionTimeout || isInputClosed(err) {
+		return
+	}
+	if err != nil || choice < 1 || choice > 6 {
+		say(sess, "menu.invalid_choice")
 		return
 	}
 
@@ -388,23 +688,26 @@ func viewTransactionHistory() {
 	case 2:
 		transactionType = "transfer_out"
 	case 3:
-		viewWithdrawHistory()
+		viewWithdrawHistory(sess)
 		return
 	case 4:
-		viewDepositHistory()
+		viewDepositHistory(sess)
 		return
 	case 5:
+		transactionType = "hold"
+	case 6:
 		return
 	}
 
-	transactions, err := transaction.ViewTransactionHistory(currentUser.ID, transactionType)
+	transactions, err := transaction.ViewTransactionHistory(sess.AccountID(), transactionType)
 	if err != nil {
-		fmt.Println("Gagal memuat riwayat transaksi:", err)
+		fail(sess, "history.failed", err)
 		return
 	}
 
 	// Display transaction history
-	fmt.Println("\n===== Riwayat Transaksi =====")
+	fmt.Println()
+	say(sess, "history.title")
 	for _, trans := range transactions {
 		tType := trans["type"].(string)
 		amount := trans["amount"].(float64)
@@ -412,9 +715,9 @@ func viewTransactionHistory() {
 		createdAt := trans["created_at"].(string)
 
 		// Display transaction information
-		fmt.Printf("Tipe Transaksi: %s\n", tType)
-		fmt.Printf("Jumlah: %s\n", formatCurrencyWithSeparator(amount))
-		fmt.Printf("Tanggal: %s\n", createdAt)
+		say(sess, "history.type", tr(sess).T(historyTypeLabels[tType]))
+		say(sess, "history.amount", formatMoney(sess, amount))
+		say(sess, "history.date", tr(sess).Timestamp(createdAt))
 
 		// Handle targetID which is an interface{} and perform type assertion
 		if targetID != nil {
@@ -424,13 +727,13 @@ func viewTransactionHistory() {
 					var targetName string
 					err := db.DB.Get(&targetName, "SELECT name FROM accounts WHERE id = ?", *tID)
 					if err != nil {
-						fmt.Println("Gagal mendapatkan nama akun tujuan:", err)
+						fail(sess, "history.target_name_failed", err)
 					} else {
-						fmt.Printf("Nama: %s\n", targetName)
+						say(sess, "history.name", targetName)
 					}
 				}
 
-				fmt.Printf("Target ID: %d\n", *tID) // Dereference pointer if successful
+				say(sess, "history.target_id", *tID) // Dereference pointer if successful
 			} else {
 				fmt.Println("Error: targetID type mismatch.")
 			}
@@ -438,81 +741,273 @@ func viewTransactionHistory() {
 
 		fmt.Println("-----------------------------------")
 	}
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "common.back_to_menu")
 }
 
 // Displays deposit transaction history
-func viewDepositHistory() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melihat riwayat transaksi deposit.")
+func viewDepositHistory(sess *session.Session) {
+	if !authorize(sess, session.PermHistory, "history.login_first") {
 		return
 	}
 
 	// Display deposit transaction history
-	transactions, err := transaction.ViewTransactionHistory(currentUser.ID, "deposit")
+	transactions, err := transaction.ViewTransactionHistory(sess.AccountID(), "deposit")
 	if err != nil {
-		fmt.Println("Gagal memuat riwayat transaksi deposit:", err)
+		fail(sess, "history.failed", err)
 		return
 	}
 
-	fmt.Println("\n===== Riwayat Transaksi Deposit =====")
+	fmt.Println()
+	say(sess, "history.title_deposit")
 	for _, trans := range transactions {
 		amount := trans["amount"].(float64)
 		createdAt := trans["created_at"].(string)
 
 		// Display deposit transaction information
-		fmt.Printf("Tipe Transaksi: Deposit\n")
-		fmt.Printf("Jumlah: %s\n", formatCurrencyWithSeparator(amount))
-		fmt.Printf("Tanggal: %s\n", createdAt)
+		say(sess, "history.type", tr(sess).T("history.type_deposit"))
+		say(sess, "history.amount", formatMoney(sess, amount))
+		say(sess, "history.date", tr(sess).Timestamp(createdAt))
 		fmt.Println("-----------------------------------")
 	}
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "common.back_to_menu")
 }
 
 // Displays withdrawal transaction history
-func viewWithdrawHistory() {
-	if currentUser == nil {
-		fmt.Println("Silakan login terlebih dahulu untuk melihat riwayat transaksi withdraw.")
+func viewWithdrawHistory(sess *session.Session) {
+	if !authorize(sess, session.PermHistory, "history.login_first") {
 		return
 	}
 
 	// Display withdrawal transaction history
-	transactions, err := transaction.ViewTransactionHistory(currentUser.ID, "withdraw")
+	transactions, err := transaction.ViewTransactionHistory(sess.AccountID(), "withdraw")
 	if err != nil {
-		fmt.Println("Gagal memuat riwayat transaksi withdraw:", err)
+		fail(sess, "history.failed", err)
 		return
 	}
 
-	fmt.Println("\n===== Riwayat Transaksi Withdraw =====")
+	fmt.Println()
+	say(sess, "history.title_withdraw")
 	for _, trans := range transactions {
 		amount := trans["amount"].(float64)
 		createdAt := trans["created_at"].(string)
 
 		// Display withdrawal transaction information
-		fmt.Printf("Tipe Transaksi: Withdraw\n")
-		fmt.Printf("Jumlah: %s\n", formatCurrencyWithSeparator(amount))
-		fmt.Printf("Tanggal: %s\n", createdAt)
+		say(sess, "history.type", tr(sess).T("history.type_withdraw"))
+		say(sess, "history.amount", formatMoney(sess, amount))
+		say(sess, "history.date", tr(sess).Timestamp(createdAt))
 		fmt.Println("-----------------------------------")
 	}
-	fmt.Println("Kembali ke menu utama...\n")
+	say(sess, "common.back_to_menu")
 }
 
 // Logs out of the application
-func logOut() {
-	if currentUser == nil {
-		fmt.Println("Anda belum login.")
+func logOut(sess *session.Session) {
+	if sess == nil {
+		say(sess, "logout.not_logged_in")
+		return
+	}
+	endSession(sess, session.ReasonLogout)
+	say(sess, "logout.success")
+	say(sess, "common.back_to_menu")
+}
+
+// receiptLabels are the catalog keys of the receipt line of each transaction kind
+var receiptLabels = map[string]string{
+	"DEPOSIT":   "receipt.deposit",
+	"PENARIKAN": "receipt.withdraw",
+	"TRANSFER":  "receipt.transfer",
+}
+
+// Asks whether a receipt is wanted and prints it for the given transaction
+func offerReceipt(sess *session.Session, kind string, amount, balance float64) {
+	answer, err := ask(sess, tr(sess).T("receipt.prompt"))
+	if err != nil {
+		return
+	}
+	if answer != "y" && answer != "Y" {
 		return
 	}
-	currentUser = nil
-	fmt.Println("Anda telah berhasil log out.")
-	fmt.Println("Kembali ke menu utama...\n")
+
+	printReceipt([]string{
+		tr(sess).DateTime(time.Now()),
+		tr(sess).T("receipt.account_id", sess.AccountID()),
+		tr(sess).T("receipt.line", tr(sess).T(receiptLabels[kind]), formatMoney(sess, amount)),
+		tr(sess).T("receipt.balance", formatMoney(sess, balance)),
+	})
+	logEvent(sess, journal.EventReceipt, fmt.Sprintf("%s jumlah=%.2f", kind, amount))
+}
+
+// printReceipt prints the lines of a receipt between tear-off lines
+func printReceipt(lines []string) {
+	fmt.Println("-----------------------------------")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	fmt.Println("-----------------------------------")
+}
+
+// Shows the last transactions of every type with the balance after each, in the layout of a
+// receipt, and prints it on request
+func miniStatement(sess *session.Session) {
+	if !authorize(sess, session.PermHistory, "mini.login_first") {
+		return
+	}
+
+	m, err := statement.GenerateMini(sess.AccountID(), miniStatementLength)
+	if err != nil {
+		fail(sess, "mini.failed", err)
+		return
+	}
+	lines := m.Receipt(tr(sess))
+	fmt.Println()
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	answer, err := ask(sess, tr(sess).T("receipt.prompt"))
+	if err == nil && (answer == "y" || answer == "Y") {
+		printReceipt(lines)
+		logEvent(sess, journal.EventReceipt, fmt.Sprintf("MINI STATEMENT transaksi=%d", len(m.Lines)))
+	}
+	say(sess, "common.back_to_menu")
+}
+
+// Loads the rate table, a missing file only disables transfers between currencies
+func loadRates(path string) error {
+	r, err := currency.LoadRates(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Berkas kurs %s tidak ditemukan, transfer antar mata uang tidak tersedia", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rates = r
+	return nil
 }
 
 // Main function to run the ATM application
 func main() {
-	// Initialize database connection
-	db.InitDB()
+	app := &cli.App{
+		Name:  "atm",
+		Usage: "Simulasi mesin ATM",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "bank-code", Value: user.BankCode, EnvVars: []string{"ATM_BANK_CODE"}, Usage: "kode bank pemilik terminal"},
+			&cli.BoolFlag{Name: "tui", EnvVars: []string{"ATM_TUI"}, Usage: "tampilan layar penuh seperti mesin ATM; tanpa flag ini menu teks biasa untuk skrip"},
+			&cli.StringFlag{Name: "lang", Value: i18n.Default, EnvVars: []string{"ATM_LANG"}, Usage: "bahasa layar sebelum nasabah memilih bahasa (id, en)"},
+			&cli.StringFlag{Name: "terminal-id", Value: "ATM-001", EnvVars: []string{"ATM_TERMINAL_ID"}, Usage: "ID terminal ATM"},
+			&cli.StringFlag{Name: "journal-dir", Value: "journal", EnvVars: []string{"ATM_JOURNAL_DIR"}, Usage: "direktori jurnal elektronik"},
+			&cli.Int64Flag{Name: "journal-max-size", Value: journal.DefaultMaxSize, Usage: "ukuran maksimum berkas jurnal sebelum dirotasi (byte)"},
+			&cli.DurationFlag{Name: "idle-timeout", Value: session.DefaultIdleTimeout, Usage: "batas waktu tanpa aktivitas sebelum log out otomatis"},
+			&cli.DurationFlag{Name: "session-timeout", Value: session.DefaultAbsoluteTimeout, Usage: "batas waktu maksimum satu sesi"},
+			&cli.StringFlag{Name: "host", EnvVars: []string{"ATM_HOST"}, Usage: "alamat host ISO 8583; jika diisi, penarikan, transfer dan cek saldo dikirim ke host"},
+			&cli.StringFlag{Name: "iso-spec", Usage: "berkas JSON spec field ISO 8583 (default spec bawaan)"},
+			&cli.StringFlag{Name: "reversal-file", Value: "reversals.json", EnvVars: []string{"ATM_REVERSAL_FILE"}, Usage: "berkas antrean reversal yang belum disetujui host"},
+			&cli.StringFlag{Name: "standin-dir", Value: "standin", EnvVars: []string{"ATM_STANDIN_DIR"}, Usage: "direktori snapshot saldo dan antrean transaksi offline"},
+			&cli.StringFlag{Name: "rates", Value: "rates.json", EnvVars: []string{"ATM_RATES"}, Usage: "berkas JSON kurs beli/jual; tanpa berkas ini transfer antar mata uang dimatikan"},
+			&cli.IntFlag{Name: "pin-length", Value: user.DefaultPINPolicy.Length, EnvVars: []string{"ATM_PIN_LENGTH"}, Usage: "jumlah angka PIN baru"},
+			&cli.IntFlag{Name: "pin-history", Value: user.DefaultPINPolicy.History, EnvVars: []string{"ATM_PIN_HISTORY"}, Usage: "jumlah PIN terakhir yang tidak boleh dipakai lagi; 0 mengizinkan pemakaian ulang"},
+			&cli.BoolFlag{Name: "pin-reject-dates", Value: user.DefaultPINPolicy.RejectDates, EnvVars: []string{"ATM_PIN_REJECT_DATES"}, Usage: "tolak PIN baru yang berupa tanggal, seperti tanggal lahir"},
+			&cli.Float64Flag{Name: "transfer-approval-threshold", Value: transaction.DefaultApprovalThreshold, EnvVars: []string{"ATM_TRANSFER_APPROVAL_THRESHOLD"}, Usage: "transfer di atas jumlah ini (dalam mata uang pengirim) menunggu persetujuan supervisor; 0 mematikan persetujuan"},
+			&cli.DurationFlag{Name: "transfer-approval-ttl", Value: transaction.DefaultApprovalTTL, EnvVars: []string{"ATM_TRANSFER_APPROVAL_TTL"}, Usage: "lama transfer menunggu persetujuan sebelum kedaluwarsa dan dananya dilepas"},
+			&cli.IntFlag{Name: "mini-statement-length", Value: statement.DefaultMiniLength, EnvVars: []string{"ATM_MINI_STATEMENT_LENGTH"}, Usage: fmt.Sprintf("jumlah transaksi pada mini statement (1-%d)", statement.MaxMiniLength)},
+			&cli.Float64Flag{Name: "offline-limit", Value: 1000000, EnvVars: []string{"ATM_OFFLINE_LIMIT"}, Usage: "batas total penarikan per akun saat offline; 0 mematikan mode stand-in"},
+		},
+		Before: func(c *cli.Context) error {
+			user.BankCode = c.String("bank-code")
+			user.Policy = user.PINPolicy{
+				Length:      c.Int("pin-length"),
+				History:     c.Int("pin-history"),
+				RejectDates: c.Bool("pin-reject-dates"),
+			}
+			if c.Float64("transfer-approval-threshold") < 0 || c.Duration("transfer-approval-ttl") <= 0 {
+				return fmt.Errorf("batas dan masa berlaku persetujuan transfer tidak valid")
+			}
+			transaction.ApprovalThreshold = c.Float64("transfer-approval-threshold")
+			transaction.ApprovalTTL = c.Duration("transfer-approval-ttl")
+			return user.Policy.Validate()
+		},
+		Commands: []*cli.Command{
+			journalCommand(),
+			auditCommand(),
+			serveCommand(),
+			grpcCommand(),
+			hostCommand(),
+			terminalCommand(),
+			fleetCommand(),
+			standinCommand(),
+			clearingCommand(),
+			holdCommand(),
+			statementCommand(),
+			exportCommand(),
+			importCommand(),
+			reconcileCommand(),
+			eodCommand(),
+			standingOrderCommand(),
+			adminCommand(),
+		},
+		Action: func(c *cli.Context) error {
+			terminalID = c.String("terminal-id")
+			if !i18n.Supported(c.String("lang")) {
+				return fmt.Errorf("bahasa %q tidak didukung", c.String("lang"))
+			}
+			lang = c.String("lang")
+			miniStatementLength = c.Int("mini-statement-length")
+			if miniStatementLength < 1 || miniStatementLength > statement.MaxMiniLength {
+				return statement.ErrInvalidLength
+			}
+			idleTimeout = c.Duration("idle-timeout")
+			absoluteTimeout = c.Duration("session-timeout")
+			if err := openStandIn(c); err != nil {
+				return err
+			}
+			if err := loadRates(c.String("rates")); err != nil {
+				return err
+			}
+
+			// A terminal working through a remote host needs no database of its own
+			if err := connectHost(c); err != nil {
+				return err
+			}
 
-	// Start the application with interactive menu
-	handleChoice(nil)
+			// Initialize database connection, falling back to stand-in mode when it is down
+			if hostClient != nil {
+				defer hostClient.Close()
+				stop := make(chan struct{})
+				defer close(stop)
+				go hostClient.Watch(30*time.Second, stop)
+			} else if err := db.InitDB(); err != nil {
+				if standIn == nil {
+					return err
+				}
+				log.Println("Database tidak tersedia, terminal berjalan dalam mode stand-in:", err)
+			} else if standIn != nil {
+				syncStandIn(standIn)
+			}
+			if standIn != nil {
+				core = standinBank{online: core, store: standIn}
+				stop := make(chan struct{})
+				defer close(stop)
+				go standIn.Watch(30*time.Second, stop)
+			}
+			if err := openJournal(c); err != nil {
+				return err
+			}
+			defer ej.Close()
+			logEvent(nil, journal.EventTerminalUp, "")
+			defer logEvent(nil, journal.EventTerminalDown, "")
+
+			// The full-screen UI reads the keyboard itself, the text menu goes through the console
+			if c.Bool("tui") {
+				return runTUI()
+			}
+			stdin = newConsole(os.Stdin)
+			handleChoice(c)
+			return nil
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/user"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Control characters of the PIN pad in raw mode
+const (
+	keyInterrupt = 0x03 // Ctrl+C
+	keyClearLine = 0x15 // Ctrl+U
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+)
+
+// escapeWait is how long to wait for the rest of an escape sequence
+// before taking the ESC as the cancel key on its own
+var escapeWait = 50 * time.Millisecond
+
+// errInputCancelled is returned when the masked entry of a PIN or password was cancelled
+var errInputCancelled = errors.New("input dibatalkan")
+
+// validPIN reports whether the PIN consists of digits only and has a valid length
+func validPIN(pin string) bool {
+	if len(pin) < user.MinPINLength || len(pin) > user.MaxPINLength {
+		return false
+	}
+	for _, ch := range pin {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// askPIN asks for a PIN until one of a valid length is entered
+// On a terminal the digits are not echoed but shown as asterisks;
+// piped input in scripted mode is read as a plain line
+func askPIN(sess *session.Session, p *i18n.Printer, key string) (string, error) {
+	if stdin.tty() {
+		fmt.Println(p.T("input.pin_keys"))
+	}
+	for {
+		pin, err := askWith(sess, p.T(key), stdin.readPIN)
+		if err == errInputCancelled {
+			fmt.Println(p.T("input.pin_cancelled"))
+			return "", err
+		}
+		if err != nil {
+			return "", err
+		}
+		if validPIN(pin) {
+			return pin, nil
+		}
+		fmt.Println(p.T("input.invalid_pin", user.MinPINLength, user.MaxPINLength))
+	}
+}
+
+// askNewPIN asks for a new PIN until one that satisfies the PIN policy is entered twice
+func askNewPIN(sess *session.Session, p *i18n.Printer, key string) (string, error) {
+	for {
+		pin, err := askPIN(sess, p, key)
+		if err != nil {
+			return "", err
+		}
+		if err := user.Policy.Check(pin); err != nil {
+			if err == user.ErrPINLength {
+				fmt.Println(p.T("pin.policy_length", user.Policy.Length))
+			} else {
+				fmt.Println(p.Error(err))
+			}
+			continue
+		}
+		confirm, err := askPIN(sess, p, "pin.confirm_prompt")
+		if err != nil {
+			return "", err
+		}
+		if confirm != pin {
+			fmt.Println(p.T("pin.confirm_mismatch"))
+			continue
+		}
+		return pin, nil
+	}
+}
+
+// tty reports whether the console reads from a terminal
+func (c *console) tty() bool {
+	return c.fd >= 0
+}
+
+// readPIN reads a PIN without echo, printing an asterisk for every digit
+// A timeout of zero waits forever
+func (c *console) readPIN(timeout time.Duration) (string, error) {
+	return c.readMasked(timeout, func(ch rune) bool { return ch >= '0' && ch <= '9' }, user.MaxPINLength)
+}
+
+// readMasked reads up to max accepted characters without echo, printing an asterisk for each
+// Backspace removes the last character, Del or Ctrl+U clears the entry and Esc or Ctrl+C cancels it
+func (c *console) readMasked(timeout time.Duration, accept func(rune) bool, max int) (string, error) {
+	if !c.tty() {
+		return c.readLine(timeout)
+	}
+	state, err := term.MakeRaw(c.fd)
+	if err != nil {
+		return c.readLine(timeout)
+	}
+	defer term.Restore(c.fd, state)
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var input []rune
+	clear := func() {
+		fmt.Print(strings.Repeat("\b \b", len(input)))
+		input = nil
+	}
+	for {
+		select {
+		case ch, ok := <-c.runes:
+			if !ok {
+				fmt.Print("\r\n")
+				return "", io.EOF
+			}
+			switch {
+			case ch == '\r' || ch == '\n':
+				fmt.Print("\r\n")
+				return string(input), nil
+			case ch == keyInterrupt:
+				fmt.Print("\r\n")
+				return "", errInputCancelled
+			case ch == keyEscape:
+				seq, ok := c.escapeSequence()
+				if !ok {
+					fmt.Print("\r\n")
+					return "", errInputCancelled
+				}
+				// The Delete key is the CLEAR key of the PIN pad
+				if seq == "[3~" {
+					clear()
+				}
+			case ch == keyClearLine:
+				clear()
+			case ch == keyBackspace || ch == '\b':
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+					fmt.Print("\b \b")
+				}
+			case accept(ch) && len(input) < max:
+				input = append(input, ch)
+				fmt.Print("*")
+			}
+		case <-deadline:
+			return "", errInputTimeout
+		}
+	}
+}
+
+// escapeSequence reads the rest of an escape sequence after ESC
+// It reports false for a lone ESC, which arrives without any following bytes
+func (c *console) escapeSequence() (string, bool) {
+	var seq []rune
+	for {
+		select {
+		case ch, ok := <-c.runes:
+			if !ok {
+				return string(seq), len(seq) > 0
+			}
+			seq = append(seq, ch)
+			// CSI and SS3 sequences end with a final byte, anything else is Alt plus a key
+			if len(seq) == 1 && ch != '[' && ch != 'O' {
+				return string(seq), true
+			}
+			if len(seq) > 1 && ch >= 0x40 && ch <= 0x7e {
+				return string(seq), true
+			}
+		case <-time.After(escapeWait):
+			return string(seq), len(seq) > 0
+		}
+	}
+}
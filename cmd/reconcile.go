@@ -0,0 +1,134 @@
+package main
+
+import (
+	"atm-simulation/internal/approval"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/reconcile"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// reconcileCommand builds the `atm reconcile` command comparing the balances with the transaction log
+func reconcileCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "reconcile",
+		Usage: "Cocokkan saldo setiap akun dengan riwayat transaksinya",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "propose-corrections", Usage: "ajukan entri koreksi untuk setiap selisih; berlaku setelah disetujui supervisor"},
+			&cli.DurationFlag{Name: "every", EnvVars: []string{"ATM_RECONCILE_EVERY"}, Usage: "jalankan terus setiap selang waktu ini; tanpa flag ini sekali saja"},
+			&cli.StringFlag{Name: "metrics-file", EnvVars: []string{"ATM_RECONCILE_METRICS"}, Usage: "tulis metrik format Prometheus ke berkas ini, misalnya untuk textfile collector node exporter"},
+		},
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Action: func(c *cli.Context) error {
+			every := c.Duration("every")
+			if every < 0 {
+				return fmt.Errorf("--every tidak boleh negatif")
+			}
+			if every == 0 {
+				result, err := runReconciliation(c)
+				if err != nil {
+					return err
+				}
+				if len(result.Mismatches) > 0 {
+					return cli.Exit(fmt.Sprintf("%d akun tidak sesuai dengan transaksinya.", len(result.Mismatches)), 1)
+				}
+				return nil
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			log.Printf("Rekonsiliasi berjalan setiap %s", every)
+			for {
+				// A failed run is logged and tried again at the next tick
+				if _, err := runReconciliation(c); err != nil {
+					log.Println("Rekonsiliasi gagal:", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-signals:
+					return nil
+				}
+			}
+		},
+	}
+}
+
+// runReconciliation runs one reconciliation, reports it and writes its metrics
+func runReconciliation(c *cli.Context) (*reconcile.Result, error) {
+	result, err := reconcile.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range result.Mismatches {
+		fmt.Printf("Akun %d (%s): saldo %s, menurut transaksi %s, selisih %s\n", m.AccountID, m.Name,
+			currency.Format(m.Balance, m.Currency), currency.Format(m.Computed, m.Currency), currency.Format(m.Difference, m.Currency))
+		since := "sejak rekonsiliasi terakhir yang sesuai"
+		if !m.Checked {
+			since = "sejak akun dibuka"
+		}
+		if m.First == nil {
+			fmt.Printf("  tidak ada transaksi %s; saldo berubah tanpa transaksi\n", since)
+		} else {
+			fmt.Printf("  %d transaksi %s, yang pertama #%d %s %s %.2f\n", m.Since, since, m.First.ID, m.First.CreatedAt, m.First.Type, m.First.Amount)
+		}
+	}
+	log.Printf("Rekonsiliasi: %d akun diperiksa, %d tidak sesuai (%s)", result.Accounts, len(result.Mismatches), result.Duration.Round(time.Millisecond))
+
+	if c.Bool("propose-corrections") {
+		if err := proposeCorrections(result.Mismatches); err != nil {
+			return nil, err
+		}
+	}
+	if path := c.String("metrics-file"); path != "" {
+		if err := result.WriteMetricsFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// proposeCorrections queues a correcting entry for every confirmed mismatch that has none waiting yet
+func proposeCorrections(mismatches []reconcile.Mismatch) error {
+	pending, err := approval.Pending()
+	if err != nil {
+		return err
+	}
+	waiting := make(map[int]bool)
+	for _, r := range pending {
+		if r.Operation == approval.OperationReconcile {
+			waiting[r.AccountID] = true
+		}
+	}
+
+	job := authz.Principal{Name: reconcile.Actor, Role: authz.RoleSystem}
+	for _, m := range mismatches {
+		if waiting[m.AccountID] {
+			fmt.Printf("  koreksi akun %d sudah menunggu persetujuan\n", m.AccountID)
+			continue
+		}
+		if !m.Confirmed {
+			fmt.Printf("  selisih akun %d baru ditemukan sekali, koreksi diajukan bila rekonsiliasi berikutnya menemukan selisih yang sama\n", m.AccountID)
+			continue
+		}
+		reason := fmt.Sprintf("saldo %.2f, menurut transaksi %.2f", m.Balance, m.Computed)
+		r, err := approval.Submit(job, approval.OperationReconcile, m.AccountID, m.Difference, reason, adminSource())
+		if err != nil {
+			return fmt.Errorf("akun %d: %w", m.AccountID, err)
+		}
+		fmt.Printf("  koreksi A%d untuk akun %d menunggu persetujuan supervisor\n", r.ID, m.AccountID)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"atm-simulation/internal/api"
+	"atm-simulation/pkg/db"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// serveCommand builds the `atm serve` command exposing the ATM operations over HTTP
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Jalankan server REST/JSON untuk semua operasi ATM",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "addr", Value: ":8080", EnvVars: []string{"ATM_HTTP_ADDR"}, Usage: "alamat yang didengarkan server"},
+		},
+		Action: func(c *cli.Context) error {
+			if err := db.InitDB(); err != nil {
+				return err
+			}
+
+			server := api.NewServer(c.String("terminal-id"), c.Duration("idle-timeout"), c.Duration("session-timeout"))
+			httpServer := &http.Server{Addr: c.String("addr"), Handler: server, ReadHeaderTimeout: 10 * time.Second}
+
+			stop := make(chan struct{})
+			go server.SweepSessions(30*time.Second, stop)
+
+			// Shut down gracefully on Ctrl+C
+			go func() {
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+				<-signals
+				close(stop)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				httpServer.Shutdown(ctx)
+			}()
+
+			log.Printf("Server API berjalan di %s", httpServer.Addr)
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "openapi",
+				Usage: "Cetak spesifikasi OpenAPI yang dihasilkan dari tabel rute",
+				Action: func(c *cli.Context) error {
+					server := api.NewServer(c.String("terminal-id"), c.Duration("idle-timeout"), c.Duration("session-timeout"))
+					encoder := json.NewEncoder(os.Stdout)
+					encoder.SetIndent("", "  ")
+					return encoder.Encode(server.OpenAPI())
+				},
+			},
+		},
+	}
+}
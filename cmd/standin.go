@@ -0,0 +1,225 @@
+package main
+
+import (
+	"atm-simulation/internal/clearing"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/journal"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/standin"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// standIn holds the snapshot and offline queue of the interactive terminal, nil when stand-in is disabled
+var standIn *standin.Store
+
+// openStandIn opens the stand-in store unless --offline-limit is 0 or the terminal works through a remote host
+// The snapshot is read from the database and the queue is posted there, neither of which the host offers,
+// so a terminal with --host declines withdrawals while the host is down
+func openStandIn(c *cli.Context) error {
+	if c.Float64("offline-limit") <= 0 {
+		return nil
+	}
+	if c.String("host") != "" {
+		if c.IsSet("offline-limit") {
+			log.Println("Mode stand-in tidak tersedia melalui host, --offline-limit diabaikan")
+		}
+		return nil
+	}
+	var err error
+	standIn, err = standin.Open(c.String("standin-dir"), c.String("terminal-id"), c.Float64("offline-limit"))
+	return err
+}
+
+// syncStandIn posts the offline queue and refreshes the snapshot while the database is reachable
+func syncStandIn(store *standin.Store) {
+	if len(store.Pending()) > 0 {
+		results, err := store.Replay()
+		logEvent(nil, journal.EventStandIn, fmt.Sprintf("%d transaksi offline dikirim ulang", len(results)))
+		if err != nil {
+			log.Println("Gagal mengirim ulang transaksi offline:", err)
+			return
+		}
+	}
+	if err := store.Snapshot(); err != nil {
+		log.Println("Gagal memperbarui snapshot stand-in:", err)
+	}
+}
+
+// online reports whether the database can be reached for online processing
+func online() bool {
+	return db.Online(2 * time.Second)
+}
+
+// standinBank processes online through the wrapped bank while the database is reachable
+// and falls back to the stand-in snapshot when it is not
+type standinBank struct {
+	online bank
+	store  *standin.Store
+}
+
+// offline reports whether the operation must be handled in stand-in mode
+func (b standinBank) offline(sess *session.Session) bool {
+	return sess.Offline || !online()
+}
+
+func (b standinBank) CheckBalance(sess *session.Session) (float64, error) {
+	if !b.offline(sess) {
+		return b.online.CheckBalance(sess)
+	}
+	return b.store.Balance(sess.AccountID())
+}
+
+// The snapshot caches the available balance, so that offline withdrawals cannot spend funds on hold
+func (b standinBank) AvailableBalance(sess *session.Session) (float64, error) {
+	if !b.offline(sess) {
+		balance, err := b.online.AvailableBalance(sess)
+		if err == nil {
+			b.store.Update(sess.AccountID(), balance)
+		}
+		return balance, err
+	}
+	return b.store.Balance(sess.AccountID())
+}
+
+// Withdraw only falls back to the snapshot when the database was already down before the request;
+// a request that failed on its way may have been posted, and approving it offline would debit twice
+func (b standinBank) Withdraw(sess *session.Session, amount float64) error {
+	if !b.offline(sess) {
+		// Post the offline queue first so the online balance includes it
+		if len(b.store.Pending()) > 0 {
+			syncStandIn(b.store)
+		}
+		return b.online.Withdraw(sess, amount)
+	}
+	entry, err := b.store.Withdraw(sess.AccountID(), amount)
+	if err != nil {
+		return err
+	}
+	logEvent(sess, journal.EventStandIn, fmt.Sprintf("ref=%s jumlah=%.2f", entry.Reference, amount))
+	return nil
+}
+
+func (b standinBank) Deposit(sess *session.Session, amount float64) error {
+	if b.offline(sess) {
+		return errServiceOffline
+	}
+	return b.online.Deposit(sess, amount)
+}
+
+func (b standinBank) FindTarget(sess *session.Session, targetID int) (*user.Account, error) {
+	if b.offline(sess) {
+		return nil, errServiceOffline
+	}
+	return b.online.FindTarget(sess, targetID)
+}
+
+func (b standinBank) Transfer(sess *session.Session, targetID int, amount float64) error {
+	if b.offline(sess) {
+		return errServiceOffline
+	}
+	return b.online.Transfer(sess, targetID, amount)
+}
+
+func (b standinBank) TransferFX(sess *session.Session, targetID int, quote currency.Quote) error {
+	if b.offline(sess) {
+		return errServiceOffline
+	}
+	return b.online.TransferFX(sess, targetID, quote)
+}
+
+func (b standinBank) TransferInterbank(sess *session.Session, bankCode string, accountID int, amount float64) (*clearing.Transfer, error) {
+	if b.offline(sess) {
+		return nil, errServiceOffline
+	}
+	return b.online.TransferInterbank(sess, bankCode, accountID, amount)
+}
+
+// errServiceOffline is returned for operations that are not available in stand-in mode
+var errServiceOffline = i18n.NewError("error.service_offline", "layanan tidak tersedia saat terminal offline")
+
+// openOfflineSession authenticates a customer against the stand-in snapshot
+func openOfflineSession(name, pin, language string) (*session.Session, error) {
+	logEvent(nil, journal.EventCardIn, "nama="+name+" (offline)")
+
+	account, err := standIn.Login(name, pin)
+	if err != nil {
+		logEvent(nil, journal.EventPINFail, "offline: "+err.Error())
+		return nil, err
+	}
+	sess, err := session.StartOffline(account, session.Terminal{ID: terminalID}, idleTimeout, absoluteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	sess.Language = language
+
+	logEvent(sess, journal.EventPINOK, "offline")
+	return sess, nil
+}
+
+// standinCommand builds the `atm standin` command for inspecting and replaying the offline queue
+func standinCommand() *cli.Command {
+	open := func(c *cli.Context) (*standin.Store, error) {
+		return standin.Open(c.String("standin-dir"), c.String("terminal-id"), c.Float64("offline-limit"))
+	}
+
+	return &cli.Command{
+		Name:  "standin",
+		Usage: "Periksa dan kirim ulang transaksi yang disetujui saat offline",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "Tampilkan umur snapshot dan antrean transaksi offline",
+				Action: func(c *cli.Context) error {
+					store, err := open(c)
+					if err != nil {
+						return err
+					}
+					if taken := store.SnapshotTime(); taken.IsZero() {
+						fmt.Println("Belum ada snapshot saldo.")
+					} else {
+						fmt.Printf("Snapshot saldo: %s\n", taken.Format(time.DateTime))
+					}
+
+					pending := store.Pending()
+					fmt.Printf("Transaksi menunggu dikirim: %d\n", len(pending))
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					for _, e := range pending {
+						fmt.Fprintf(w, "%s\t%s\takun %d\t%s\n", e.CreatedAt.Format(time.DateTime), e.Reference, e.AccountID, currency.Format(e.Amount, currency.Base))
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:  "replay",
+				Usage: "Kirim antrean transaksi offline ke database",
+				Action: func(c *cli.Context) error {
+					store, err := open(c)
+					if err != nil {
+						return err
+					}
+					if err := db.InitDB(); err != nil {
+						return err
+					}
+
+					results, err := store.Replay()
+					for _, r := range results {
+						fmt.Printf("%s\takun %d\t%s\t%s %s\n", r.Reference, r.AccountID, currency.Format(r.Amount, currency.Base), r.Outcome, r.Detail)
+					}
+					if err != nil {
+						return err
+					}
+					return store.Snapshot()
+				},
+			},
+		},
+	}
+}
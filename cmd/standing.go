@@ -0,0 +1,80 @@
+package main
+
+import (
+	"atm-simulation/internal/transaction"
+	"atm-simulation/pkg/db"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// standingOrderCommand builds the `atm standing-order` command managing monthly transfers,
+// which the end-of-day run carries out
+func standingOrderCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "standing-order",
+		Usage: "Kelola transfer rutin bulanan yang dijalankan proses akhir hari",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "Buat transfer rutin bulanan antara dua akun dengan mata uang yang sama",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "account", Required: true, Usage: "ID akun pengirim"},
+					&cli.IntFlag{Name: "target", Required: true, Usage: "ID akun tujuan"},
+					&cli.Float64Flag{Name: "amount", Required: true, Usage: "jumlah transfer, dalam mata uang akun"},
+					&cli.IntFlag{Name: "day", Required: true, Usage: "tanggal transfer setiap bulan (1-31); di bulan yang lebih pendek pada hari terakhir"},
+					&cli.StringFlag{Name: "reference", Usage: "keterangan, misalnya cicilan atau tabungan"},
+				},
+				Action: func(c *cli.Context) error {
+					o, err := transaction.CreateStandingOrder(c.Int("account"), c.Int("target"), c.Float64("amount"), c.Int("day"), c.String("reference"))
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Standing order #%d: %s dari akun %d ke akun %d setiap tanggal %d.\n",
+						o.ID, formatAccountAmount(o.AccountID, o.Amount), o.AccountID, o.TargetID, o.Day)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "Tampilkan standing order yang aktif",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "account", Usage: "hanya akun ini"},
+				},
+				Action: func(c *cli.Context) error {
+					orders, err := transaction.StandingOrders(c.Int("account"))
+					if err != nil {
+						return err
+					}
+					for _, o := range orders {
+						last := "-"
+						if o.LastRunDate != nil {
+							last = *o.LastRunDate + " " + o.LastResult
+						}
+						fmt.Printf("#%-5d %-5d -> %-5d %15s  tgl %-2d  %-20s  terakhir: %s\n",
+							o.ID, o.AccountID, o.TargetID, formatAccountAmount(o.AccountID, o.Amount), o.Day, o.Reference, last)
+					}
+					fmt.Printf("%d standing order aktif.\n", len(orders))
+					return nil
+				},
+			},
+			{
+				Name:  "cancel",
+				Usage: "Hentikan standing order",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "id", Required: true, Usage: "ID standing order"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := transaction.CancelStandingOrder(c.Int("id")); err != nil {
+						return err
+					}
+					fmt.Printf("Standing order #%d dihentikan.\n", c.Int("id"))
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/statement"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// monthLayout is how a statement month is written on the command line
+const monthLayout = "2006-01"
+
+// statementCommand builds the `atm statement` command producing account statements
+func statementCommand() *cli.Command {
+	formatFlag := &cli.StringSliceFlag{Name: "format", Value: cli.NewStringSlice(statement.Formats...), Usage: "format berkas (" + strings.Join(statement.Formats, ", ") + ")"}
+	langFlag := &cli.StringFlag{Name: "lang", Value: i18n.Default, Usage: "bahasa rekening koran (id, en)"}
+	return &cli.Command{
+		Name:  "statement",
+		Usage: "Buat rekening koran nasabah dalam PDF dan CSV",
+		Before: func(c *cli.Context) error {
+			return db.InitDB()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "generate",
+				Usage: "Buat rekening koran satu akun untuk satu bulan atau periode",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "account", Required: true, Usage: "ID akun"},
+					&cli.StringFlag{Name: "month", Usage: "bulan rekening koran (YYYY-MM); default bulan lalu"},
+					&cli.TimestampFlag{Name: "from", Layout: time.DateOnly, Timezone: time.Local, Usage: "tanggal awal periode (YYYY-MM-DD), sebagai ganti --month"},
+					&cli.TimestampFlag{Name: "to", Layout: time.DateOnly, Timezone: time.Local, Usage: "tanggal akhir periode (YYYY-MM-DD), termasuk"},
+					&cli.StringFlag{Name: "out", Value: "statements", Usage: "direktori tujuan"},
+					formatFlag,
+					langFlag,
+				},
+				Action: func(c *cli.Context) error {
+					from, to, err := statementPeriod(c)
+					if err != nil {
+						return err
+					}
+					p, formats, err := statementOutput(c)
+					if err != nil {
+						return err
+					}
+					s, err := statement.Generate(c.Int("account"), from, to)
+					if err != nil {
+						return err
+					}
+					for _, format := range formats {
+						path, err := statement.WriteFile(c.String("out"), s, format, p)
+						if err != nil {
+							return err
+						}
+						fmt.Println(path)
+					}
+					fmt.Printf("Saldo awal %.2f, %d transaksi, saldo akhir %.2f.\n", s.Opening, len(s.Lines), s.Closing)
+					return nil
+				},
+			},
+			{
+				Name:  "batch",
+				Usage: "Buat rekening koran bulanan semua akun ke direktori; dijalankan awal bulan, misalnya dari cron",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "month", Usage: "bulan rekening koran (YYYY-MM); default bulan lalu"},
+					&cli.StringFlag{Name: "dir", Value: "statements", EnvVars: []string{"ATM_STATEMENT_DIR"}, Usage: "direktori tujuan; berkas ditulis ke subdirektori per bulan"},
+					&cli.BoolFlag{Name: "overwrite", Usage: "tulis ulang rekening koran yang sudah ada"},
+					formatFlag,
+					langFlag,
+				},
+				Action: func(c *cli.Context) error {
+					from, to, err := statementMonth(c.String("month"))
+					if err != nil {
+						return err
+					}
+					p, formats, err := statementOutput(c)
+					if err != nil {
+						return err
+					}
+					dir := filepath.Join(c.String("dir"), from.Format(monthLayout))
+					written, err := statement.Batch(dir, from, to, formats, p, c.Bool("overwrite"))
+					fmt.Printf("%d berkas rekening koran %s ditulis ke %s.\n", len(written), from.Format(monthLayout), dir)
+					return err
+				},
+			},
+		},
+	}
+}
+
+// statementPeriod reads the period of `atm statement generate`: --from and --to, or a month
+func statementPeriod(c *cli.Context) (time.Time, time.Time, error) {
+	from, to := c.Timestamp("from"), c.Timestamp("to")
+	if from == nil && to == nil {
+		return statementMonth(c.String("month"))
+	}
+	if from == nil || to == nil || c.IsSet("month") {
+		return time.Time{}, time.Time{}, fmt.Errorf("gunakan --month, atau --from bersama --to")
+	}
+	// The end date is included in the period
+	return *from, to.AddDate(0, 0, 1), nil
+}
+
+// statementMonth returns the period of the month written as YYYY-MM, or of last month when empty
+func statementMonth(month string) (time.Time, time.Time, error) {
+	if month == "" {
+		from, to := statement.PreviousMonth(time.Now())
+		return from, to, nil
+	}
+	t, err := time.ParseInLocation(monthLayout, month, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("bulan %q tidak valid, gunakan YYYY-MM", month)
+	}
+	from, to := statement.Month(t.Year(), t.Month())
+	return from, to, nil
+}
+
+// statementOutput reads the language and the formats of the statement files
+func statementOutput(c *cli.Context) (*i18n.Printer, []string, error) {
+	if !i18n.Supported(c.String("lang")) {
+		return nil, nil, fmt.Errorf("bahasa %q tidak didukung", c.String("lang"))
+	}
+	formats := c.StringSlice("format")
+	for _, format := range formats {
+		if format != statement.FormatPDF && format != statement.FormatCSV {
+			return nil, nil, fmt.Errorf("%w: %q", statement.ErrUnknownFormat, format)
+		}
+	}
+	return i18n.New(c.String("lang")), formats, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"atm-simulation/internal/host"
+	"atm-simulation/internal/journal"
+	"atm-simulation/internal/terminal"
+	"atm-simulation/pkg/currency"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// simulationFlags are shared by `atm terminal` and `atm fleet`
+func simulationFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{Name: "customer", Required: true, Usage: "nasabah yang bertransaksi, format id:PIN (boleh diulang)"},
+		&cli.StringFlag{Name: "cash", Value: "100000x200,50000x200", Usage: "isi kaset per terminal, format nominalxjumlah"},
+		&cli.DurationFlag{Name: "duration", Value: time.Minute, Usage: "lama simulasi"},
+		&cli.DurationFlag{Name: "interval", Value: time.Second, Usage: "jeda antar transaksi di satu terminal"},
+		&cli.Float64Flag{Name: "outage-rate", Value: 0.02, Usage: "peluang terminal mengalami gangguan sebelum setiap transaksi"},
+		&cli.DurationFlag{Name: "outage-duration", Value: 10 * time.Second, Usage: "lama gangguan terminal"},
+		&cli.IntFlag{Name: "max-withdrawal", Value: 500000, Usage: "jumlah penarikan acak terbesar"},
+	}
+}
+
+// newSimulatedTerminal creates a terminal with its own host client, cash and journal in <journal-dir>/<id>
+func newSimulatedTerminal(c *cli.Context, id string) (*terminal.Terminal, error) {
+	if c.String("host") == "" {
+		return nil, fmt.Errorf("--host wajib diisi untuk simulasi terminal")
+	}
+	spec, err := loadISOSpec(c)
+	if err != nil {
+		return nil, err
+	}
+	cash, err := terminal.ParseCassettes(c.String("cash"))
+	if err != nil {
+		return nil, err
+	}
+	ej, err := journal.Open(filepath.Join(c.String("journal-dir"), id), id, c.Int64("journal-max-size"))
+	if err != nil {
+		return nil, err
+	}
+	client := host.NewClient(strings.Split(c.String("host"), ","), id, spec)
+	return terminal.New(id, client, cash, ej), nil
+}
+
+// checkTerminalID refuses a terminal ID that does not fit field 41 of the ISO 8583 spec in use,
+// which every request of the terminal carries
+func checkTerminalID(c *cli.Context, id string) error {
+	spec, err := loadISOSpec(c)
+	if err != nil {
+		return err
+	}
+	if limit := spec[41].Length; len(id) > limit {
+		return fmt.Errorf("ID terminal %q lebih dari %d karakter, batas field 41 ISO 8583", id, limit)
+	}
+	return nil
+}
+
+// runSimulation runs the terminals concurrently until --duration passes or Ctrl+C is pressed
+// and prints the statistics of every terminal
+func runSimulation(c *cli.Context, terminals []*terminal.Terminal) error {
+	var customers []terminal.Customer
+	for _, value := range c.StringSlice("customer") {
+		customer, err := terminal.ParseCustomer(value)
+		if err != nil {
+			return err
+		}
+		customers = append(customers, customer)
+	}
+	opts := terminal.Options{
+		Interval:       c.Duration("interval"),
+		OutageRate:     c.Float64("outage-rate"),
+		OutageDuration: c.Duration("outage-duration"),
+		MaxWithdrawal:  c.Int("max-withdrawal"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("duration"))
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats := make([]*terminal.Stats, len(terminals))
+	var wg sync.WaitGroup
+	for i, t := range terminals {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.Journal.Write("", 0, journal.EventTerminalUp, "simulasi dimulai")
+			stats[i] = t.Simulate(ctx, customers, opts)
+			t.Journal.Write("", 0, journal.EventTerminalDown, "simulasi selesai")
+			t.Journal.Close()
+			t.Client.Close()
+		}()
+	}
+	wg.Wait()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TERMINAL\tCEK SALDO\tPENARIKAN\tDISETUJUI\tDITOLAK\tKAS KURANG\tHOST DOWN\tGANGGUAN\tFAILOVER\tSISA KAS")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n", s.Terminal, s.Inquiries, s.Withdrawals,
+			s.Approved, s.Declined, s.CashShort, s.Unavailable, s.Outages, s.Failovers, currency.Format(s.CashLeft, currency.Base))
+	}
+	return w.Flush()
+}
+
+// terminalCommand builds the `atm terminal` command running one simulated terminal against the host
+func terminalCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "terminal",
+		Usage: "Jalankan satu terminal simulasi yang bertransaksi ke host ISO 8583",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "id", Required: true, Usage: "ID terminal"},
+		}, simulationFlags()...),
+		Before: func(c *cli.Context) error {
+			return checkTerminalID(c, c.String("id"))
+		},
+		Action: func(c *cli.Context) error {
+			t, err := newSimulatedTerminal(c, c.String("id"))
+			if err != nil {
+				return err
+			}
+			return runSimulation(c, []*terminal.Terminal{t})
+		},
+	}
+}
+
+// fleetTerminalID returns the ID of the i-th terminal of the fleet
+func fleetTerminalID(c *cli.Context, i int) string {
+	return fmt.Sprintf("%s%03d", c.String("prefix"), i)
+}
+
+// fleetCommand builds the `atm fleet` command running many simulated terminals in one process
+func fleetCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fleet",
+		Usage: "Jalankan armada terminal simulasi yang bertransaksi ke host ISO 8583",
+		Flags: append([]cli.Flag{
+			&cli.IntFlag{Name: "terminals", Value: 5, Usage: "jumlah terminal"},
+			&cli.StringFlag{Name: "prefix", Value: "ATM-", Usage: "awalan ID terminal; awalan dan nomor terminal paling banyak 8 karakter"},
+		}, simulationFlags()...),
+		Before: func(c *cli.Context) error {
+			// The last terminal has the longest ID
+			return checkTerminalID(c, fleetTerminalID(c, c.Int("terminals")))
+		},
+		Action: func(c *cli.Context) error {
+			var terminals []*terminal.Terminal
+			for i := 1; i <= c.Int("terminals"); i++ {
+				t, err := newSimulatedTerminal(c, fleetTerminalID(c, i))
+				if err != nil {
+					return err
+				}
+				terminals = append(terminals, t)
+			}
+			return runSimulation(c, terminals)
+		},
+	}
+}
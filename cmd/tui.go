@@ -0,0 +1,645 @@
+package main
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/journal"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/statement"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tuiTick is how often the screen animates and checks the session timeouts
+const tuiTick = 200 * time.Millisecond
+
+// screenWidth is the inner width of the ATM screen in columns
+const screenWidth = 62
+
+// Frames of the dispense animation, counted in ticks
+const (
+	countingFrames = 10 // notes are counted
+	presentFrames  = 50 // cash waits in the slot before the screen moves on by itself
+)
+
+// screen is one state of the full-screen terminal
+type screen int
+
+const (
+	screenLanguage   screen = iota // choose the language, the idle screen of the ATM
+	screenName                     // identify the customer, standing in for the card
+	screenPIN                      // masked PIN entry
+	screenMenu                     // main menu on the side buttons
+	screenQuickCash                // withdrawal amounts on the side buttons
+	screenAmount                   // keypad entry of an amount
+	screenTarget                   // keypad entry of the transfer target
+	screenConfirm                  // confirm the transfer target
+	screenProcessing               // waiting for the bank
+	screenDispense                 // cash is counted and presented
+	screenResult                   // outcome of an operation
+)
+
+// Operations that ask for an amount on the keypad
+const (
+	opWithdraw = "withdraw"
+	opDeposit  = "deposit"
+	opTransfer = "transfer"
+)
+
+// amountTitles are the catalog keys of the title of the amount keypad per operation
+var amountTitles = map[string]string{
+	opWithdraw: "tui.amount_withdraw",
+	opDeposit:  "tui.amount_deposit",
+	opTransfer: "tui.amount_transfer",
+}
+
+// quickCash are the withdrawal amounts offered on the side buttons, per currency
+var quickCash = map[string][]float64{
+	"IDR": {50000, 100000, 200000, 300000, 500000, 1000000},
+	"JPY": {5000, 10000, 20000, 30000, 50000, 100000},
+}
+
+// defaultQuickCash is offered for currencies without their own amounts
+var defaultQuickCash = []float64{20, 50, 100, 200, 300, 500}
+
+// spinner are the frames of the processing animation
+var spinner = []string{"|", "/", "-", "\\"}
+
+// sideButton is one of the eight function keys next to the screen,
+// F1-F4 on the left and F5-F8 on the right
+type sideButton struct {
+	label  string
+	action func(m *atmUI) (tea.Model, tea.Cmd)
+}
+
+type tickMsg time.Time
+
+// loginMsg carries the outcome of a login started from the PIN screen
+type loginMsg struct {
+	sess *session.Session
+	err  error
+}
+
+// opMsg carries the outcome of a money movement and the balance looked up after it
+// A failed balance lookup is kept apart in balanceErr, since the movement itself went through
+type opMsg struct {
+	op         string
+	amount     float64
+	balance    float64
+	err        error
+	balanceErr error
+}
+
+// atmUI is the bubbletea model of the full-screen terminal
+type atmUI struct {
+	screen  screen
+	lang    string // language chosen on the first screen
+	sess    *session.Session
+	name    string        // name entered before the PIN
+	input   []rune        // keypad buffer of the current entry screen
+	op      string        // operation the amount is entered for
+	target  *user.Account // target of the transfer being entered
+	amount  float64
+	balance float64
+	balErr  error  // why the balance after the movement could not be looked up
+	title   string // title and lines of the result screen
+	lines   []string
+	frame   int // animation frame, advanced on every tick
+	phaseAt int // frame at which the dispense animation started
+}
+
+// runTUI runs the full-screen terminal until it is closed with Ctrl+C
+func runTUI() error {
+	m := &atmUI{screen: screenLanguage, lang: lang}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	endSession(m.sess, session.ReasonShutdown)
+	return err
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(tuiTick, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m *atmUI) Init() tea.Cmd {
+	return tick()
+}
+
+// p returns the printer of the session, or of the language picked before login
+func (m *atmUI) p() *i18n.Printer {
+	if m.sess != nil {
+		return tr(m.sess)
+	}
+	return i18n.New(m.lang)
+}
+
+// show switches to an entry or menu screen with an empty keypad buffer
+func (m *atmUI) show(s screen) (tea.Model, tea.Cmd) {
+	m.screen = s
+	m.input = nil
+	return m, nil
+}
+
+// result shows the outcome of an operation until the customer presses a key
+func (m *atmUI) result(title string, lines ...string) (tea.Model, tea.Cmd) {
+	m.screen = screenResult
+	m.title = title
+	m.lines = lines
+	return m, nil
+}
+
+// home returns to the main menu, or to the language screen when the session is over
+func (m *atmUI) home() (tea.Model, tea.Cmd) {
+	if m.sess == nil || m.sess.Ended() {
+		m.sess = nil
+		return m.show(screenLanguage)
+	}
+	return m.show(screenMenu)
+}
+
+// finish ends the session and asks the customer to take the card
+func (m *atmUI) finish(reason string) (tea.Model, tea.Cmd) {
+	p := m.p()
+	endSession(m.sess, reason)
+	title := p.T("tui.take_card")
+	if reason != session.ReasonLogout {
+		title = p.T("session.logged_out")
+	}
+	return m.result(title, p.T("menu.goodbye"))
+}
+
+func (m *atmUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		m.frame++
+		return m.onTick()
+	case loginMsg:
+		if msg.err != nil {
+			p := m.p()
+			return m.result(p.T("login.failed", p.Error(msg.err)))
+		}
+		m.sess = msg.sess
+		return m.show(screenMenu)
+	case opMsg:
+		return m.onResult(msg)
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		if m.sess != nil && !m.sess.Ended() {
+			m.sess.Touch()
+		}
+		return m.onKey(msg)
+	}
+	return m, nil
+}
+
+// onTick advances the animations and logs an idle customer out
+func (m *atmUI) onTick() (tea.Model, tea.Cmd) {
+	if m.screen == screenDispense && m.frame-m.phaseAt >= countingFrames+presentFrames {
+		return m.cashTaken(), tick()
+	}
+	if m.sess != nil && !m.sess.Ended() && m.screen != screenProcessing && m.screen != screenDispense {
+		switch {
+		case m.sess.Remaining() <= 0:
+			logEvent(m.sess, journal.EventTimeout, session.ReasonAbsoluteTimeout)
+			model, _ := m.finish(session.ReasonAbsoluteTimeout)
+			return model, tick()
+		case m.sess.IdleRemaining() <= 0:
+			logEvent(m.sess, journal.EventTimeout, session.ReasonIdleTimeout)
+			model, _ := m.finish(session.ReasonIdleTimeout)
+			return model, tick()
+		}
+	}
+	return m, tick()
+}
+
+// onKey handles the function keys and the keypad of the current screen
+func (m *atmUI) onKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	buttons := m.buttons()
+
+	// F1-F8 press the side buttons; digits do too on screens without keypad entry
+	index := -1
+	if strings.HasPrefix(key, "f") {
+		if n, err := strconv.Atoi(key[1:]); err == nil {
+			index = n - 1
+		}
+	} else if !m.entry() && len(key) == 1 && key[0] >= '1' && key[0] <= '8' {
+		index = int(key[0] - '1')
+	}
+	if index >= 0 && index < len(buttons) {
+		if b := buttons[index]; b != nil {
+			return b.action(m)
+		}
+		return m, nil
+	}
+
+	switch m.screen {
+	case screenDispense:
+		// Any key means the cash was taken from the slot
+		if m.frame-m.phaseAt >= countingFrames {
+			return m.cashTaken(), nil
+		}
+		return m, nil
+	case screenResult:
+		if key == "enter" || key == "esc" {
+			return m.home()
+		}
+		return m, nil
+	}
+
+	if !m.entry() {
+		return m, nil
+	}
+	switch key {
+	case "enter":
+		return m.submit()
+	case "esc":
+		return m.cancel()
+	case "backspace":
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case "delete":
+		// The CLEAR key of the PIN pad
+		m.input = nil
+	default:
+		if msg.Type == tea.KeyRunes && len(m.input) < m.maxInput() {
+			for _, r := range msg.Runes {
+				if m.accepts(r) {
+					m.input = append(m.input, r)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// entry reports whether the screen reads characters from the keypad
+func (m *atmUI) entry() bool {
+	switch m.screen {
+	case screenName, screenPIN, screenAmount, screenTarget:
+		return true
+	}
+	return false
+}
+
+// accepts reports whether the keypad of the screen takes the character
+func (m *atmUI) accepts(r rune) bool {
+	if m.screen == screenName {
+		return r >= ' '
+	}
+	return r >= '0' && r <= '9'
+}
+
+// maxInput is the length limit of the keypad buffer
+func (m *atmUI) maxInput() int {
+	switch m.screen {
+	case screenName:
+		return 40
+	case screenPIN:
+		return user.MaxPINLength
+	}
+	return 12
+}
+
+// cancel leaves an entry screen
+func (m *atmUI) cancel() (tea.Model, tea.Cmd) {
+	switch m.screen {
+	case screenName, screenPIN:
+		return m.show(screenLanguage)
+	}
+	return m.home()
+}
+
+// submit handles Enter on an entry screen
+func (m *atmUI) submit() (tea.Model, tea.Cmd) {
+	value := string(m.input)
+	switch m.screen {
+	case screenName:
+		if value == "" {
+			return m, nil
+		}
+		m.name = value
+		return m.show(screenPIN)
+	case screenPIN:
+		if !validPIN(value) {
+			return m, nil
+		}
+		m.screen = screenProcessing
+		m.input = nil
+		name, language := m.name, m.lang
+		return m, func() tea.Msg {
+			sess, err := openSession(name, value, language)
+			return loginMsg{sess: sess, err: err}
+		}
+	case screenAmount:
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil || amount <= 0 {
+			m.input = nil
+			return m, nil
+		}
+		return m.start(m.op, amount)
+	case screenTarget:
+		targetID, err := strconv.Atoi(value)
+		if err != nil {
+			m.input = nil
+			return m, nil
+		}
+		target, err := core.FindTarget(m.sess, targetID)
+		if err != nil {
+			return m.result(m.p().T("transfer.target_not_found"))
+		}
+		m.target = target
+		return m.show(screenConfirm)
+	}
+	return m, nil
+}
+
+// start runs a money movement in the background while the processing screen animates
+func (m *atmUI) start(op string, amount float64) (tea.Model, tea.Cmd) {
+	m.screen = screenProcessing
+	m.input = nil
+	sess, target := m.sess, m.target
+	return m, func() tea.Msg {
+		var err error
+		switch op {
+		case opWithdraw:
+			err = core.Withdraw(sess, amount)
+		case opDeposit:
+			err = core.Deposit(sess, amount)
+		case opTransfer:
+			err = core.Transfer(sess, target.ID, amount)
+		}
+		if err != nil {
+			return opMsg{op: op, amount: amount, err: err}
+		}
+		balance, err := core.CheckBalance(sess)
+		return opMsg{op: op, amount: amount, balance: balance, balanceErr: err}
+	}
+}
+
+// onResult journals the outcome of a money movement and shows it
+func (m *atmUI) onResult(msg opMsg) (tea.Model, tea.Cmd) {
+	p := m.p()
+	if msg.err != nil {
+		switch msg.op {
+		case opWithdraw:
+			logEvent(m.sess, journal.EventDispenseFail, fmt.Sprintf("jumlah=%.2f: %v", msg.amount, msg.err))
+			return m.result(p.T("withdraw.failed", p.Error(msg.err)))
+		case opDeposit:
+			logEvent(m.sess, journal.EventError, "deposit: "+msg.err.Error())
+			return m.result(p.T("deposit.failed", p.Error(msg.err)))
+		default:
+			if errors.Is(msg.err, transaction.ErrPendingApproval) {
+				return m.transferHeld(msg)
+			}
+			logEvent(m.sess, journal.EventError, fmt.Sprintf("transfer ke %d: %v", m.target.ID, msg.err))
+			return m.result(p.T("transfer.failed", p.Error(msg.err)))
+		}
+	}
+
+	m.amount, m.balance, m.balErr = msg.amount, msg.balance, msg.balanceErr
+	switch msg.op {
+	case opWithdraw:
+		logEvent(m.sess, journal.EventDispense, fmt.Sprintf("jumlah=%.2f", msg.amount))
+		m.screen = screenDispense
+		m.phaseAt = m.frame
+		return m, nil
+	case opDeposit:
+		logEvent(m.sess, journal.EventDeposit, fmt.Sprintf("jumlah=%.2f", msg.amount))
+		return m.success("deposit")
+	default:
+		logEvent(m.sess, journal.EventTransfer, fmt.Sprintf("tujuan=%d jumlah=%.2f", m.target.ID, msg.amount))
+		return m.success("transfer")
+	}
+}
+
+// cashTaken ends the dispense animation and shows the new balance
+func (m *atmUI) cashTaken() tea.Model {
+	model, _ := m.success("withdraw")
+	return model
+}
+
+// success shows a completed money movement with the balance after it, or why the balance is unavailable
+func (m *atmUI) success(op string) (tea.Model, tea.Cmd) {
+	p := m.p()
+	if m.balErr != nil {
+		return m.result(p.T(op+".success_no_balance"), p.T("balance.failed", p.Error(m.balErr)))
+	}
+	return m.result(p.T(op+".success", formatMoney(m.sess, m.balance)))
+}
+
+// buttons returns the side buttons of the current screen, indexed F1 to F8
+func (m *atmUI) buttons() []*sideButton {
+	p := m.p()
+	buttons := make([]*sideButton, 8)
+	cancel := &sideButton{label: p.T("tui.cancel"), action: (*atmUI).cancel}
+
+	switch m.screen {
+	case screenLanguage:
+		for i, l := range i18n.Languages {
+			code := l.Code
+			buttons[i] = &sideButton{label: l.Name, action: func(m *atmUI) (tea.Model, tea.Cmd) {
+				m.lang = code
+				return m.show(screenName)
+			}}
+		}
+	case screenName, screenPIN, screenAmount, screenTarget:
+		buttons[7] = cancel
+	case screenMenu:
+		items := []struct {
+			index int
+			perm  session.Permission
+			label string
+			open  func(m *atmUI) (tea.Model, tea.Cmd)
+		}{
+			{0, session.PermBalance, "tui.balance", (*atmUI).showBalance},
+			{1, session.PermWithdraw, "tui.withdraw", func(m *atmUI) (tea.Model, tea.Cmd) { return m.show(screenQuickCash) }},
+			{2, session.PermDeposit, "tui.deposit", func(m *atmUI) (tea.Model, tea.Cmd) { return m.askAmount(opDeposit) }},
+			{3, session.PermTransfer, "tui.transfer", func(m *atmUI) (tea.Model, tea.Cmd) { return m.show(screenTarget) }},
+			{4, session.PermProfile, "tui.profile", (*atmUI).showProfile},
+			{5, session.PermHistory, "tui.mini_statement", (*atmUI).showMiniStatement},
+		}
+		for _, item := range items {
+			if m.sess.Can(item.perm) {
+				buttons[item.index] = &sideButton{label: p.T(item.label), action: item.open}
+			}
+		}
+		buttons[7] = &sideButton{label: p.T("tui.finish"), action: func(m *atmUI) (tea.Model, tea.Cmd) {
+			return m.finish(session.ReasonLogout)
+		}}
+	case screenQuickCash:
+		amounts, ok := quickCash[m.sess.Currency()]
+		if !ok {
+			amounts = defaultQuickCash
+		}
+		for i, amount := range amounts {
+			amount := amount
+			buttons[i%3+i/3*4] = &sideButton{label: currency.Format(amount, m.sess.Currency()), action: func(m *atmUI) (tea.Model, tea.Cmd) {
+				return m.start(opWithdraw, amount)
+			}}
+		}
+		buttons[3] = &sideButton{label: p.T("tui.other_amount"), action: func(m *atmUI) (tea.Model, tea.Cmd) { return m.askAmount(opWithdraw) }}
+		buttons[7] = cancel
+	case screenConfirm:
+		buttons[3] = &sideButton{label: p.T("tui.yes"), action: func(m *atmUI) (tea.Model, tea.Cmd) { return m.askAmount(opTransfer) }}
+		buttons[7] = &sideButton{label: p.T("tui.no"), action: (*atmUI).cancel}
+	case screenResult:
+		buttons[7] = &sideButton{label: p.T("tui.back"), action: (*atmUI).home}
+	}
+	return buttons
+}
+
+// askAmount opens the keypad to enter the amount of an operation
+func (m *atmUI) askAmount(op string) (tea.Model, tea.Cmd) {
+	m.op = op
+	return m.show(screenAmount)
+}
+
+// showBalance shows the balance of the session's account
+func (m *atmUI) showBalance() (tea.Model, tea.Cmd) {
+	p := m.p()
+	balance, err := core.CheckBalance(m.sess)
+	if err != nil {
+		return m.result(p.T("balance.failed", p.Error(err)))
+	}
+	lines := []string{p.T("balance.current", formatMoney(m.sess, balance))}
+	if available, err := core.AvailableBalance(m.sess); err == nil && available != balance {
+		lines = append(lines, p.T("balance.available", formatMoney(m.sess, available)))
+	}
+	return m.result(p.T("tui.balance"), lines...)
+}
+
+// transferHeld shows a transfer that was held for the approval of a supervisor instead of posted
+func (m *atmUI) transferHeld(msg opMsg) (tea.Model, tea.Cmd) {
+	p := m.p()
+	logEvent(m.sess, journal.EventTransfer, fmt.Sprintf("tujuan=%d jumlah=%.2f ditahan: %v", m.target.ID, msg.amount, msg.err))
+	lines := []string{p.T("transfer.pending_approval", formatMoney(m.sess, msg.amount))}
+	var pending *transaction.PendingApprovalError
+	if errors.As(msg.err, &pending) {
+		lines = append(lines, p.T("transfer.pending_number", pending.Transfer.ID, pending.Transfer.ExpiresAt))
+	}
+	return m.result(p.T("tui.transfer"), lines...)
+}
+
+// showProfile shows the profile of the session's account
+func (m *atmUI) showProfile() (tea.Model, tea.Cmd) {
+	p := m.p()
+	balance, err := core.CheckBalance(m.sess)
+	if err != nil {
+		return m.result(p.T("balance.failed", p.Error(err)))
+	}
+	return m.result(p.T("tui.profile"),
+		p.T("profile.account_id", m.sess.AccountID()),
+		p.T("profile.name", m.sess.Account.Name),
+		p.T("profile.balance", formatMoney(m.sess, balance)))
+}
+
+// showMiniStatement shows the last transactions of the session's account in the layout of a receipt
+func (m *atmUI) showMiniStatement() (tea.Model, tea.Cmd) {
+	p := m.p()
+	mini, err := statement.GenerateMini(m.sess.AccountID(), miniStatementLength)
+	if err != nil {
+		return m.result(p.T("mini.failed", p.Error(err)))
+	}
+	return m.result(p.T("tui.mini_statement"), mini.Receipt(p)...)
+}
+
+// body returns the title and the lines in the middle of the screen
+func (m *atmUI) body() (string, []string) {
+	p := m.p()
+	switch m.screen {
+	case screenLanguage:
+		return p.T("tui.welcome"), []string{p.T("tui.choose_language")}
+	case screenName:
+		return p.T("tui.name_prompt"), []string{"[ " + string(m.input) + "_ ]"}
+	case screenPIN:
+		return p.T("tui.pin_prompt"), []string{"[ " + strings.Repeat("* ", len(m.input)) + "]"}
+	case screenMenu:
+		lines := []string{p.T("tui.choose_transaction")}
+		if m.sess.Offline {
+			lines = append(lines, "", p.T("login.offline_notice"))
+		}
+		if m.sess.Account.Status == user.StatusFrozen {
+			lines = append(lines, "", p.T("login.frozen_notice"))
+		}
+		return p.T("tui.greeting", m.sess.Account.Name), lines
+	case screenQuickCash:
+		return p.T("tui.withdraw"), []string{p.T("tui.choose_amount")}
+	case screenAmount:
+		amount, _ := strconv.ParseFloat(string(m.input), 64)
+		return p.T(amountTitles[m.op]), []string{formatMoney(m.sess, amount)}
+	case screenTarget:
+		return p.T("tui.transfer"), []string{p.T("tui.target_prompt"), "[ " + string(m.input) + "_ ]"}
+	case screenConfirm:
+		return p.T("tui.transfer"), []string{p.T("tui.confirm_target", m.target.Name, m.target.ID)}
+	case screenProcessing:
+		return p.T("tui.processing"), []string{spinner[m.frame%len(spinner)]}
+	case screenDispense:
+		elapsed := m.frame - m.phaseAt
+		if elapsed < countingFrames {
+			bar := strings.Repeat("■", elapsed+1) + strings.Repeat("□", countingFrames-elapsed-1)
+			return p.T("tui.counting"), []string{bar}
+		}
+		// The prompt blinks while the cash waits in the slot
+		title := p.T("tui.take_cash")
+		if elapsed/3%2 == 1 {
+			title = ""
+		}
+		return title, []string{"", "▀▀▀▀▀▀▀▀ " + formatMoney(m.sess, m.amount) + " ▀▀▀▀▀▀▀▀"}
+	case screenResult:
+		return m.title, m.lines
+	}
+	return "", nil
+}
+
+var (
+	frameStyle  = lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(0, 1).Width(screenWidth)
+	titleStyle  = lipgloss.NewStyle().Bold(true).Width(screenWidth).Align(lipgloss.Center)
+	bodyStyle   = lipgloss.NewStyle().Width(screenWidth).Align(lipgloss.Center)
+	buttonStyle = lipgloss.NewStyle().Width(screenWidth / 2)
+	hintStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+func (m *atmUI) View() string {
+	title, lines := m.body()
+	buttons := m.buttons()
+
+	var rows []string
+	rows = append(rows, titleStyle.Render(terminalID), "", titleStyle.Render(title), "")
+	for _, line := range lines {
+		rows = append(rows, bodyStyle.Render(line))
+	}
+	for len(rows) < 9 {
+		rows = append(rows, "")
+	}
+
+	// Labels sit next to the side buttons: F1-F4 on the left, F5-F8 on the right
+	for i := 0; i < 4; i++ {
+		left, right := "", ""
+		if b := buttons[i]; b != nil {
+			left = fmt.Sprintf("F%d ▸ %s", i+1, b.label)
+		}
+		if b := buttons[i+4]; b != nil {
+			right = fmt.Sprintf("%s ◂ F%d", b.label, i+5)
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+			buttonStyle.Render(left), buttonStyle.Align(lipgloss.Right).Render(right)))
+	}
+
+	screen := frameStyle.Render(strings.Join(rows, "\n"))
+	hint := m.p().T("tui.keys")
+	if m.sess != nil && !m.sess.Ended() && m.sess.IdleRemaining() < moreTimeCountdown {
+		hint = m.p().T("tui.more_time", int(m.sess.IdleRemaining().Seconds())+1)
+	}
+	return screen + "\n" + hintStyle.Render(hint) + "\n"
+}
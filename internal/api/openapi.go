@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of the API described by the generated spec
+const OpenAPIVersion = "1.0.0"
+
+// OpenAPI generates the OpenAPI 3 document of the server from its route table,
+// so the spec cannot drift away from the registered handlers
+func (s *Server) OpenAPI() map[string]interface{} {
+	paths := map[string]interface{}{}
+	for _, rt := range s.routes() {
+		responses := map[string]interface{}{
+			"default": map[string]interface{}{
+				"description": "Kesalahan",
+				"content":     jsonContent(ErrorResponse{}),
+			},
+		}
+		for _, status := range append([]int{rt.Status}, rt.Also...) {
+			success := map[string]interface{}{"description": http.StatusText(status)}
+			if rt.Response != nil {
+				success["content"] = jsonContent(rt.Response)
+			}
+			responses[strconv.Itoa(status)] = success
+		}
+
+		op := map[string]interface{}{
+			"summary":     rt.Summary,
+			"operationId": operationID(rt.Path),
+			"responses":   responses,
+		}
+		if rt.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(rt.Request),
+			}
+		}
+		if len(rt.Query) > 0 {
+			var params []interface{}
+			for _, q := range rt.Query {
+				schema := map[string]interface{}{"type": "string"}
+				if len(q.Enum) > 0 {
+					schema["enum"] = q.Enum
+				}
+				params = append(params, map[string]interface{}{
+					"name":        q.Name,
+					"in":          "query",
+					"description": q.Description,
+					"schema":      schema,
+				})
+			}
+			op["parameters"] = params
+		}
+		if rt.Auth {
+			op["security"] = []interface{}{map[string]interface{}{"sessionToken": []string{}}}
+		}
+
+		item, ok := paths[rt.Path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "ATM Simulation API",
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"sessionToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives an operation ID from the last segment of the path
+func operationID(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// jsonContent describes a JSON body of the given type
+func jsonContent(v interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(v))},
+	}
+}
+
+// timeType is the reflected type of time.Time, documented as a date-time string
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor builds the JSON schema of a Go type from its fields and json tags
+// Fields without omitempty are listed as required
+func schemaFor(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaFor(t.Elem())
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaFor(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,474 @@
+package api
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/statement"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// route describes one API endpoint
+// The same table registers the handlers and generates the OpenAPI spec
+type route struct {
+	Method     string
+	Path       string
+	Summary    string
+	Auth       bool               // the route requires a bearer session token
+	Permission session.Permission // permission checked when the route requires a session
+	Query      []queryParam       // query parameters accepted by the route
+	Request    interface{}        // zero value of the request body type, nil when there is no body
+	Response   interface{}        // zero value of the response body type, nil when there is no body
+	Status     int                // status code of a successful response
+	Also       []int              // further status codes of a successful response, with the same body
+	Handler    handlerFunc
+}
+
+// queryParam describes a query string parameter of a route
+type queryParam struct {
+	Name        string
+	Description string
+	Enum        []string
+}
+
+// newRequest allocates a new request body of the route's request type
+func (rt route) newRequest() interface{} {
+	return reflect.New(reflect.TypeOf(rt.Request)).Interface()
+}
+
+// validator is implemented by request bodies that check their own fields
+type validator interface {
+	Validate() error
+}
+
+// ErrorResponse is returned for every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// CredentialsRequest is the body of the login request
+type CredentialsRequest struct {
+	Name string `json:"name"`
+	PIN  string `json:"pin"`
+}
+
+// Validate checks that both the name and the PIN are given
+func (r *CredentialsRequest) Validate() error {
+	if r.Name == "" || r.PIN == "" {
+		return fmt.Errorf("nama dan PIN wajib diisi")
+	}
+	return nil
+}
+
+// RegisterRequest is the body of the register request
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	PIN      string `json:"pin"`
+	Currency string `json:"currency,omitempty"` // ISO 4217 code of the account, default IDR
+}
+
+// Validate checks that both the name and the PIN are given
+func (r *RegisterRequest) Validate() error {
+	if r.Name == "" || r.PIN == "" {
+		return fmt.Errorf("nama dan PIN wajib diisi")
+	}
+	return nil
+}
+
+// AccountResponse describes an account
+type AccountResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+}
+
+// LoginResponse carries the session token used as bearer token for later requests
+type LoginResponse struct {
+	Token     string          `json:"token"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Account   AccountResponse `json:"account"`
+}
+
+// AmountRequest is the body of the deposit and withdraw requests
+type AmountRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+// Validate checks that the amount is positive
+func (r *AmountRequest) Validate() error {
+	if r.Amount <= 0 {
+		return transaction.ErrInvalidAmount
+	}
+	return nil
+}
+
+// TransferRequest is the body of the transfer request
+type TransferRequest struct {
+	TargetID int     `json:"target_id"`
+	Amount   float64 `json:"amount"`
+}
+
+// Validate checks the target account and the amount
+func (r *TransferRequest) Validate() error {
+	if r.TargetID <= 0 {
+		return fmt.Errorf("target_id wajib diisi")
+	}
+	if r.Amount <= 0 {
+		return transaction.ErrInvalidAmount
+	}
+	return nil
+}
+
+// BalanceResponse reports the balance of the session's account
+type BalanceResponse struct {
+	AccountID        int     `json:"account_id"`
+	Balance          float64 `json:"balance"`
+	AvailableBalance float64 `json:"available_balance"` // balance minus the active holds and the transfers waiting for approval
+	Currency         string  `json:"currency"`
+}
+
+// PendingTransferResponse describes a transfer held until a supervisor approves it
+type PendingTransferResponse struct {
+	ID        int     `json:"id"`
+	TargetID  int     `json:"target_id"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+	CreatedAt string  `json:"created_at"`
+	ExpiresAt string  `json:"expires_at"`
+}
+
+// TransferResponse reports the balance after a transfer, and the held transfer when it needs approval
+type TransferResponse struct {
+	AccountID        int                      `json:"account_id"`
+	Balance          float64                  `json:"balance"`
+	AvailableBalance float64                  `json:"available_balance"`
+	Currency         string                   `json:"currency"`
+	PendingTransfer  *PendingTransferResponse `json:"pending_transfer,omitempty"`
+}
+
+// PendingTransfersResponse lists the held transfers of the session's account, oldest first
+type PendingTransfersResponse struct {
+	Transfers []PendingTransferResponse `json:"transfers"`
+}
+
+// CancelTransferRequest is the body of the request cancelling a held transfer
+type CancelTransferRequest struct {
+	ID int `json:"id"`
+}
+
+// Validate checks that the held transfer is named
+func (r *CancelTransferRequest) Validate() error {
+	if r.ID <= 0 {
+		return fmt.Errorf("id wajib diisi")
+	}
+	return nil
+}
+
+// pendingTransferResponse converts a held transfer into its response
+func pendingTransferResponse(p transaction.PendingTransfer) PendingTransferResponse {
+	return PendingTransferResponse{ID: p.ID, TargetID: p.TargetID, Amount: p.Amount, Status: p.Status, CreatedAt: p.CreatedAt, ExpiresAt: p.ExpiresAt}
+}
+
+// HoldResponse describes funds on hold on the session's account
+type HoldResponse struct {
+	ID        int     `json:"id"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+	CreatedAt string  `json:"created_at"`
+	ExpiresAt string  `json:"expires_at"`
+}
+
+// HoldsResponse lists the active holds of the session's account, oldest first
+type HoldsResponse struct {
+	Holds []HoldResponse `json:"holds"`
+}
+
+// MiniStatementEntry is one transaction of a mini-statement
+type MiniStatementEntry struct {
+	Type      string  `json:"type"`
+	Amount    float64 `json:"amount"` // negative for debits
+	TargetID  *int    `json:"target_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	Balance   float64 `json:"balance"` // balance after the transaction
+}
+
+// MiniStatementResponse lists the last transactions of every type, newest first, and the same
+// mini-statement in the layout of a receipt
+type MiniStatementResponse struct {
+	AccountID    int                  `json:"account_id"`
+	Balance      float64              `json:"balance"`
+	Currency     string               `json:"currency"`
+	Transactions []MiniStatementEntry `json:"transactions"`
+	Receipt      []string             `json:"receipt"`
+}
+
+// TransactionResponse describes one entry of the transaction history
+type TransactionResponse struct {
+	Type      string  `json:"type"`
+	Amount    float64 `json:"amount"`
+	TargetID  *int    `json:"target_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// HistoryResponse lists the transactions of the session's account, newest first
+type HistoryResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
+}
+
+// historyTypes are the accepted values of the history type query parameter
+var historyTypes = []string{"all", "deposit", "withdraw", "transfer_in", "transfer_out", "reversal_in", "reversal_out", "interbank_out", "interbank_in",
+	"interbank_refund", "adjustment_credit", "adjustment_debit", "payout", "capture", "hold", "interest", "fee"}
+
+// routes lists every endpoint of the API
+func (s *Server) routes() []route {
+	return []route{
+		{Method: "POST", Path: "/api/v1/register", Summary: "Daftarkan akun baru",
+			Request: RegisterRequest{}, Response: AccountResponse{}, Status: http.StatusCreated, Handler: s.register},
+		{Method: "POST", Path: "/api/v1/login", Summary: "Login dan dapatkan token sesi",
+			Request: CredentialsRequest{}, Response: LoginResponse{}, Status: http.StatusOK, Handler: s.login},
+		{Method: "POST", Path: "/api/v1/logout", Summary: "Akhiri sesi", Auth: true,
+			Status: http.StatusNoContent, Handler: s.logout},
+		{Method: "GET", Path: "/api/v1/balance", Summary: "Cek saldo", Auth: true, Permission: session.PermBalance,
+			Response: BalanceResponse{}, Status: http.StatusOK, Handler: s.balance},
+		{Method: "POST", Path: "/api/v1/deposit", Summary: "Setor tunai", Auth: true, Permission: session.PermDeposit,
+			Request: AmountRequest{}, Response: BalanceResponse{}, Status: http.StatusOK, Handler: s.deposit},
+		{Method: "POST", Path: "/api/v1/withdraw", Summary: "Tarik tunai", Auth: true, Permission: session.PermWithdraw,
+			Request: AmountRequest{}, Response: BalanceResponse{}, Status: http.StatusOK, Handler: s.withdraw},
+		{Method: "POST", Path: "/api/v1/transfer", Summary: "Transfer ke akun lain; di atas batas persetujuan dijawab 202 dan ditahan", Auth: true, Permission: session.PermTransfer,
+			Request: TransferRequest{}, Response: TransferResponse{}, Status: http.StatusOK, Also: []int{http.StatusAccepted}, Handler: s.transfer},
+		{Method: "GET", Path: "/api/v1/pending-transfers", Summary: "Transfer yang menunggu persetujuan", Auth: true, Permission: session.PermTransfer,
+			Response: PendingTransfersResponse{}, Status: http.StatusOK, Handler: s.pendingTransfers},
+		{Method: "POST", Path: "/api/v1/pending-transfers/cancel", Summary: "Batalkan transfer yang menunggu persetujuan", Auth: true, Permission: session.PermTransfer,
+			Request: CancelTransferRequest{}, Response: BalanceResponse{}, Status: http.StatusOK, Handler: s.cancelTransfer},
+		{Method: "GET", Path: "/api/v1/holds", Summary: "Dana yang ditahan", Auth: true, Permission: session.PermBalance,
+			Response: HoldsResponse{}, Status: http.StatusOK, Handler: s.holds},
+		{Method: "GET", Path: "/api/v1/mini-statement", Summary: "Mini statement: transaksi terakhir semua jenis dengan saldo berjalan", Auth: true, Permission: session.PermHistory,
+			Query: []queryParam{
+				{Name: "limit", Description: fmt.Sprintf("jumlah transaksi, 1-%d, default %d", statement.MaxMiniLength, statement.DefaultMiniLength)},
+				{Name: "lang", Description: "bahasa struk, default bahasa sesi", Enum: languages()},
+			},
+			Response: MiniStatementResponse{}, Status: http.StatusOK, Handler: s.miniStatement},
+		{Method: "GET", Path: "/api/v1/history", Summary: "Riwayat transaksi", Auth: true, Permission: session.PermHistory,
+			Query:    []queryParam{{Name: "type", Description: "jenis transaksi, default all", Enum: historyTypes}},
+			Response: HistoryResponse{}, Status: http.StatusOK, Handler: s.history},
+	}
+}
+
+// register creates a new account
+func (s *Server) register(r *http.Request, _ *session.Session, req interface{}) (int, interface{}, error) {
+	body := req.(*RegisterRequest)
+	account, err := user.Register(body.Name, body.PIN, strings.ToUpper(body.Currency), s.terminal(r).String())
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, AccountResponse{ID: account.ID, Name: account.Name, Currency: account.Currency}, nil
+}
+
+// login authenticates the customer and opens a session
+func (s *Server) login(r *http.Request, _ *session.Session, req interface{}) (int, interface{}, error) {
+	body := req.(*CredentialsRequest)
+	terminal := s.terminal(r)
+	account, err := user.Login(body.Name, body.PIN, terminal.String())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sess, err := session.Start(account, terminal, s.idleTimeout, s.absoluteTimeout)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.sessions.Add(sess)
+
+	return http.StatusOK, LoginResponse{
+		Token:     sess.ID,
+		ExpiresAt: sess.StartedAt.Add(sess.MaxDuration).UTC(),
+		Account:   AccountResponse{ID: account.ID, Name: account.Name, Currency: account.Currency},
+	}, nil
+}
+
+// logout ends the session
+func (s *Server) logout(_ *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	s.sessions.Remove(sess.ID)
+	if err := sess.End(session.ReasonLogout); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// balanceOf reads the current and available balance of the session's account
+func balanceOf(sess *session.Session) (BalanceResponse, error) {
+	balance, err := transaction.CheckBalance(sess.AccountID())
+	if err != nil {
+		return BalanceResponse{}, err
+	}
+	return BalanceResponse{AccountID: sess.AccountID(), Balance: balance.Ledger, AvailableBalance: balance.Available, Currency: sess.Currency()}, nil
+}
+
+// balanceResponse reads the current balance of the session's account
+func balanceResponse(sess *session.Session) (int, interface{}, error) {
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, balance, nil
+}
+
+// balance returns the balance of the session's account
+func (s *Server) balance(_ *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	return balanceResponse(sess)
+}
+
+// deposit adds money to the session's account
+func (s *Server) deposit(_ *http.Request, sess *session.Session, req interface{}) (int, interface{}, error) {
+	if err := transaction.Deposit(sess.AccountID(), req.(*AmountRequest).Amount); err != nil {
+		return 0, nil, err
+	}
+	return balanceResponse(sess)
+}
+
+// withdraw takes money from the session's account
+func (s *Server) withdraw(_ *http.Request, sess *session.Session, req interface{}) (int, interface{}, error) {
+	if err := transaction.Withdraw(sess.AccountID(), req.(*AmountRequest).Amount); err != nil {
+		return 0, nil, err
+	}
+	return balanceResponse(sess)
+}
+
+// transfer moves money from the session's account to another account
+func (s *Server) transfer(_ *http.Request, sess *session.Session, req interface{}) (int, interface{}, error) {
+	body := req.(*TransferRequest)
+	var pending *transaction.PendingApprovalError
+	err := transaction.Transfer(sess.AccountID(), body.TargetID, body.Amount)
+	if err != nil && !errors.As(err, &pending) {
+		return 0, nil, err
+	}
+
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return 0, nil, err
+	}
+	transfer := TransferResponse{AccountID: balance.AccountID, Balance: balance.Balance, AvailableBalance: balance.AvailableBalance, Currency: balance.Currency}
+	if pending != nil {
+		held := pendingTransferResponse(*pending.Transfer)
+		transfer.PendingTransfer = &held
+		return http.StatusAccepted, transfer, nil
+	}
+	return http.StatusOK, transfer, nil
+}
+
+// pendingTransfers lists the transfers of the session's account that wait for approval
+func (s *Server) pendingTransfers(_ *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	transfers, err := transaction.PendingTransfers(sess.AccountID())
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := PendingTransfersResponse{Transfers: []PendingTransferResponse{}}
+	for _, p := range transfers {
+		resp.Transfers = append(resp.Transfers, pendingTransferResponse(p))
+	}
+	return http.StatusOK, resp, nil
+}
+
+// cancelTransfer withdraws a held transfer of the session's account and releases its amount
+func (s *Server) cancelTransfer(_ *http.Request, sess *session.Session, req interface{}) (int, interface{}, error) {
+	if err := transaction.CancelTransfer(sess.AccountID(), req.(*CancelTransferRequest).ID); err != nil {
+		return 0, nil, err
+	}
+	return balanceResponse(sess)
+}
+
+// holds lists the active holds of the session's account
+func (s *Server) holds(_ *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	holds, err := transaction.Holds(sess.AccountID())
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := HoldsResponse{Holds: []HoldResponse{}}
+	for _, h := range holds {
+		resp.Holds = append(resp.Holds, HoldResponse{ID: h.ID, Amount: h.Amount, Reference: h.Reference, CreatedAt: h.CreatedAt, ExpiresAt: h.ExpiresAt})
+	}
+	return http.StatusOK, resp, nil
+}
+
+// languages are the codes of the languages of the terminal
+func languages() []string {
+	var codes []string
+	for _, l := range i18n.Languages {
+		codes = append(codes, l.Code)
+	}
+	return codes
+}
+
+// miniStatement returns the last transactions of the session's account with the balance after each
+func (s *Server) miniStatement(r *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	limit := statement.DefaultMiniLength
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: limit %q", errBadRequest, value)
+		}
+		limit = n
+	}
+	lang := sess.Language
+	if value := r.URL.Query().Get("lang"); value != "" {
+		if !i18n.Supported(value) {
+			return 0, nil, fmt.Errorf("%w: bahasa %q", errBadRequest, value)
+		}
+		lang = value
+	}
+
+	m, err := statement.GenerateMini(sess.AccountID(), limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := MiniStatementResponse{AccountID: m.Account.ID, Balance: m.Balance, Currency: m.Account.Currency,
+		Transactions: []MiniStatementEntry{}, Receipt: m.Receipt(i18n.New(lang))}
+	for _, l := range m.Lines {
+		resp.Transactions = append(resp.Transactions, MiniStatementEntry{Type: l.Type, Amount: l.Signed(), TargetID: l.TargetID, CreatedAt: l.CreatedAt, Balance: l.Balance})
+	}
+	return http.StatusOK, resp, nil
+}
+
+// history lists the transactions of the session's account, filtered by the type query parameter
+func (s *Server) history(r *http.Request, sess *session.Session, _ interface{}) (int, interface{}, error) {
+	transactionType := r.URL.Query().Get("type")
+	if transactionType == "" {
+		transactionType = "all"
+	}
+	valid := false
+	for _, t := range historyTypes {
+		valid = valid || t == transactionType
+	}
+	if !valid {
+		return 0, nil, fmt.Errorf("%w: tipe transaksi %q", errBadRequest, transactionType)
+	}
+
+	resp := HistoryResponse{Transactions: []TransactionResponse{}}
+	transactions, err := transaction.ViewTransactionHistory(sess.AccountID(), transactionType)
+	if errors.Is(err, transaction.ErrNoHistory) {
+		return http.StatusOK, resp, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, trans := range transactions {
+		item := TransactionResponse{
+			Type:      trans["type"].(string),
+			Amount:    trans["amount"].(float64),
+			CreatedAt: trans["created_at"].(string),
+		}
+		if targetID, ok := trans["target_id"].(*int); ok {
+			item.TargetID = targetID
+		}
+		resp.Transactions = append(resp.Transactions, item)
+	}
+	return http.StatusOK, resp, nil
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/statement"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errBadRequest marks errors caused by an invalid request
+var errBadRequest = errors.New("permintaan tidak valid")
+
+// Server exposes the ATM operations as a JSON HTTP API
+type Server struct {
+	terminalID      string
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+	sessions        *session.Store
+	mux             *http.ServeMux
+}
+
+// NewServer creates an API server whose sessions are reported as the given terminal
+func NewServer(terminalID string, idleTimeout, absoluteTimeout time.Duration) *Server {
+	s := &Server{
+		terminalID:      terminalID,
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+		sessions:        session.NewStore(),
+		mux:             http.NewServeMux(),
+	}
+
+	for _, rt := range s.routes() {
+		s.mux.HandleFunc(rt.Method+" "+rt.Path, s.wrap(rt))
+	}
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.OpenAPI())
+	})
+	return s
+}
+
+// ServeHTTP dispatches the request to the matching route
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SweepSessions ends idle sessions periodically until the stop channel is closed
+func (s *Server) SweepSessions(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sessions.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// handlerFunc handles a decoded request and returns the response body
+// The session is nil for routes that do not require a session
+type handlerFunc func(r *http.Request, sess *session.Session, req interface{}) (int, interface{}, error)
+
+// wrap authenticates, decodes and validates the request before calling the route handler
+func (s *Server) wrap(rt route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var sess *session.Session
+		if rt.Auth {
+			var err error
+			sess, err = s.authenticate(r)
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if rt.Permission != "" {
+				if err := sess.Authorize(rt.Permission); err != nil {
+					writeError(w, http.StatusForbidden, err.Error())
+					return
+				}
+			}
+			sess.Touch()
+		}
+
+		var req interface{}
+		if rt.Request != nil {
+			req = rt.newRequest()
+			decoder := json.NewDecoder(r.Body)
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(req); err != nil {
+				writeError(w, http.StatusBadRequest, "body JSON tidak valid: "+err.Error())
+				return
+			}
+			if v, ok := req.(validator); ok {
+				if err := v.Validate(); err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+			}
+		}
+
+		status, resp, err := rt.Handler(r, sess, req)
+		if err != nil {
+			status, message := statusFor(err)
+			if status == http.StatusInternalServerError {
+				log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			}
+			writeError(w, status, message)
+			return
+		}
+		if resp == nil {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, resp)
+	}
+}
+
+// authenticate returns the session named by the bearer token of the request
+func (s *Server) authenticate(r *http.Request) (*session.Session, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, fmt.Errorf("token sesi tidak ada")
+	}
+	return s.sessions.Get(token)
+}
+
+// terminal describes the client of the request for the audit log
+func (s *Server) terminal(r *http.Request) session.Terminal {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return session.Terminal{ID: s.terminalID, Source: host}
+}
+
+// statusFor maps a business error onto an HTTP status and a message safe to show to clients
+func statusFor(err error) (int, string) {
+	switch {
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, user.ErrAccountLocked), errors.Is(err, user.ErrAccountFrozen), errors.Is(err, user.ErrAccountDormant),
+		errors.Is(err, user.ErrAccountClosed):
+		return http.StatusLocked, err.Error()
+	case errors.Is(err, user.ErrWrongPIN), errors.Is(err, user.ErrAccountNotFound):
+		return http.StatusUnauthorized, err.Error()
+	case errors.Is(err, user.ErrNameTaken):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, transaction.ErrAccountNotFound), errors.Is(err, transaction.ErrTargetNotFound),
+		errors.Is(err, transaction.ErrTransferNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, transaction.ErrInvalidAmount), errors.Is(err, transaction.ErrSameAccount),
+		errors.Is(err, currency.ErrUnknownCurrency), errors.Is(err, statement.ErrInvalidLength), user.IsPINPolicyError(err):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, transaction.ErrInsufficientBalance), errors.Is(err, transaction.ErrCurrencyMismatch),
+		errors.Is(err, transaction.ErrTargetUnavailable), errors.Is(err, transaction.ErrTransferNotPending),
+		errors.Is(err, transaction.ErrTransferExpired):
+		return http.StatusUnprocessableEntity, err.Error()
+	default:
+		return http.StatusInternalServerError, "terjadi kesalahan pada server"
+	}
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Gagal menulis respons:", err)
+	}
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
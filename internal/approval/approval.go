@@ -0,0 +1,216 @@
+package approval
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/reconcile"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Errors returned by the approval queue
+var (
+	ErrNotFound         = errors.New("permintaan persetujuan tidak ditemukan")
+	ErrNotPending       = errors.New("permintaan sudah diputuskan")
+	ErrUnknownOperation = errors.New("operasi tidak memerlukan persetujuan")
+)
+
+// Operations that wait in the queue until a second person approves them
+const (
+	OperationAdjustBalance = "adjust_balance"
+	OperationCloseAccount  = "close_account"
+	// OperationReconcile writes the correcting entry of a balance that disagrees with its transactions
+	OperationReconcile = "reconcile_balance"
+)
+
+// operationPermissions is the permission a maker needs to request each operation
+var operationPermissions = map[string]authz.Permission{
+	OperationAdjustBalance: authz.PermAdjust,
+	OperationCloseAccount:  authz.PermClose,
+	OperationReconcile:     authz.PermReconcile,
+}
+
+// Statuses of a request
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Request is an operation made by one member of staff (the maker) that waits for another (the checker)
+type Request struct {
+	ID        int     `db:"id"`
+	Operation string  `db:"operation"`
+	AccountID int     `db:"account_id"`
+	Amount    float64 `db:"amount"` // balance correction or reconciliation difference, zero for a closure
+	Reason    string  `db:"reason"`
+	Maker     string  `db:"maker"`
+	Status    string  `db:"status"`
+	Checker   *string `db:"checker"`
+	Note      string  `db:"note"` // reason given by the checker when the request is rejected
+	CreatedAt string  `db:"created_at"`
+	DecidedAt *string `db:"decided_at"`
+}
+
+// Result is what an approved request did
+type Result struct {
+	Payout   float64 // balance paid out when an account was closed
+	Currency string  // currency of the account
+}
+
+// Submit queues an operation on behalf of the maker, who must be allowed to request it
+func Submit(maker authz.Principal, operation string, accountID int, amount float64, reason, source string) (*Request, error) {
+	perm, ok := operationPermissions[operation]
+	if !ok {
+		return nil, ErrUnknownOperation
+	}
+	if err := authz.Authorize(maker, perm); err != nil {
+		audit.RecordOrLog(maker.Name, audit.ActionApprovalSubmit, accountID, audit.OutcomeFailure, source, err.Error())
+		return nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, user.ErrReasonRequired
+	}
+	if (operation == OperationAdjustBalance || operation == OperationReconcile) && amount == 0 {
+		return nil, user.ErrInvalidAmount
+	}
+	if _, err := user.Get(accountID); err != nil {
+		return nil, err
+	}
+
+	r := &Request{Operation: operation, AccountID: accountID, Amount: amount, Reason: reason, Maker: maker.Name, Status: StatusPending}
+	result, err := db.DB.NamedExec(`INSERT INTO approvals (operation, account_id, amount, reason, maker, status)
+		VALUES (:operation, :account_id, :amount, :reason, :maker, :status)`, r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	r.ID = int(id)
+	audit.RecordOrLog(maker.Name, audit.ActionApprovalSubmit, accountID, audit.OutcomeSuccess, source, fmt.Sprintf("#%d %s: %s", r.ID, operation, reason))
+	return r, nil
+}
+
+// Get retrieves a request by its ID
+func Get(id int) (*Request, error) {
+	r := &Request{}
+	err := db.DB.Get(r, "SELECT * FROM approvals WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// Pending returns the requests waiting for a decision, oldest first
+func Pending() ([]Request, error) {
+	var requests []Request
+	err := db.DB.Select(&requests, "SELECT * FROM approvals WHERE status = ? ORDER BY id", StatusPending)
+	return requests, err
+}
+
+// Approve carries out the request on behalf of the checker, who may not be its maker
+// The request goes back to pending when the operation fails, so that it can be retried or rejected,
+// except for a reconciliation correction whose difference is gone or changed, which is rejected
+func Approve(id int, checker authz.Principal, source string) (*Result, error) {
+	r, err := decide(id, checker, audit.ActionApprove, source)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := user.Get(r.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the request first so that two checkers cannot both carry it out
+	if err := setDecision(r.ID, StatusPending, StatusApproved, &checker.Name, ""); err != nil {
+		return nil, err
+	}
+
+	// The operation is recorded as performed by the checker, with the maker named in the reason
+	reason := fmt.Sprintf("%s (diajukan %s, persetujuan #%d)", r.Reason, r.Maker, r.ID)
+	result := &Result{Currency: account.Currency}
+	switch r.Operation {
+	case OperationAdjustBalance:
+		err = user.AdjustBalance(r.AccountID, r.Amount, reason, checker, source)
+	case OperationCloseAccount:
+		result.Payout, err = user.Close(r.AccountID, reason, checker, source)
+	case OperationReconcile:
+		err = reconcile.Correct(r.ID, r.AccountID, r.Amount, reason, checker.Name, source)
+	default:
+		err = ErrUnknownOperation
+	}
+	if errors.Is(err, reconcile.ErrNoMismatch) || errors.Is(err, reconcile.ErrMismatchChanged) {
+		// A stale correction can never succeed, so it is closed instead of waiting forever
+		if rejectErr := setDecision(r.ID, StatusApproved, StatusRejected, &checker.Name, err.Error()); rejectErr != nil {
+			log.Printf("Persetujuan #%d tidak dapat ditolak: %v", r.ID, rejectErr)
+		}
+	} else if err != nil {
+		if resetErr := setDecision(r.ID, StatusApproved, StatusPending, nil, ""); resetErr != nil {
+			log.Printf("Persetujuan #%d tidak dapat dikembalikan ke pending: %v", r.ID, resetErr)
+		}
+	}
+	if err != nil {
+		audit.RecordOrLog(checker.Name, audit.ActionApprove, r.AccountID, audit.OutcomeFailure, source, fmt.Sprintf("#%d: %v", r.ID, err))
+		return nil, err
+	}
+	audit.RecordOrLog(checker.Name, audit.ActionApprove, r.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("#%d %s", r.ID, r.Operation))
+	return result, nil
+}
+
+// Reject turns the request down on behalf of the checker, who may not be its maker
+func Reject(id int, checker authz.Principal, note, source string) error {
+	if strings.TrimSpace(note) == "" {
+		return user.ErrReasonRequired
+	}
+	r, err := decide(id, checker, audit.ActionReject, source)
+	if err != nil {
+		return err
+	}
+	if err := setDecision(r.ID, StatusPending, StatusRejected, &checker.Name, note); err != nil {
+		return err
+	}
+	audit.RecordOrLog(checker.Name, audit.ActionReject, r.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("#%d: %s", r.ID, note))
+	return nil
+}
+
+// decide loads a pending request and checks that the checker may decide on it
+func decide(id int, checker authz.Principal, action, source string) (*Request, error) {
+	r, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+	if err := authz.AuthorizeApproval(checker, r.Maker); err != nil {
+		audit.RecordOrLog(checker.Name, action, r.AccountID, audit.OutcomeFailure, source, fmt.Sprintf("#%d: %v", r.ID, err))
+		return nil, err
+	}
+	return r, nil
+}
+
+// setDecision moves the request from one status to another, failing with ErrNotPending
+// when someone else changed it first; a nil checker clears the decision
+func setDecision(id int, from, to string, checker *string, note string) error {
+	res, err := db.DB.Exec(`UPDATE approvals SET status = ?, checker = ?, note = ?,
+		decided_at = IF(? IS NULL, NULL, CURRENT_TIMESTAMP) WHERE id = ? AND status = ?`, to, checker, note, checker, id, from)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"atm-simulation/pkg/db"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionRegister  = "register"
+	ActionLogin     = "login"
+	ActionPINChange = "pin_change"
+	ActionLockout   = "lockout"
+	// ActionStandInReplay records offline withdrawals that could not be posted cleanly
+	ActionStandInReplay = "standin_replay"
+	// ActionReconcile records the correcting entries written for a balance that disagreed with its transactions
+	ActionReconcile = "reconcile"
+)
+
+// Actions of operators in the admin console
+const (
+	ActionOperatorLogin  = "operator_login"
+	ActionOperatorCreate = "operator_create"
+	ActionAccountSearch  = "account_search"
+	ActionAccountView    = "account_view"
+	ActionFreeze         = "freeze"
+	ActionUnfreeze       = "unfreeze"
+	ActionReactivate     = "reactivate"
+	ActionDormant        = "dormant"
+	ActionClose          = "close"
+	ActionPINReset       = "pin_reset"
+	ActionAdjustBalance  = "adjust_balance"
+	ActionUnlock         = "unlock"
+	ActionApprovalSubmit = "approval_submit"
+	ActionApprove        = "approval_approve"
+	ActionReject         = "approval_reject"
+	ActionAuditView      = "audit_view"
+	ActionHoldPlace      = "hold_place"
+	ActionHoldCapture    = "hold_capture"
+	ActionHoldRelease    = "hold_release"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry represents a single security event in the audit log
+type Entry struct {
+	ID              int64  `db:"id" json:"id"`
+	Actor           string `db:"actor" json:"actor"`
+	Action          string `db:"action" json:"action"`
+	TargetAccountID *int   `db:"target_account_id" json:"target_account_id,omitempty"`
+	Outcome         string `db:"outcome" json:"outcome"`
+	Source          string `db:"source" json:"source"`
+	Detail          string `db:"detail" json:"detail,omitempty"`
+	CreatedAt       string `db:"created_at" json:"created_at"`
+}
+
+// Record stores a new entry in the audit log
+// A target account ID of 0 is stored as NULL
+func Record(actor, action string, targetAccountID int, outcome, source, detail string) error {
+	entry := Entry{Actor: actor, Action: action, Outcome: outcome, Source: source, Detail: detail}
+	if targetAccountID != 0 {
+		entry.TargetAccountID = &targetAccountID
+	}
+	_, err := db.DB.NamedExec(`INSERT INTO audit_logs (actor, action, target_account_id, outcome, source, detail)
+		VALUES (:actor, :action, :target_account_id, :outcome, :source, :detail)`, entry)
+	return err
+}
+
+// RecordOrLog stores a new entry in the audit log and only logs when that fails,
+// for callers whose action already took effect and must not fail because of the log
+func RecordOrLog(actor, action string, targetAccountID int, outcome, source, detail string) {
+	if err := Record(actor, action, targetAccountID, outcome, source, detail); err != nil {
+		log.Println("Gagal mencatat audit log:", err)
+	}
+}
+
+// Filter selects audit log entries
+// Zero values match everything
+type Filter struct {
+	Actor           string
+	Action          string
+	TargetAccountID int
+	Outcome         string
+	From            time.Time
+	To              time.Time
+	Limit           int
+}
+
+// Query retrieves the audit log entries matching the filter, newest first
+func Query(filter Filter) ([]Entry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetAccountID != 0 {
+		conditions = append(conditions, "target_account_id = ?")
+		args = append(args, filter.TargetAccountID)
+	}
+	if filter.Outcome != "" {
+		conditions = append(conditions, "outcome = ?")
+		args = append(args, filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.Format(time.DateTime))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To.Format(time.DateTime))
+	}
+
+	query := "SELECT id, actor, action, target_account_id, outcome, source, detail, created_at FROM audit_logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	var entries []Entry
+	if err := db.DB.Select(&entries, query, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ExportJSONLines writes the entries as JSON lines, one entry per line
+func ExportJSONLines(w io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
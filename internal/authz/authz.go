@@ -0,0 +1,153 @@
+package authz
+
+import (
+	"atm-simulation/internal/i18n"
+	"errors"
+	"fmt"
+)
+
+// Role is the kind of user an action is performed by
+type Role string
+
+// Roles known to the bank
+const (
+	RoleCustomer        Role = "customer"         // an account holder at a terminal or through the API
+	RoleTeller          Role = "teller"           // branch staff serving customers
+	RoleSupervisor      Role = "supervisor"       // branch staff approving sensitive operations
+	RoleAuditor         Role = "auditor"          // internal audit, read only
+	RoleCashReplenisher Role = "cash_replenisher" // loads cash into the terminals
+	RoleSystem          Role = "system"           // scheduled jobs of the bank, not a person
+)
+
+// Permission is an action that has to be granted to the role of the user performing it
+type Permission string
+
+// Permissions of customers
+const (
+	PermBalance   Permission = "balance"
+	PermDeposit   Permission = "deposit"
+	PermWithdraw  Permission = "withdraw"
+	PermTransfer  Permission = "transfer"
+	PermHistory   Permission = "history"
+	PermProfile   Permission = "profile"
+	PermChangePIN Permission = "change_pin"
+)
+
+// Permissions of the bank staff
+const (
+	PermSearch        Permission = "search"
+	PermView          Permission = "view"
+	PermFreeze        Permission = "freeze"
+	PermReactivate    Permission = "reactivate"
+	PermClose         Permission = "close"
+	PermResetPIN      Permission = "reset_pin"
+	PermAdjust        Permission = "adjust"
+	PermUnlock        Permission = "unlock"
+	PermApprove       Permission = "approve"
+	PermAuditLog      Permission = "audit_log"
+	PermCashReplenish Permission = "cash_replenish"
+	PermReconcile     Permission = "reconcile"
+	PermOperators     Permission = "operators"
+	PermHold          Permission = "hold"
+)
+
+// rolePermissions lists what each role may do
+// Closing accounts and adjusting balances move money: tellers and supervisors may request them,
+// but they only take effect once a second supervisor approves (see the approval package). The same goes
+// for the corrections the reconciliation job proposes
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer:        {PermBalance, PermDeposit, PermWithdraw, PermTransfer, PermHistory, PermProfile, PermChangePIN},
+	RoleTeller:          {PermSearch, PermView, PermFreeze, PermReactivate, PermClose, PermResetPIN, PermAdjust, PermUnlock, PermHold},
+	RoleSupervisor:      {PermSearch, PermView, PermFreeze, PermReactivate, PermClose, PermResetPIN, PermAdjust, PermUnlock, PermHold, PermApprove, PermOperators},
+	RoleAuditor:         {PermSearch, PermView, PermAuditLog},
+	RoleCashReplenisher: {PermCashReplenish},
+	RoleSystem:          {PermReconcile},
+}
+
+// Reasons for which an action is denied
+var (
+	ErrUnknownRole  = i18n.NewError("error.unknown_role", "peran tidak dikenal")
+	ErrNotPermitted = i18n.NewError("error.not_permitted", "peran tidak memiliki izin untuk aksi ini")
+	ErrOutOfScope   = i18n.NewError("error.out_of_scope", "aksi tidak tersedia dalam sesi ini")
+	ErrSelfApproval = i18n.NewError("error.self_approval", "permintaan tidak boleh disetujui oleh pembuatnya sendiri")
+)
+
+// DeniedError is returned when a principal is not allowed to perform an action
+// Reason is one of the Err values above and can be matched with errors.Is
+type DeniedError struct {
+	Principal  string
+	Role       Role
+	Permission Permission
+	Reason     error
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s (%s) tidak diizinkan melakukan %s: %v", e.Principal, e.Role, e.Permission, e.Reason)
+}
+
+func (e *DeniedError) Unwrap() error {
+	return e.Reason
+}
+
+// IsDenied reports whether the error is a denial of the authorization layer
+func IsDenied(err error) bool {
+	var denied *DeniedError
+	return errors.As(err, &denied)
+}
+
+// Principal is the user an action is performed for
+type Principal struct {
+	Name  string // username of a staff member, account ID of a customer
+	Role  Role
+	Scope []Permission // when not nil, the only permissions usable, e.g. in a stand-in session
+}
+
+// Authorize returns a *DeniedError when the principal may not perform the action
+func Authorize(p Principal, perm Permission) error {
+	granted, ok := rolePermissions[p.Role]
+	if !ok {
+		return &DeniedError{Principal: p.Name, Role: p.Role, Permission: perm, Reason: ErrUnknownRole}
+	}
+	if !contains(granted, perm) {
+		return &DeniedError{Principal: p.Name, Role: p.Role, Permission: perm, Reason: ErrNotPermitted}
+	}
+	if p.Scope != nil && !contains(p.Scope, perm) {
+		return &DeniedError{Principal: p.Name, Role: p.Role, Permission: perm, Reason: ErrOutOfScope}
+	}
+	return nil
+}
+
+// AuthorizeApproval returns a *DeniedError when the checker may not approve or reject a request made by the maker
+func AuthorizeApproval(checker Principal, maker string) error {
+	if err := Authorize(checker, PermApprove); err != nil {
+		return err
+	}
+	if checker.Name == maker {
+		return &DeniedError{Principal: checker.Name, Role: checker.Role, Permission: PermApprove, Reason: ErrSelfApproval}
+	}
+	return nil
+}
+
+// Permissions returns the permissions of the role
+func Permissions(role Role) []Permission {
+	return append([]Permission(nil), rolePermissions[role]...)
+}
+
+// StaffRoles returns the roles of the bank staff, who use the admin console
+func StaffRoles() []Role {
+	return []Role{RoleTeller, RoleSupervisor, RoleAuditor, RoleCashReplenisher}
+}
+
+// IsStaffRole reports whether the role belongs to the bank staff
+func IsStaffRole(role Role) bool {
+	return role != RoleCustomer && role != RoleSystem && rolePermissions[role] != nil
+}
+
+func contains(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		perm      Permission
+		want      error
+	}{
+		{"customer withdraws", Principal{Name: "12", Role: RoleCustomer}, PermWithdraw, nil},
+		{"customer searches accounts", Principal{Name: "12", Role: RoleCustomer}, PermSearch, ErrNotPermitted},
+		{"teller freezes", Principal{Name: "ani", Role: RoleTeller}, PermFreeze, nil},
+		{"teller approves", Principal{Name: "ani", Role: RoleTeller}, PermApprove, ErrNotPermitted},
+		{"teller adds an operator", Principal{Name: "ani", Role: RoleTeller}, PermOperators, ErrNotPermitted},
+		{"supervisor adds an operator", Principal{Name: "budi", Role: RoleSupervisor}, PermOperators, nil},
+		{"teller places a hold", Principal{Name: "ani", Role: RoleTeller}, PermHold, nil},
+		{"auditor places a hold", Principal{Name: "citra", Role: RoleAuditor}, PermHold, ErrNotPermitted},
+		{"auditor reads the audit log", Principal{Name: "citra", Role: RoleAuditor}, PermAuditLog, nil},
+		{"auditor adjusts a balance", Principal{Name: "citra", Role: RoleAuditor}, PermAdjust, ErrNotPermitted},
+		{"cash replenisher replenishes", Principal{Name: "dedi", Role: RoleCashReplenisher}, PermCashReplenish, nil},
+		{"system reconciles", Principal{Name: "reconcile", Role: RoleSystem}, PermReconcile, nil},
+		{"system approves", Principal{Name: "reconcile", Role: RoleSystem}, PermApprove, ErrNotPermitted},
+		{"unknown role", Principal{Name: "eko", Role: "manager"}, PermView, ErrUnknownRole},
+		{"empty role", Principal{Name: "eko"}, PermView, ErrUnknownRole},
+		{"within scope", Principal{Name: "12", Role: RoleCustomer, Scope: []Permission{PermBalance, PermWithdraw}}, PermWithdraw, nil},
+		{"outside scope", Principal{Name: "12", Role: RoleCustomer, Scope: []Permission{PermBalance, PermWithdraw}}, PermTransfer, ErrOutOfScope},
+		{"scope does not grant", Principal{Name: "12", Role: RoleCustomer, Scope: []Permission{PermSearch}}, PermSearch, ErrNotPermitted},
+		{"empty scope denies everything", Principal{Name: "12", Role: RoleCustomer, Scope: []Permission{}}, PermBalance, ErrOutOfScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.principal, tt.perm)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Authorize(%s, %s) = %v, want %v", tt.principal.Role, tt.perm, err, tt.want)
+			}
+			if err != nil && !IsDenied(err) {
+				t.Errorf("Authorize(%s, %s) = %v, want a *DeniedError", tt.principal.Role, tt.perm, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizeApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker Principal
+		maker   string
+		want    error
+	}{
+		{"another supervisor", Principal{Name: "budi", Role: RoleSupervisor}, "ani", nil},
+		{"own request", Principal{Name: "budi", Role: RoleSupervisor}, "budi", ErrSelfApproval},
+		{"teller", Principal{Name: "ani", Role: RoleTeller}, "budi", ErrNotPermitted},
+		{"auditor", Principal{Name: "citra", Role: RoleAuditor}, "budi", ErrNotPermitted},
+		{"unknown role", Principal{Name: "eko", Role: "manager"}, "budi", ErrUnknownRole},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeApproval(tt.checker, tt.maker)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AuthorizeApproval(%s, %s) = %v, want %v", tt.checker.Name, tt.maker, err, tt.want)
+			}
+			if err != nil && !IsDenied(err) {
+				t.Errorf("AuthorizeApproval(%s, %s) = %v, want a *DeniedError", tt.checker.Name, tt.maker, err)
+			}
+		})
+	}
+}
+
+func TestIsStaffRole(t *testing.T) {
+	tests := []struct {
+		role Role
+		want bool
+	}{
+		{RoleTeller, true},
+		{RoleSupervisor, true},
+		{RoleAuditor, true},
+		{RoleCashReplenisher, true},
+		{RoleCustomer, false},
+		{RoleSystem, false},
+		{"manager", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if got := IsStaffRole(tt.role); got != tt.want {
+				t.Errorf("IsStaffRole(%s) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
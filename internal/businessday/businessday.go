@@ -0,0 +1,178 @@
+package businessday
+
+import (
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned by the business date functions
+var (
+	ErrNoBusinessDate = errors.New("belum ada tanggal bisnis yang dibuka, jalankan `atm eod start`")
+	ErrAlreadyStarted = errors.New("tanggal bisnis sudah dimulai")
+	ErrBeforeCutoff   = errors.New("hari bisnis belum melewati jam cut-off")
+	ErrNotOpen        = errors.New("tanggal bisnis tidak sedang dibuka")
+)
+
+// Statuses of a business date
+const (
+	StatusOpen    = "open"    // transactions are booked on it
+	StatusClosing = "closing" // closed for transactions, the end-of-day run is still working on it
+	StatusClosed  = "closed"  // the end-of-day run finished
+)
+
+// Layout is how a business date is written, as the database writes a DATE
+const Layout = time.DateOnly
+
+// DefaultCutoff is the time of day after which transactions are booked on the next business date
+const DefaultCutoff = 17 * time.Hour
+
+// Cutoff is the cut-off time of the business dates opened from now on, as an offset from midnight
+var Cutoff = DefaultCutoff
+
+// SQL is the business date of a transaction posted now, for the business_date column of the
+// transactions table: the open business date, or the one after it once its cut-off time has passed
+const SQL = `(SELECT IF(NOW() >= b.cutoff_at, b.business_date + INTERVAL 1 DAY, b.business_date)
+	FROM business_dates b WHERE b.status = 'open')`
+
+// Day is a business date
+type Day struct {
+	Date     string  `db:"business_date"`
+	Status   string  `db:"status"`
+	CutoffAt string  `db:"cutoff_at"`
+	OpenedAt string  `db:"opened_at"`
+	ClosedAt *string `db:"closed_at"`
+}
+
+// Time returns the business date at midnight in local time
+func (d *Day) Time() time.Time {
+	t, _ := time.ParseInLocation(Layout, d.Date, time.Local)
+	return t
+}
+
+// DateAt returns the business date of a transaction posted at the time while this date is open:
+// the date itself, or the next one once its cut-off time has passed, as SQL does in the database
+func (d *Day) DateAt(t time.Time) string {
+	cutoff, err := time.ParseInLocation(time.DateTime, d.CutoffAt, time.Local)
+	if err == nil && !t.Before(cutoff) {
+		return d.Time().AddDate(0, 0, 1).Format(Layout)
+	}
+	return d.Date
+}
+
+// MonthEnd reports whether the business date is the last day of its month
+func (d *Day) MonthEnd() bool {
+	return d.Time().AddDate(0, 0, 1).Day() == 1
+}
+
+// Start opens the first business date; it fails once any business date exists
+func Start(date time.Time) (*Day, error) {
+	var count int
+	if err := db.DB.Get(&count, "SELECT COUNT(*) FROM business_dates"); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyStarted
+	}
+	if err := open(db.DB, date); err != nil {
+		return nil, err
+	}
+	return Get(date.Format(Layout))
+}
+
+// execer is a database or a transaction to write business dates with
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// open adds the business date with the cut-off time of today's configuration
+func open(e execer, date time.Time) error {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	_, err := e.Exec("INSERT INTO business_dates (business_date, status, cutoff_at) VALUES (?, ?, ?)",
+		day.Format(Layout), StatusOpen, day.Add(Cutoff).Format(time.DateTime))
+	return err
+}
+
+// Get retrieves a business date
+func Get(date string) (*Day, error) {
+	d := &Day{}
+	err := db.DB.Get(d, "SELECT * FROM business_dates WHERE business_date = ?", date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoBusinessDate
+	}
+	return d, err
+}
+
+// Current returns the open business date
+func Current() (*Day, error) {
+	d := &Day{}
+	err := db.DB.Get(d, "SELECT * FROM business_dates WHERE status = ?", StatusOpen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoBusinessDate
+	}
+	return d, err
+}
+
+// Closing returns the business date the end-of-day run is working on, nil when there is none
+func Closing() (*Day, error) {
+	d := &Day{}
+	err := db.DB.Get(d, "SELECT * FROM business_dates WHERE status = ? ORDER BY business_date LIMIT 1", StatusClosing)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// Close stops booking transactions on the open business date once its cut-off time has passed,
+// and opens the next date in the same database transaction, so every transaction has a date to go on
+func Close(date string) (*Day, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var passed bool
+	err = tx.Get(&passed, "SELECT NOW() >= cutoff_at FROM business_dates WHERE business_date = ? AND status = ? FOR UPDATE", date, StatusOpen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotOpen
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !passed {
+		return nil, ErrBeforeCutoff
+	}
+
+	if _, err := tx.Exec("UPDATE business_dates SET status = ? WHERE business_date = ?", StatusClosing, date); err != nil {
+		return nil, err
+	}
+	day, err := time.ParseInLocation(Layout, date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	next := day.AddDate(0, 0, 1)
+	if err := open(tx, next); err != nil {
+		return nil, fmt.Errorf("tanggal bisnis %s: %w", next.Format(Layout), err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return Get(next.Format(Layout))
+}
+
+// Finish records that the end-of-day run of the business date is complete
+func Finish(date string) error {
+	_, err := db.DB.Exec("UPDATE business_dates SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE business_date = ? AND status = ?",
+		StatusClosed, date, StatusClosing)
+	return err
+}
+
+// Recent returns the latest business dates, newest first
+func Recent(limit int) ([]Day, error) {
+	var days []Day
+	err := db.DB.Select(&days, "SELECT * FROM business_dates ORDER BY business_date DESC LIMIT ?", limit)
+	return days, err
+}
@@ -0,0 +1,380 @@
+package clearing
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Statuses of an interbank transfer in the clearing network
+const (
+	// StatusPending means the source account has been debited and the transfer waits for the next clearing cycle
+	StatusPending = "pending"
+	// StatusSettled means the beneficiary bank accepted the transfer and the beneficiary was credited
+	StatusSettled = "settled"
+	// StatusRejected means the beneficiary bank refused the transfer and the source account was refunded
+	StatusRejected = "rejected"
+)
+
+// Errors returned by the clearing functions
+var (
+	ErrUnknownBank         = i18n.NewError("error.unknown_bank", "kode bank tidak dikenal")
+	ErrSameBank            = i18n.NewError("error.same_bank", "bank tujuan sama dengan bank asal, gunakan transfer biasa")
+	ErrBeneficiaryNotFound = i18n.NewError("error.beneficiary_not_found", "rekening tujuan tidak ditemukan di bank tujuan")
+	ErrNothingToSettle     = errors.New("tidak ada transfer yang menunggu settlement")
+	ErrForeignCurrency     = i18n.NewError("error.foreign_currency", "kliring antarbank hanya melayani rekening dalam "+currency.Base)
+)
+
+// Bank is a participant of the clearing network
+type Bank struct {
+	Code string `db:"code"`
+	Name string `db:"name"`
+}
+
+// Transfer is an interbank transfer routed through the clearing network
+type Transfer struct {
+	ID              int64   `db:"id"`
+	Reference       string  `db:"reference"`
+	SourceBank      string  `db:"source_bank"`
+	SourceAccountID int     `db:"source_account_id"`
+	DestBank        string  `db:"dest_bank"`
+	DestAccountID   int     `db:"dest_account_id"`
+	Amount          float64 `db:"amount"`
+	Status          string  `db:"status"`
+	Reason          string  `db:"reason"`
+	SettlementID    *int64  `db:"settlement_id"`
+	CreatedAt       string  `db:"created_at"`
+	ProcessedAt     *string `db:"processed_at"`
+}
+
+// Position is the net amount a bank receives (positive) or pays (negative) in a settlement
+type Position struct {
+	BankCode string  `db:"bank_code"`
+	Net      float64 `db:"net_amount"`
+}
+
+// Banks returns the participants of the clearing network
+func Banks() ([]Bank, error) {
+	var banks []Bank
+	err := db.DB.Select(&banks, "SELECT code, name FROM banks ORDER BY code")
+	return banks, err
+}
+
+// bankName returns the name of a participant
+func bankName(code string) (string, error) {
+	var name string
+	err := db.DB.Get(&name, "SELECT name FROM banks WHERE code = ?", code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUnknownBank
+	}
+	return name, err
+}
+
+// Inquire returns the name of the beneficiary of an account at another bank, so the
+// customer can check it before confirming the transfer
+func Inquire(bankCode string, accountID int) (string, error) {
+	if _, err := bankName(bankCode); err != nil {
+		return "", err
+	}
+	var beneficiary user.Account
+	err := db.DB.Get(&beneficiary, "SELECT name, status FROM accounts WHERE id = ? AND bank_code = ? AND locked_at IS NULL", accountID, bankCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrBeneficiaryNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if user.CheckStatus(beneficiary.Status, user.OpReceive) != nil {
+		return "", ErrBeneficiaryNotFound
+	}
+	return beneficiary.Name, nil
+}
+
+// newReference generates the reference of a clearing transfer
+func newReference() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "CLR-" + hex.EncodeToString(b), nil
+}
+
+// Submit debits the source account and hands the transfer to the clearing network
+// The beneficiary is only credited by the next clearing cycle. A transfer above the approval threshold
+// is held for a supervisor instead and a *transaction.PendingApprovalError returned
+func Submit(sourceAccountID int, destBank string, destAccountID int, amount float64) (*Transfer, error) {
+	if amount <= 0 {
+		return nil, transaction.ErrInvalidAmount
+	}
+	if _, err := bankName(destBank); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	source, err := lockSource(tx, sourceAccountID, destBank)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.NeedsApproval(amount) {
+		tx.Rollback()
+		return nil, transaction.HoldInterbank(sourceAccountID, destBank, destAccountID, amount)
+	}
+	if source.Balance < amount {
+		return nil, transaction.ErrInsufficientBalance
+	}
+
+	t, err := submit(tx, source, destBank, destAccountID, amount)
+	if err != nil {
+		return nil, err
+	}
+	return t, tx.Commit()
+}
+
+// postApproved hands a transfer held for approval to the clearing network once a supervisor approves it
+func postApproved(tx *sqlx.Tx, p *transaction.PendingTransfer) error {
+	source, err := lockSource(tx, p.AccountID, *p.DestBank)
+	if err != nil {
+		return err
+	}
+	// The held amount still counts against the available balance until the approval is recorded
+	if available := source.Balance + p.Amount; available < p.Amount {
+		return transaction.ErrInsufficientBalance
+	}
+	_, err = submit(tx, source, *p.DestBank, p.TargetID, p.Amount)
+	return err
+}
+
+func init() {
+	transaction.PostInterbank = postApproved
+}
+
+// lockSource locks the source account of an interbank transfer and checks that it may send to the bank
+// The balance of the returned account is its available balance
+func lockSource(tx *sqlx.Tx, accountID int, destBank string) (*user.Account, error) {
+	source := &user.Account{}
+	err := tx.Get(source, "SELECT id, bank_code, currency, status, "+transaction.AvailableSQL+" AS balance FROM accounts WHERE id = ? FOR UPDATE", accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, transaction.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := user.CheckStatus(source.Status, user.OpSend); err != nil {
+		return nil, err
+	}
+	if source.BankCode == destBank {
+		return nil, ErrSameBank
+	}
+	if source.Currency != currency.Base {
+		return nil, ErrForeignCurrency
+	}
+	return source, nil
+}
+
+// submit debits the source account and records the transfer for the next clearing cycle
+func submit(tx *sqlx.Tx, source *user.Account, destBank string, destAccountID int, amount float64) (*Transfer, error) {
+	reference, err := newReference()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, source.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, 'interbank_out', ?, ?, `+businessday.SQL+`)`,
+		source.ID, amount, reference); err != nil {
+		return nil, err
+	}
+
+	t := &Transfer{
+		Reference:       reference,
+		SourceBank:      source.BankCode,
+		SourceAccountID: source.ID,
+		DestBank:        destBank,
+		DestAccountID:   destAccountID,
+		Amount:          amount,
+		Status:          StatusPending,
+	}
+	result, err := tx.NamedExec(`INSERT INTO clearing_transfers (reference, source_bank, source_account_id, dest_bank, dest_account_id, amount, status)
+		VALUES (:reference, :source_bank, :source_account_id, :dest_bank, :dest_account_id, :amount, :status)`, t)
+	if err != nil {
+		return nil, err
+	}
+	if t.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Process runs one clearing cycle: every pending transfer is either credited to the
+// beneficiary (settled) or refunded to the source account (rejected)
+func Process() (settled, rejected int, err error) {
+	var pending []Transfer
+	if err := db.DB.Select(&pending, "SELECT * FROM clearing_transfers WHERE status = ? ORDER BY id", StatusPending); err != nil {
+		return 0, 0, err
+	}
+
+	for _, t := range pending {
+		status, err := clear(t)
+		if err != nil {
+			return settled, rejected, fmt.Errorf("transfer %s: %w", t.Reference, err)
+		}
+		if status == StatusSettled {
+			settled++
+		} else {
+			rejected++
+		}
+	}
+	return settled, rejected, nil
+}
+
+// clear delivers one pending transfer to the beneficiary bank in a single database transaction
+func clear(t Transfer) (string, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Another cycle running at the same time may have handled the transfer already
+	var status string
+	if err := tx.Get(&status, "SELECT status FROM clearing_transfers WHERE id = ? FOR UPDATE", t.ID); err != nil {
+		return "", err
+	}
+	if status != StatusPending {
+		return status, nil
+	}
+
+	status, reason := StatusSettled, ""
+	var beneficiary user.Account
+	err = tx.Get(&beneficiary, "SELECT currency, status FROM accounts WHERE id = ? AND bank_code = ? AND locked_at IS NULL FOR UPDATE", t.DestAccountID, t.DestBank)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		status, reason = StatusRejected, ErrBeneficiaryNotFound.Error()
+	case err != nil:
+		return "", err
+	case user.CheckStatus(beneficiary.Status, user.OpReceive) != nil:
+		status, reason = StatusRejected, ErrBeneficiaryNotFound.Error()
+	case beneficiary.Currency != currency.Base:
+		status, reason = StatusRejected, ErrForeignCurrency.Error()
+	}
+
+	if status == StatusSettled {
+		err = credit(tx, t.DestAccountID, "interbank_in", t)
+	} else {
+		err = credit(tx, t.SourceAccountID, "interbank_refund", t)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec("UPDATE clearing_transfers SET status = ?, reason = ?, processed_at = CURRENT_TIMESTAMP WHERE id = ?",
+		status, reason, t.ID)
+	if err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// credit adds the amount of a clearing transfer to an account and records the transaction
+func credit(tx *sqlx.Tx, accountID int, transactionType string, t Transfer) error {
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", t.Amount, accountID); err != nil {
+		return err
+	}
+	// The reference column is unique, so the credit gets its own reference derived from the transfer
+	_, err := tx.Exec("INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, ?, ?, ?, "+businessday.SQL+")",
+		accountID, transactionType, t.Amount, t.Reference+"-"+transactionType)
+	return err
+}
+
+// List returns the most recent clearing transfers, optionally only those with the given status
+func List(status string, limit int) ([]Transfer, error) {
+	query := "SELECT * FROM clearing_transfers"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var transfers []Transfer
+	err := db.DB.Select(&transfers, query, args...)
+	return transfers, err
+}
+
+// Settle nets the settled transfers that are not part of a settlement yet into one
+// position per bank, so each bank pays or receives a single amount for the day
+func Settle() (int64, []Position, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var transfers []Transfer
+	err = tx.Select(&transfers, "SELECT * FROM clearing_transfers WHERE status = ? AND settlement_id IS NULL FOR UPDATE", StatusSettled)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(transfers) == 0 {
+		return 0, nil, ErrNothingToSettle
+	}
+
+	net := make(map[string]float64)
+	for _, t := range transfers {
+		net[t.SourceBank] -= t.Amount
+		net[t.DestBank] += t.Amount
+	}
+
+	result, err := tx.Exec("INSERT INTO settlements (transfers) VALUES (?)", len(transfers))
+	if err != nil {
+		return 0, nil, err
+	}
+	settlementID, err := result.LastInsertId()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for bank, amount := range net {
+		if _, err := tx.Exec("INSERT INTO settlement_positions (settlement_id, bank_code, net_amount) VALUES (?, ?, ?)",
+			settlementID, bank, amount); err != nil {
+			return 0, nil, err
+		}
+	}
+	ids := make([]int64, len(transfers))
+	for i, t := range transfers {
+		ids[i] = t.ID
+	}
+	query, args, err := sqlx.In("UPDATE clearing_transfers SET settlement_id = ? WHERE id IN (?)", settlementID, ids)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	var positions []Position
+	err = db.DB.Select(&positions, "SELECT bank_code, net_amount FROM settlement_positions WHERE settlement_id = ? ORDER BY bank_code", settlementID)
+	return settlementID, positions, err
+}
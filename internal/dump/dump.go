@@ -0,0 +1,392 @@
+package dump
+
+import (
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Formats a dump can be written in
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatJSONL, FormatCSV}
+
+// ErrUnknownFormat is returned for a format other than those in Formats
+var ErrUnknownFormat = errors.New("format ekspor tidak dikenal")
+
+// Names of the files of a dump directory, without the extension of the format
+const (
+	accountsFile     = "accounts"
+	transactionsFile = "transactions"
+)
+
+// Account is an account as it is exported, a row of the accounts table
+type Account struct {
+	ID             int     `db:"id" json:"id"`
+	Name           string  `db:"name" json:"name"`
+	PIN            *string `db:"pin" json:"pin,omitempty"` // bcrypt hash of the PIN, left out unless asked for
+	Balance        float64 `db:"balance" json:"balance"`
+	CreatedAt      string  `db:"created_at" json:"created_at"`
+	FailedAttempts int     `db:"failed_attempts" json:"failed_attempts"`
+	LockedAt       *string `db:"locked_at" json:"locked_at"`
+	BankCode       string  `db:"bank_code" json:"bank_code"`
+	Currency       string  `db:"currency" json:"currency"`
+	Status         string  `db:"status" json:"status"`
+	ClosedAt       *string `db:"closed_at" json:"closed_at"`
+}
+
+// Transaction is a transaction as it is exported, a row of the transactions table
+type Transaction struct {
+	ID        int     `db:"id" json:"id"`
+	AccountID int     `db:"account_id" json:"account_id"`
+	Type      string  `db:"type" json:"type"`
+	Amount    float64 `db:"amount" json:"amount"`
+	TargetID  *int    `db:"target_id" json:"target_id"`
+	Reference *string `db:"reference" json:"reference"`
+	CreatedAt string  `db:"created_at" json:"created_at"`
+	// BusinessDate is the business date the transaction was booked on, nil for transactions from
+	// before business dates were kept
+	BusinessDate *string `db:"business_date" json:"business_date"`
+}
+
+// Columns of the CSV files, in order
+var (
+	accountColumns     = []string{"id", "name", "pin", "balance", "created_at", "failed_attempts", "locked_at", "bank_code", "currency", "status", "closed_at"}
+	transactionColumns = []string{"id", "account_id", "type", "amount", "target_id", "reference", "created_at", "business_date"}
+)
+
+// Counts are the numbers of rows written to or read from a dump
+type Counts struct {
+	Accounts     int
+	Transactions int
+}
+
+// Export writes every account and transaction into the directory, as accounts.<format> and
+// transactions.<format>. The PINs are left out unless withPINHashes is set, and then only their hashes are written
+func Export(dir, format string, withPINHashes bool) (Counts, error) {
+	if format != FormatJSONL && format != FormatCSV {
+		return Counts{}, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	var accounts []Account
+	if err := db.DB.Select(&accounts, "SELECT * FROM accounts ORDER BY id"); err != nil {
+		return Counts{}, err
+	}
+	var transactions []Transaction
+	err := db.DB.Select(&transactions, `SELECT id, account_id, type, amount, target_id, reference, created_at, business_date
+		FROM transactions WHERE account_id IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return Counts{}, err
+	}
+	for i, a := range accounts {
+		switch {
+		case !withPINHashes:
+			accounts[i].PIN = nil
+		case a.PIN != nil && !user.PINHashed(*a.PIN):
+			// A PIN stored before PINs were hashed is hashed for the export, it never leaves the database as it is
+			hash, err := user.HashPIN(*a.PIN)
+			if err != nil {
+				return Counts{}, err
+			}
+			accounts[i].PIN = &hash
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Counts{}, err
+	}
+	err = writeFile(filepath.Join(dir, accountsFile+"."+format), func(w io.Writer) error {
+		if format == FormatCSV {
+			return writeAccountsCSV(w, accounts, withPINHashes)
+		}
+		return writeJSONLines(w, len(accounts), func(i int) interface{} { return accounts[i] })
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+	err = writeFile(filepath.Join(dir, transactionsFile+"."+format), func(w io.Writer) error {
+		if format == FormatCSV {
+			return writeTransactionsCSV(w, transactions)
+		}
+		return writeJSONLines(w, len(transactions), func(i int) interface{} { return transactions[i] })
+	})
+	return Counts{Accounts: len(accounts), Transactions: len(transactions)}, err
+}
+
+// writeFile writes a file under a temporary name first, so a failed export leaves no partial file
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// writeJSONLines writes n rows as JSON lines, one row per line
+func writeJSONLines(w io.Writer, n int, row func(i int) interface{}) error {
+	encoder := json.NewEncoder(w)
+	for i := 0; i < n; i++ {
+		if err := encoder.Encode(row(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAccountsCSV writes the accounts as CSV, with the pin column only when the PIN hashes are exported
+func writeAccountsCSV(w io.Writer, accounts []Account, withPINHashes bool) error {
+	columns := accountColumns
+	if !withPINHashes {
+		columns = append([]string{columns[0], columns[1]}, columns[3:]...)
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, a := range accounts {
+		row := []string{strconv.Itoa(a.ID), a.Name}
+		if withPINHashes {
+			row = append(row, optional(a.PIN))
+		}
+		row = append(row, plain(a.Balance), a.CreatedAt, strconv.Itoa(a.FailedAttempts), optional(a.LockedAt),
+			a.BankCode, a.Currency, a.Status, optional(a.ClosedAt))
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTransactionsCSV writes the transactions as CSV
+func writeTransactionsCSV(w io.Writer, transactions []Transaction) error {
+	cw := csv.NewWriter(w)
+	cw.Write(transactionColumns)
+	for _, t := range transactions {
+		target := ""
+		if t.TargetID != nil {
+			target = strconv.Itoa(*t.TargetID)
+		}
+		cw.Write([]string{strconv.Itoa(t.ID), strconv.Itoa(t.AccountID), t.Type, plain(t.Amount), target, optional(t.Reference), t.CreatedAt, optional(t.BusinessDate)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// optional writes a nullable column, empty for NULL
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// plain formats an amount for machines: dot decimal separator, no grouping
+func plain(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Read loads the accounts and transactions of a dump directory written by Export
+func Read(dir, format string) ([]Account, []Transaction, error) {
+	if format != FormatJSONL && format != FormatCSV {
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	var accounts []Account
+	var transactions []Transaction
+	err := readFile(filepath.Join(dir, accountsFile+"."+format), func(r io.Reader) (err error) {
+		if format == FormatCSV {
+			accounts, err = readAccountsCSV(r)
+			return err
+		}
+		return readJSONLines(r, func(d *json.Decoder) error {
+			var a Account
+			if err := d.Decode(&a); err != nil {
+				return err
+			}
+			accounts = append(accounts, a)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = readFile(filepath.Join(dir, transactionsFile+"."+format), func(r io.Reader) (err error) {
+		if format == FormatCSV {
+			transactions, err = readTransactionsCSV(r)
+			return err
+		}
+		return readJSONLines(r, func(d *json.Decoder) error {
+			var t Transaction
+			if err := d.Decode(&t); err != nil {
+				return err
+			}
+			transactions = append(transactions, t)
+			return nil
+		})
+	})
+	return accounts, transactions, err
+}
+
+// readFile opens a file of the dump and names it in the errors of reading it
+func readFile(path string, read func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readJSONLines reads JSON lines, calling decode to decode and keep each row
+func readJSONLines(r io.Reader, decode func(d *json.Decoder) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	for line := 1; decoder.More(); line++ {
+		if err := decode(decoder); err != nil {
+			return fmt.Errorf("baris %d: %w", line, err)
+		}
+	}
+	return nil
+}
+
+// csvRows reads a CSV file with a header row and calls parse for every other row with a lookup of
+// its columns by name; columns missing from the header read as empty
+func csvRows(r io.Reader, required []string, parse func(column func(name string) string) error) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range required {
+		if _, ok := index[name]; !ok {
+			return fmt.Errorf("kolom %q tidak ada", name)
+		}
+	}
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		column := func(name string) string {
+			if i, ok := index[name]; ok {
+				return row[i]
+			}
+			return ""
+		}
+		if err := parse(column); err != nil {
+			return fmt.Errorf("baris %d: %w", line, err)
+		}
+	}
+}
+
+// readAccountsCSV parses the accounts written by writeAccountsCSV
+func readAccountsCSV(r io.Reader) ([]Account, error) {
+	var accounts []Account
+	err := csvRows(r, []string{"id", "name", "balance"}, func(column func(string) string) error {
+		a := Account{Name: column("name"), CreatedAt: column("created_at"), BankCode: column("bank_code"),
+			Currency: column("currency"), Status: column("status"),
+			PIN: nullable(column("pin")), LockedAt: nullable(column("locked_at")), ClosedAt: nullable(column("closed_at"))}
+		var err error
+		if a.ID, err = parseInt("id", column("id")); err != nil {
+			return err
+		}
+		if a.Balance, err = parseAmount("balance", column("balance")); err != nil {
+			return err
+		}
+		if value := column("failed_attempts"); value != "" {
+			if a.FailedAttempts, err = parseInt("failed_attempts", value); err != nil {
+				return err
+			}
+		}
+		accounts = append(accounts, a)
+		return nil
+	})
+	return accounts, err
+}
+
+// readTransactionsCSV parses the transactions written by writeTransactionsCSV
+func readTransactionsCSV(r io.Reader) ([]Transaction, error) {
+	var transactions []Transaction
+	// Dumps from before business dates were kept have no business_date column
+	err := csvRows(r, transactionColumns[:len(transactionColumns)-1], func(column func(string) string) error {
+		t := Transaction{Type: column("type"), Reference: nullable(column("reference")), CreatedAt: column("created_at"),
+			BusinessDate: nullable(column("business_date"))}
+		var err error
+		if t.ID, err = parseInt("id", column("id")); err != nil {
+			return err
+		}
+		if t.AccountID, err = parseInt("account_id", column("account_id")); err != nil {
+			return err
+		}
+		if t.Amount, err = parseAmount("amount", column("amount")); err != nil {
+			return err
+		}
+		if value := column("target_id"); value != "" {
+			target, err := parseInt("target_id", value)
+			if err != nil {
+				return err
+			}
+			t.TargetID = &target
+		}
+		transactions = append(transactions, t)
+		return nil
+	})
+	return transactions, err
+}
+
+// nullable reads an empty CSV column as NULL
+func nullable(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// parseInt parses an integer column
+func parseInt(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("kolom %s %q bukan bilangan bulat", name, value)
+	}
+	return n, nil
+}
+
+// parseAmount parses an amount column
+func parseAmount(name, value string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("kolom %s %q bukan jumlah uang", name, value)
+	}
+	return amount, nil
+}
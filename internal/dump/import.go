@@ -0,0 +1,451 @@
+package dump
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrInvalidDump is wrapped by the errors of a dump that fails validation; nothing of it is imported
+var ErrInvalidDump = errors.New("data impor tidak valid")
+
+// Lengths of the text columns of the accounts and transactions tables
+const (
+	maxNameLength      = 100
+	maxPINLength       = 60
+	maxReferenceLength = 64
+)
+
+// Options control how a dump is imported
+type Options struct {
+	RemapIDs     bool // give the accounts and transactions new IDs instead of those of the dump
+	KeepBalances bool // keep the balances of the dump instead of rebuilding them from the transactions
+	DryRun       bool // validate and report without keeping anything
+}
+
+// Mismatch is an account whose balance differs from the one rebuilt from its transactions
+type Mismatch struct {
+	SourceID int // ID of the account in the dump, 0 for an account that is not in it
+	ID       int // ID of the account in the database
+	Name     string
+	Exported float64 // balance in the dump, or the balance stored in the database for an existing account
+	Rebuilt  float64
+	Existing bool // the account was in the database before the import, its balance is left as it is
+}
+
+// Report tells what an import did
+type Report struct {
+	Accounts              int // accounts added
+	Transactions          int // transactions added
+	DuplicateAccounts     int // accounts already in the database or repeated in the dump
+	DuplicateTransactions int // transactions already in the database or repeated in the dump
+	WithoutPIN            int // accounts added without a PIN, which need a PIN reset before use
+	IDs                   map[int]int
+	Mismatches            []Mismatch
+}
+
+// Import validates the accounts and transactions of a dump and adds those not in the database yet,
+// all in one database transaction. With the IDs of the dump preserved, a row is a duplicate when its ID
+// is already in the database; with remapped IDs, an account is a duplicate of the account with the same
+// name and a transaction of the one with the same account, type, amount, counterparty, reference and time.
+// The balances of the accounts the import added are then rebuilt from their transactions and compared with
+// those in the dump. Accounts that were in the database already keep their balance; when it no longer
+// matches their transactions, for instance because the dump added some, they are reported as mismatches
+func Import(accounts []Account, transactions []Transaction, opts Options) (*Report, error) {
+	accounts, transactions, duplicates, err := validate(accounts, transactions, opts)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{IDs: make(map[int]int), DuplicateAccounts: duplicates.Accounts, DuplicateTransactions: duplicates.Transactions}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inserted := make(map[int]bool)
+	for _, a := range accounts {
+		added, err := importAccount(tx, a, opts, report)
+		if err != nil {
+			return nil, fmt.Errorf("akun %d: %w", a.ID, err)
+		}
+		inserted[a.ID] = added
+	}
+	// References to accounts that are not in the dump keep their ID; validate made sure they exist
+	resolve := func(id int) int {
+		if mapped, ok := report.IDs[id]; ok {
+			return mapped
+		}
+		return id
+	}
+	// Existing accounts the dump names or adds transactions to, by their ID in the database and in the dump
+	existing := make(map[int]int)
+	for _, a := range accounts {
+		if !inserted[a.ID] {
+			existing[report.IDs[a.ID]] = a.ID
+		}
+	}
+	for _, t := range transactions {
+		_, inDump := report.IDs[t.AccountID]
+		t.AccountID = resolve(t.AccountID)
+		if t.TargetID != nil {
+			target := resolve(*t.TargetID)
+			t.TargetID = &target
+		}
+		added, err := importTransaction(tx, t, opts, report)
+		if err != nil {
+			return nil, fmt.Errorf("transaksi %d: %w", t.ID, err)
+		}
+		if added && !inDump {
+			existing[t.AccountID] = 0
+		}
+	}
+
+	for _, a := range accounts {
+		if !inserted[a.ID] {
+			continue
+		}
+		id := report.IDs[a.ID]
+		rebuilt, err := rebuildBalance(tx, id, !opts.KeepBalances)
+		if err != nil {
+			return nil, fmt.Errorf("akun %d: %w", a.ID, err)
+		}
+		if math.Abs(rebuilt-a.Balance) >= 0.005 {
+			report.Mismatches = append(report.Mismatches, Mismatch{SourceID: a.ID, ID: id, Name: a.Name, Exported: a.Balance, Rebuilt: rebuilt})
+		}
+	}
+	// The balance of an account the import did not add belongs to the database, so it is only compared
+	ids := make([]int, 0, len(existing))
+	for id := range existing {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		var stored struct {
+			Name    string  `db:"name"`
+			Balance float64 `db:"balance"`
+		}
+		if err := tx.Get(&stored, "SELECT name, COALESCE(balance, 0) AS balance FROM accounts WHERE id = ?", id); err != nil {
+			return nil, fmt.Errorf("akun %d: %w", id, err)
+		}
+		rebuilt, err := rebuildBalance(tx, id, false)
+		if err != nil {
+			return nil, fmt.Errorf("akun %d: %w", id, err)
+		}
+		if math.Abs(rebuilt-stored.Balance) >= 0.005 {
+			report.Mismatches = append(report.Mismatches, Mismatch{SourceID: existing[id], ID: id, Name: stored.Name,
+				Exported: stored.Balance, Rebuilt: rebuilt, Existing: true})
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// validate checks every row of the dump and drops the rows repeated in it
+// It returns all the problems found at once, so a dump can be fixed in one go
+func validate(accounts []Account, transactions []Transaction, opts Options) ([]Account, []Transaction, Counts, error) {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	var duplicates Counts
+
+	banks := make(map[string]bool)
+	var codes []string
+	if err := db.DB.Select(&codes, "SELECT code FROM banks"); err != nil {
+		return nil, nil, Counts{}, err
+	}
+	for _, code := range codes {
+		banks[code] = true
+	}
+
+	byID := make(map[int]Account)
+	byName := make(map[string]int)
+	var keptAccounts []Account
+	for _, a := range accounts {
+		if a.BankCode == "" {
+			a.BankCode = user.BankCode
+		}
+		if a.Currency == "" {
+			a.Currency = currency.Base
+		}
+		if a.Status == "" {
+			a.Status = user.StatusActive
+		}
+		if previous, ok := byID[a.ID]; ok {
+			if !sameAccount(previous, a) {
+				problem("akun %d muncul dua kali dengan isi berbeda", a.ID)
+			}
+			duplicates.Accounts++
+			continue
+		}
+
+		switch {
+		case a.ID <= 0:
+			problem("akun dengan ID %d: ID harus positif", a.ID)
+		case strings.TrimSpace(a.Name) == "" || len(a.Name) > maxNameLength:
+			problem("akun %d: nama harus 1 sampai %d karakter", a.ID, maxNameLength)
+		case byName[a.Name] != 0:
+			problem("akun %d: nama %q sudah dipakai akun %d", a.ID, a.Name, byName[a.Name])
+		}
+		if a.PIN != nil && len(*a.PIN) > maxPINLength {
+			problem("akun %d: PIN lebih dari %d karakter", a.ID, maxPINLength)
+		}
+		if _, err := currency.Lookup(a.Currency); err != nil {
+			problem("akun %d: %v", a.ID, err)
+		}
+		if !banks[a.BankCode] {
+			problem("akun %d: kode bank %q tidak dikenal", a.ID, a.BankCode)
+		}
+		if !user.ValidStatus(a.Status) {
+			problem("akun %d: status %q tidak dikenal", a.ID, a.Status)
+		}
+		if math.IsNaN(a.Balance) || math.IsInf(a.Balance, 0) {
+			problem("akun %d: saldo tidak valid", a.ID)
+		}
+		for _, t := range []*string{&a.CreatedAt, a.LockedAt, a.ClosedAt} {
+			if t != nil && *t != "" && !validTime(*t) {
+				problem("akun %d: waktu %q tidak valid, gunakan %s", a.ID, *t, time.DateTime)
+			}
+		}
+		byID[a.ID] = a
+		byName[a.Name] = a.ID
+		keptAccounts = append(keptAccounts, a)
+	}
+
+	// An account that is not in the dump can only be referred to by its ID when the IDs are preserved
+	exists := func(id int) bool {
+		if _, ok := byID[id]; ok {
+			return true
+		}
+		if opts.RemapIDs {
+			return false
+		}
+		var count int
+		if err := db.DB.Get(&count, "SELECT COUNT(*) FROM accounts WHERE id = ?", id); err != nil {
+			problem("akun %d: %v", id, err)
+			return true
+		}
+		return count > 0
+	}
+	types := make(map[string]bool)
+	for _, t := range transaction.Types {
+		types[t] = true
+	}
+	seen := make(map[int]Transaction)
+	var keptTransactions []Transaction
+	for _, t := range transactions {
+		if previous, ok := seen[t.ID]; ok {
+			if !sameTransaction(previous, t) {
+				problem("transaksi %d muncul dua kali dengan isi berbeda", t.ID)
+			}
+			duplicates.Transactions++
+			continue
+		}
+
+		if t.ID <= 0 {
+			problem("transaksi dengan ID %d: ID harus positif", t.ID)
+		}
+		if !types[t.Type] {
+			problem("transaksi %d: jenis %q tidak dikenal", t.ID, t.Type)
+		}
+		if !(t.Amount > 0) || math.IsInf(t.Amount, 0) {
+			problem("transaksi %d: jumlah harus lebih dari 0", t.ID)
+		}
+		if !exists(t.AccountID) {
+			problem("transaksi %d: akun %d tidak ada", t.ID, t.AccountID)
+		}
+		if t.TargetID != nil && !exists(*t.TargetID) {
+			problem("transaksi %d: akun tujuan %d tidak ada", t.ID, *t.TargetID)
+		}
+		if t.Reference != nil && len(*t.Reference) > maxReferenceLength {
+			problem("transaksi %d: referensi lebih dari %d karakter", t.ID, maxReferenceLength)
+		}
+		if t.CreatedAt != "" && !validTime(t.CreatedAt) {
+			problem("transaksi %d: waktu %q tidak valid, gunakan %s", t.ID, t.CreatedAt, time.DateTime)
+		}
+		if t.BusinessDate != nil {
+			if _, err := time.Parse(businessday.Layout, *t.BusinessDate); err != nil {
+				problem("transaksi %d: tanggal bisnis %q tidak valid, gunakan %s", t.ID, *t.BusinessDate, businessday.Layout)
+			}
+		}
+		seen[t.ID] = t
+		keptTransactions = append(keptTransactions, t)
+	}
+
+	if len(problems) > 0 {
+		return nil, nil, Counts{}, fmt.Errorf("%w:\n%s", ErrInvalidDump, strings.Join(problems, "\n"))
+	}
+	return keptAccounts, keptTransactions, duplicates, nil
+}
+
+// validTime reports whether a timestamp is written as the database writes it
+func validTime(value string) bool {
+	_, err := time.Parse(time.DateTime, value)
+	return err == nil
+}
+
+// sameAccount reports whether two rows of the dump describe the same account
+func sameAccount(a, b Account) bool {
+	return a.Name == b.Name && a.Balance == b.Balance && a.Currency == b.Currency && a.Status == b.Status && a.CreatedAt == b.CreatedAt
+}
+
+// sameTransaction reports whether two rows of the dump describe the same transaction
+func sameTransaction(a, b Transaction) bool {
+	return a.AccountID == b.AccountID && a.Type == b.Type && a.Amount == b.Amount && a.CreatedAt == b.CreatedAt &&
+		equalInt(a.TargetID, b.TargetID) && equalString(a.Reference, b.Reference)
+}
+
+// equalInt compares two nullable integers
+func equalInt(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// equalString compares two nullable strings
+func equalString(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// orNull stores an empty timestamp as NULL, so the database fills in its default
+func orNull(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// importAccount adds the account unless the database has it already, records its ID in the report
+// and reports whether it added it
+func importAccount(tx *sqlx.Tx, a Account, opts Options, report *Report) (bool, error) {
+	var existing struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	var err error
+	if opts.RemapIDs {
+		err = tx.Get(&existing, "SELECT id, name FROM accounts WHERE name = ?", a.Name)
+	} else {
+		err = tx.Get(&existing, "SELECT id, name FROM accounts WHERE id = ? OR name = ? ORDER BY id = ? DESC LIMIT 1", a.ID, a.Name, a.ID)
+	}
+	if err == nil {
+		if existing.ID != a.ID && !opts.RemapIDs {
+			return false, fmt.Errorf("nama %q sudah dipakai akun %d", a.Name, existing.ID)
+		}
+		if existing.Name != a.Name {
+			return false, fmt.Errorf("ID sudah dipakai akun %q", existing.Name)
+		}
+		report.IDs[a.ID] = existing.ID
+		report.DuplicateAccounts++
+		return false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	var id interface{}
+	if !opts.RemapIDs {
+		id = a.ID
+	}
+	// Dumps from before PINs were hashed carry the PINs themselves, which are hashed on the way in
+	var pin interface{}
+	if a.PIN != nil {
+		hash := *a.PIN
+		if !user.PINHashed(hash) {
+			if hash, err = user.HashPIN(hash); err != nil {
+				return false, err
+			}
+		}
+		pin = hash
+	}
+	result, err := tx.Exec(`INSERT INTO accounts (id, name, pin, balance, created_at, failed_attempts, locked_at, bank_code, currency, status, closed_at)
+		VALUES (?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), ?, ?, ?, ?, ?, ?)`,
+		id, a.Name, pin, a.Balance, orNull(a.CreatedAt), a.FailedAttempts, a.LockedAt, a.BankCode, a.Currency, a.Status, a.ClosedAt)
+	if err != nil {
+		return false, err
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	report.IDs[a.ID] = int(newID)
+	report.Accounts++
+
+	// The PIN of the dump starts the PIN history, as it does for a registered account
+	if a.PIN == nil {
+		report.WithoutPIN++
+		return true, nil
+	}
+	_, err = tx.Exec("INSERT INTO pin_history (account_id, pin) VALUES (?, ?)", newID, pin)
+	return true, err
+}
+
+// importTransaction adds the transaction unless the database has it already, and reports whether it did
+// Its account and counterparty are already those of the database
+func importTransaction(tx *sqlx.Tx, t Transaction, opts Options, report *Report) (bool, error) {
+	var count int
+	var err error
+	if opts.RemapIDs {
+		err = tx.Get(&count, `SELECT COUNT(*) FROM transactions WHERE account_id = ? AND type = ? AND amount = ?
+			AND target_id <=> ? AND reference <=> ? AND created_at = ?`,
+			t.AccountID, t.Type, t.Amount, t.TargetID, t.Reference, t.CreatedAt)
+	} else {
+		err = tx.Get(&count, "SELECT COUNT(*) FROM transactions WHERE id = ?", t.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		report.DuplicateTransactions++
+		return false, nil
+	}
+
+	var id interface{}
+	if !opts.RemapIDs {
+		id = t.ID
+	}
+	// A transaction without a business date gets the date it was made on, or today's business date
+	_, err = tx.Exec(`INSERT INTO transactions (id, account_id, type, amount, target_id, reference, created_at, business_date)
+		VALUES (?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), COALESCE(?, DATE(?), `+businessday.SQL+`))`,
+		id, t.AccountID, t.Type, t.Amount, t.TargetID, t.Reference, orNull(t.CreatedAt), t.BusinessDate, orNull(t.CreatedAt))
+	if err != nil {
+		return false, err
+	}
+	report.Transactions++
+	return true, nil
+}
+
+// rebuildBalance sums the transactions of the account and stores the sum as its balance when asked to
+func rebuildBalance(tx *sqlx.Tx, accountID int, store bool) (float64, error) {
+	var rows []struct {
+		Type   string  `db:"type"`
+		Amount float64 `db:"amount"`
+	}
+	if err := tx.Select(&rows, "SELECT type, amount FROM transactions WHERE account_id = ?", accountID); err != nil {
+		return 0, err
+	}
+	var balance float64
+	for _, r := range rows {
+		balance += transaction.SignedAmount(r.Type, r.Amount)
+	}
+	balance = math.Round(balance*100) / 100
+	if !store {
+		return balance, nil
+	}
+	_, err := tx.Exec("UPDATE accounts SET balance = ? WHERE id = ?", balance, accountID)
+	return balance, err
+}
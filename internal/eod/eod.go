@@ -0,0 +1,258 @@
+package eod
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/reconcile"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRunning is returned when another end-of-day run holds the lock
+var ErrRunning = errors.New("proses akhir hari lain sedang berjalan")
+
+// Actor is the name the end-of-day run uses in the audit log
+const Actor = "eod"
+
+// lockName is the MySQL user lock that keeps two end-of-day runs apart
+const lockName = "atm_eod"
+
+// Steps of the end-of-day run
+const (
+	StepCloseDay       = "close_day"
+	StepInterest       = "interest"
+	StepFees           = "fees"
+	StepDormancy       = "dormancy"
+	StepStandingOrders = "standing_orders"
+	StepReconcile      = "reconcile"
+	StepReports        = "reports"
+)
+
+// Order lists the steps in the order they run
+var Order = []string{StepCloseDay, StepInterest, StepFees, StepDormancy, StepStandingOrders, StepReconcile, StepReports}
+
+// Statuses of a step, as in the eod_steps table
+const (
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Config holds the settings of the end-of-day run
+type Config struct {
+	InterestRate float64            // yearly interest in percent, 0 accrues no interest
+	MonthlyFees  map[string]float64 // monthly account fee per currency; currencies without one are not charged
+	DormantAfter int                // months without activity before an account becomes dormant, 0 skips the check
+	ReportDir    string             // directory of the daily reports, empty writes none
+	StatementDir string             // directory of the monthly statements, empty writes none
+	Source       string             // where the run was started, for the audit log
+}
+
+// Step is the checkpoint of a step of the end-of-day run of a business date
+type Step struct {
+	Date       string  `db:"business_date"`
+	Step       string  `db:"step"`
+	Status     string  `db:"status"`
+	Detail     string  `db:"detail"`
+	StartedAt  string  `db:"started_at"`
+	FinishedAt *string `db:"finished_at"`
+}
+
+// Result is the outcome of an end-of-day run
+type Result struct {
+	Date    string // business date closed by the run
+	Next    string // business date open after it
+	Steps   []Step // steps run this time; those done by an earlier run are left out
+	Resumed bool   // an earlier run of the business date stopped partway
+}
+
+// stepFunc runs a step for the business date being closed and describes what it did
+type stepFunc func(cfg Config, day *businessday.Day) (string, error)
+
+// runners maps the steps to their functions
+var runners = map[string]stepFunc{
+	StepCloseDay:       closeDay,
+	StepInterest:       accrueInterest,
+	StepFees:           chargeFees,
+	StepDormancy:       markDormant,
+	StepStandingOrders: runStandingOrders,
+	StepReconcile:      runReconciliation,
+	StepReports:        writeReports,
+}
+
+// Run closes the open business date, or resumes the business date an earlier run left unfinished,
+// and runs the steps in Order. Each step is checkpointed in the eod_steps table: steps already done
+// are skipped and the run stops at the first step that fails, so running it again carries on there
+func Run(cfg Config) (*Result, error) {
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	day, err := businessday.Closing()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Resumed: day != nil}
+	if day == nil {
+		if day, err = businessday.Current(); err != nil {
+			return nil, err
+		}
+	}
+	result.Date = day.Date
+
+	done, err := doneSteps(day.Date)
+	if err != nil {
+		return nil, err
+	}
+	if len(done) > 0 {
+		result.Resumed = true
+	}
+	for _, name := range Order {
+		if done[name] {
+			continue
+		}
+		step, err := runStep(cfg, day, name)
+		result.Steps = append(result.Steps, step)
+		if err != nil {
+			return result, fmt.Errorf("langkah %s: %w", name, err)
+		}
+	}
+
+	if err := businessday.Finish(day.Date); err != nil {
+		return result, err
+	}
+	next, err := businessday.Current()
+	if err != nil {
+		return result, err
+	}
+	result.Next = next.Date
+	return result, nil
+}
+
+// lock takes the end-of-day lock on a connection of its own, as a MySQL user lock belongs to a connection
+func lock() (func(), error) {
+	conn, err := db.DB.Connx(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var locked sql.NullInt64
+	if err := conn.GetContext(context.Background(), &locked, "SELECT GET_LOCK(?, 0)", lockName); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if locked.Int64 != 1 {
+		conn.Close()
+		return nil, ErrRunning
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+		conn.Close()
+	}, nil
+}
+
+// doneSteps returns the steps of the business date that already finished
+func doneSteps(date string) (map[string]bool, error) {
+	var names []string
+	if err := db.DB.Select(&names, "SELECT step FROM eod_steps WHERE business_date = ? AND status = ?", date, StatusDone); err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool)
+	for _, name := range names {
+		done[name] = true
+	}
+	return done, nil
+}
+
+// runStep runs one step and records its outcome
+func runStep(cfg Config, day *businessday.Day, name string) (Step, error) {
+	_, err := db.DB.Exec(`INSERT INTO eod_steps (business_date, step, status) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), detail = '', started_at = CURRENT_TIMESTAMP, finished_at = NULL`,
+		day.Date, name, StatusRunning)
+	if err != nil {
+		return Step{Date: day.Date, Step: name, Status: StatusFailed, Detail: err.Error()}, err
+	}
+
+	detail, err := runners[name](cfg, day)
+	status := StatusDone
+	if err != nil {
+		status, detail = StatusFailed, err.Error()
+	}
+	if len(detail) > 255 {
+		detail = detail[:255]
+	}
+	if _, serr := db.DB.Exec("UPDATE eod_steps SET status = ?, detail = ?, finished_at = CURRENT_TIMESTAMP WHERE business_date = ? AND step = ?",
+		status, detail, day.Date, name); serr != nil && err == nil {
+		err = serr
+	}
+	return Step{Date: day.Date, Step: name, Status: status, Detail: detail}, err
+}
+
+// Steps returns the checkpoints of the business date in the order the steps run
+func Steps(date string) ([]Step, error) {
+	var steps []Step
+	err := db.DB.Select(&steps, "SELECT * FROM eod_steps WHERE business_date = ?", date)
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]Step, 0, len(steps))
+	for _, name := range Order {
+		for _, s := range steps {
+			if s.Step == name {
+				ordered = append(ordered, s)
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// closeDay stops booking transactions on the business date and opens the next one
+func closeDay(cfg Config, day *businessday.Day) (string, error) {
+	// A run that failed after closing the day resumes with the day already closing
+	if day.Status == businessday.StatusOpen {
+		next, err := businessday.Close(day.Date)
+		if err != nil {
+			return "", err
+		}
+		day.Status = businessday.StatusClosing
+		return "tanggal bisnis berikutnya " + next.Date, nil
+	}
+	return "tanggal bisnis sudah ditutup", nil
+}
+
+// markDormant makes the accounts without activity for too long dormant
+func markDormant(cfg Config, day *businessday.Day) (string, error) {
+	if cfg.DormantAfter == 0 {
+		return "pemeriksaan dormant tidak diaktifkan", nil
+	}
+	marked, err := user.MarkDormant(cfg.DormantAfter, Actor, cfg.Source)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d akun menjadi dormant", len(marked)), nil
+}
+
+// runStandingOrders carries out the standing orders due on the business date after the one being closed,
+// which is open for transactions by now
+func runStandingOrders(cfg Config, day *businessday.Day) (string, error) {
+	run, err := transaction.RunStandingOrders(day.Time().AddDate(0, 0, 1))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d dijalankan, %d menunggu persetujuan, %d gagal", run.Executed, run.Pending, run.Failed), nil
+}
+
+// runReconciliation compares the balances with the transactions; mismatches are reported, they do not stop the run
+func runReconciliation(cfg Config, day *businessday.Day) (string, error) {
+	result, err := reconcile.Run()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d akun diperiksa, %d tidak sesuai (%s)", result.Accounts, len(result.Mismatches), result.Duration.Round(time.Millisecond)), nil
+}
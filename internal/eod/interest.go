@@ -0,0 +1,220 @@
+package eod
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// errPosted is returned by post when the reference was already booked by an earlier run
+var errPosted = errors.New("sudah dibukukan")
+
+// daysPerYear is the day count of the daily interest rate
+const daysPerYear = 365
+
+// closingBalances returns the balance of every account that is not closed as it stood at the end of the
+// business date: the current balance without the transactions booked on later business dates
+func closingBalances(date string) (map[int]float64, error) {
+	tx, err := db.DB.BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var accounts []struct {
+		ID      int     `db:"id"`
+		Balance float64 `db:"balance"`
+	}
+	if err := tx.Select(&accounts, "SELECT id, COALESCE(balance, 0) AS balance FROM accounts WHERE status <> ?", user.StatusClosed); err != nil {
+		return nil, err
+	}
+	var later []struct {
+		AccountID int     `db:"account_id"`
+		Type      string  `db:"type"`
+		Amount    float64 `db:"amount"`
+	}
+	err = tx.Select(&later, `SELECT account_id, type, SUM(amount) AS amount FROM transactions
+		WHERE account_id IS NOT NULL AND business_date > ? GROUP BY account_id, type`, date)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]float64, len(accounts))
+	for _, a := range accounts {
+		balances[a.ID] = a.Balance
+	}
+	for _, t := range later {
+		if _, ok := balances[t.AccountID]; ok {
+			balances[t.AccountID] -= transaction.SignedAmount(t.Type, t.Amount)
+		}
+	}
+	return balances, tx.Commit()
+}
+
+// accrueInterest accrues a day of interest on the closing balance of every account, and at the end
+// of the month credits each account with the interest accrued over the month
+func accrueInterest(cfg Config, day *businessday.Day) (string, error) {
+	if cfg.InterestRate == 0 {
+		return "bunga tidak diaktifkan", nil
+	}
+	balances, err := closingBalances(day.Date)
+	if err != nil {
+		return "", err
+	}
+
+	accrued := 0
+	for id, balance := range balances {
+		balance = math.Round(balance*100) / 100
+		if balance <= 0 {
+			continue
+		}
+		// An accrual already made for the day is kept, so a resumed run does not accrue twice
+		_, err := db.DB.Exec("INSERT IGNORE INTO interest_accruals (account_id, business_date, balance, rate, amount) VALUES (?, ?, ?, ?, ?)",
+			id, day.Date, balance, cfg.InterestRate, balance*cfg.InterestRate/100/daysPerYear)
+		if err != nil {
+			return "", err
+		}
+		accrued++
+	}
+	detail := fmt.Sprintf("bunga %.2f%% setahun untuk %d akun", cfg.InterestRate, accrued)
+	if !day.MonthEnd() {
+		return detail, nil
+	}
+
+	posted, err := postInterest(day)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s, bunga bulan ini dikreditkan ke %d akun", detail, posted), nil
+}
+
+// postInterest credits every account with the interest it accrued up to the business date and that was not
+// credited yet. Less than a cent stays accrued until it adds up to one
+func postInterest(day *businessday.Day) (int, error) {
+	var totals []struct {
+		AccountID int     `db:"account_id"`
+		Amount    float64 `db:"amount"`
+	}
+	err := db.DB.Select(&totals, `SELECT account_id, SUM(amount) AS amount FROM interest_accruals
+		WHERE posted_at IS NULL AND business_date <= ? GROUP BY account_id ORDER BY account_id`, day.Date)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, t := range totals {
+		amount := math.Floor(t.Amount*100) / 100
+		if amount < 0.01 {
+			continue
+		}
+		reference := fmt.Sprintf("bunga %s #%d", day.Date[:7], t.AccountID)
+		if err := post(t.AccountID, "interest", amount, reference, day.Date, func(tx *sqlx.Tx) error {
+			_, err := tx.Exec("UPDATE interest_accruals SET posted_at = CURRENT_TIMESTAMP WHERE account_id = ? AND posted_at IS NULL AND business_date <= ?",
+				t.AccountID, day.Date)
+			return err
+		}); err != nil {
+			return posted, fmt.Errorf("akun %d: %w", t.AccountID, err)
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+// chargeFees charges the monthly account fee at the end of the month
+// Accounts without the fee available are left uncharged and listed in the detail of the step
+func chargeFees(cfg Config, day *businessday.Day) (string, error) {
+	if len(cfg.MonthlyFees) == 0 {
+		return "biaya bulanan tidak diaktifkan", nil
+	}
+	if !day.MonthEnd() {
+		return "bukan akhir bulan", nil
+	}
+
+	currencies := make([]string, 0, len(cfg.MonthlyFees))
+	for c := range cfg.MonthlyFees {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	charged, already := 0, 0
+	var short []string
+	for _, c := range currencies {
+		var ids []int
+		err := db.DB.Select(&ids, "SELECT id FROM accounts WHERE currency = ? AND status <> ? ORDER BY id", c, user.StatusClosed)
+		if err != nil {
+			return "", err
+		}
+		for _, id := range ids {
+			reference := fmt.Sprintf("biaya %s #%d", day.Date[:7], id)
+			err := post(id, "fee", cfg.MonthlyFees[c], reference, day.Date, nil)
+			switch {
+			case errors.Is(err, errPosted):
+				already++
+			case errors.Is(err, transaction.ErrInsufficientBalance):
+				short = append(short, fmt.Sprint(id))
+			case err != nil:
+				return "", fmt.Errorf("akun %d: %w", id, err)
+			default:
+				charged++
+			}
+		}
+	}
+
+	detail := fmt.Sprintf("%d akun dikenai biaya, %d sudah dikenai sebelumnya", charged, already)
+	if len(short) > 0 {
+		detail += fmt.Sprintf(", %d akun saldo tidak cukup: %s", len(short), strings.Join(short, ", "))
+	}
+	return detail, nil
+}
+
+// post books an interest credit or a fee debit on the business date and changes the balance with it,
+// together with whatever else has to be written in the same database transaction. The reference is
+// unique per account and month, so a resumed run does not book it twice. A debit needs the amount available
+func post(accountID int, kind string, amount float64, reference, date string, also func(tx *sqlx.Tx) error) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var available float64
+	if err := tx.Get(&available, "SELECT "+transaction.AvailableSQL+" FROM accounts WHERE id = ? FOR UPDATE", accountID); err != nil {
+		return err
+	}
+	var booked int
+	if err := tx.Get(&booked, "SELECT COUNT(*) FROM transactions WHERE reference = ?", reference); err != nil {
+		return err
+	}
+	if booked > 0 {
+		return errPosted
+	}
+	signed := transaction.SignedAmount(kind, amount)
+	if signed < 0 && available < amount {
+		return transaction.ErrInsufficientBalance
+	}
+
+	_, err = tx.Exec("INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, ?, ?, ?, ?)",
+		accountID, kind, amount, reference, date)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", signed, accountID); err != nil {
+		return err
+	}
+	if also != nil {
+		if err := also(tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
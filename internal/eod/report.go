@@ -0,0 +1,101 @@
+package eod
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/statement"
+	"atm-simulation/pkg/db"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Total is the number and the sum of the transactions of a type in a currency on a business date
+type Total struct {
+	Currency string  `db:"currency"`
+	Type     string  `db:"type"`
+	Count    int     `db:"count"`
+	Amount   float64 `db:"amount"`
+}
+
+// Totals sums the transactions booked on the business date per currency and type
+func Totals(date string) ([]Total, error) {
+	var totals []Total
+	err := db.DB.Select(&totals, `SELECT a.currency, t.type, COUNT(*) AS count, SUM(t.amount) AS amount
+		FROM transactions t JOIN accounts a ON a.id = t.account_id
+		WHERE t.business_date = ? GROUP BY a.currency, t.type ORDER BY a.currency, t.type`, date)
+	return totals, err
+}
+
+// ReportName returns the file name of the daily report of the business date
+func ReportName(date string) string {
+	return "eod-" + date + ".csv"
+}
+
+// writeReports writes the daily report of the business date, and at the end of the month the statements of the month
+func writeReports(cfg Config, day *businessday.Day) (string, error) {
+	if cfg.ReportDir == "" && cfg.StatementDir == "" {
+		return "laporan tidak diaktifkan", nil
+	}
+	detail := ""
+	if cfg.ReportDir != "" {
+		path, err := writeDailyReport(cfg.ReportDir, day.Date)
+		if err != nil {
+			return "", err
+		}
+		detail = path
+	}
+	if cfg.StatementDir == "" || !day.MonthEnd() {
+		return detail, nil
+	}
+
+	t := day.Time()
+	from, to := statement.Month(t.Year(), t.Month())
+	dir := filepath.Join(cfg.StatementDir, from.Format("2006-01"))
+	// Statements written by an earlier attempt are kept, so a resumed run only writes the missing ones
+	written, err := statement.Batch(dir, from, to, statement.Formats, i18n.New(i18n.Default), false)
+	if err != nil {
+		return "", err
+	}
+	if detail != "" {
+		detail += ", "
+	}
+	return detail + fmt.Sprintf("%d berkas rekening koran di %s", len(written), dir), nil
+}
+
+// writeDailyReport writes the totals of the business date as CSV into the directory, replacing the file at once
+func writeDailyReport(dir, date string) (string, error) {
+	totals, err := Totals(date)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ReportName(date))
+	f, err := os.CreateTemp(dir, ".eod-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"business_date", "currency", "type", "count", "amount"})
+	for _, t := range totals {
+		w.Write([]string{date, t.Currency, t.Type, strconv.Itoa(t.Count), strconv.FormatFloat(t.Amount, 'f', 2, 64)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(f.Name(), path)
+}
@@ -0,0 +1,343 @@
+package host
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/iso8583"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrHostUnavailable is returned when the host cannot be reached or does not answer in time
+var ErrHostUnavailable = i18n.NewError("error.host_unavailable", "host tidak dapat dihubungi")
+
+// Client is a terminal connection to a remote ISO 8583 host
+// When several host addresses are given the client fails over to the next one
+// as soon as the active host cannot be reached
+type Client struct {
+	Addrs      []string
+	TerminalID string
+	Spec       iso8583.Spec
+	Timeout    time.Duration
+
+	mu        sync.Mutex
+	conn      net.Conn
+	active    int
+	failovers int
+	stan      int
+
+	// Reversal advices not yet approved by the host, oldest first, see OpenReversals
+	reversals    []*iso8583.Message
+	reversalFile string
+}
+
+// NewClient creates a client that connects to the first host lazily on the first request
+func NewClient(addrs []string, terminalID string, spec iso8583.Spec) *Client {
+	return &Client{Addrs: addrs, TerminalID: terminalID, Spec: spec, Timeout: 10 * time.Second}
+}
+
+// Addr returns the address of the host currently in use
+func (c *Client) Addr() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Addrs[c.active]
+}
+
+// Failovers returns how many times the client switched to another host
+func (c *Client) Failovers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failovers
+}
+
+// Close closes the connection to the host
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// nextSTAN returns the next system trace audit number, wrapping after 999999
+func (c *Client) nextSTAN() string {
+	c.stan = c.stan%999999 + 1
+	return fmt.Sprintf("%06d", c.stan)
+}
+
+// exchange sends a request and waits for its response, reconnecting when needed
+// The caller must hold the lock
+func (c *Client) exchange(req *iso8583.Message) (*iso8583.Message, error) {
+	data, err := req.Pack(c.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.conn == nil {
+		if err := c.dial(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(c.Timeout))
+	if err := iso8583.WriteFrame(c.conn, data); err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return nil, fmt.Errorf("%w: %v", ErrHostUnavailable, err)
+	}
+	respData, err := iso8583.ReadFrame(c.conn)
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return nil, fmt.Errorf("%w: %v", ErrHostUnavailable, err)
+	}
+	return iso8583.Unpack(c.Spec, respData)
+}
+
+// dial connects to the active host, trying the other hosts in turn when it is down
+// The caller must hold the lock
+func (c *Client) dial() error {
+	var lastErr error
+	for i := range c.Addrs {
+		index := (c.active + i) % len(c.Addrs)
+		conn, err := net.DialTimeout("tcp", c.Addrs[index], c.Timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if index != c.active {
+			log.Printf("terminal %s: failover dari host %s ke %s", c.TerminalID, c.Addrs[c.active], c.Addrs[index])
+			c.active = index
+			c.failovers++
+		}
+		c.conn = conn
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrHostUnavailable, lastErr)
+}
+
+// newRequest fills the fields common to every request sent by the terminal
+func (c *Client) newRequest(mti string) *iso8583.Message {
+	now := time.Now()
+	req := iso8583.NewMessage(mti)
+	req.Set(7, transmissionTime(now))
+	req.Set(11, c.nextSTAN())
+	req.Set(12, now.Format("150405"))
+	req.Set(13, now.Format("0102"))
+	req.Set(41, c.TerminalID)
+	return req
+}
+
+// Card holds what the terminal sends to authenticate a customer: the account number and the PIN block
+// made from the PIN when it was entered. The PIN itself is not kept
+type Card struct {
+	AccountID int
+	PINBlock  string
+	Currency  string // currency of the account, sent in field 49 once known from the login
+}
+
+// NewCard builds the PIN block of the PIN entered for the account
+func NewCard(accountID int, pin string) (Card, error) {
+	pinBlock, err := iso8583.PINBlock(pin, strconv.Itoa(accountID))
+	if err != nil {
+		return Card{}, err
+	}
+	return Card{AccountID: accountID, PINBlock: pinBlock}, nil
+}
+
+// cardRequest builds a request authenticated with the card
+func (c *Client) cardRequest(mti, proc string, card Card, amount float64) *iso8583.Message {
+	req := c.newRequest(mti)
+	req.Set(2, strconv.Itoa(card.AccountID))
+	req.Set(3, proc+"0000")
+	req.Set(4, FormatAmount(amount))
+	req.Set(37, req.Get(7)[4:]+req.Get(11))
+	if card.Currency != "" {
+		req.Set(49, numericCurrency(card.Currency))
+	}
+	req.Set(52, card.PINBlock)
+	return req
+}
+
+// inquire sends a 0100 request and returns the approved response
+// The caller must hold the lock
+func (c *Client) inquire(req *iso8583.Message) (*iso8583.Message, error) {
+	c.sendReversals()
+	resp, err := c.exchange(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(resp.Get(39)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// financial sends a 0200 request and reverses it when the response does not arrive
+// Earlier reversals go first, so the host posts the request against the right balance
+// The caller must hold the lock
+func (c *Client) financial(req *iso8583.Message) (*iso8583.Message, error) {
+	c.sendReversals()
+	resp, err := c.exchange(req)
+	if err != nil && errors.Is(err, ErrHostUnavailable) && req.Get(3)[:2] != ProcBalanceInquiry {
+		// The host may have posted the request, so it is reversed, on the next host right away
+		// and otherwise until a host approves the reversal
+		if err := c.queueReversal(c.reversal(req)); err != nil {
+			log.Printf("terminal %s: antrean reversal tidak dapat disimpan: %v", c.TerminalID, err)
+		}
+		c.sendReversals()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, responseError(resp.Get(39))
+}
+
+// Echo sends a 0800 echo test and reports whether the host answered
+func (c *Client) Echo() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := c.newRequest(iso8583.MTINetworkRequest)
+	req.Set(70, NetEcho)
+	resp, err := c.exchange(req)
+	if err != nil {
+		return err
+	}
+	if err := responseError(resp.Get(39)); err != nil {
+		return err
+	}
+	// The host is back, so the reversals it missed are sent now
+	if err := c.sendReversals(); err != nil {
+		log.Printf("terminal %s: reversal belum terkirim: %v", c.TerminalID, err)
+	}
+	return nil
+}
+
+// LookupCard asks the host for the account ID of the customer name, as reading the card would give it
+func (c *Client) LookupCard(name string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := c.newRequest(iso8583.MTIAuthorizationRequest)
+	req.Set(3, ProcCardLookup+"0000")
+	req.Set(48, url.Values{"name": {name}}.Encode())
+	resp, err := c.inquire(req)
+	if err != nil {
+		return 0, err
+	}
+	accountID, err := strconv.Atoi(resp.Get(102))
+	if err != nil {
+		return 0, fmt.Errorf("field 102 tidak valid: %w", err)
+	}
+	return accountID, nil
+}
+
+// Login has the host verify the PIN of the card and returns the account as the host describes it
+// The account carries no PIN and no balance
+func (c *Client) Login(card Card) (*user.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.inquire(c.cardRequest(iso8583.MTIAuthorizationRequest, ProcLogin, card, 0))
+	if err != nil {
+		return nil, err
+	}
+	return parseAccount(card.AccountID, resp.Get(48))
+}
+
+// Account asks the host for the name and currency of the target account of a transfer within the bank
+func (c *Client) Account(card Card, targetID int) (*user.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := c.cardRequest(iso8583.MTIAuthorizationRequest, ProcNameInquiry, card, 0)
+	req.Set(103, strconv.Itoa(targetID))
+	resp, err := c.inquire(req)
+	if err != nil {
+		return nil, err
+	}
+	return parseAccount(targetID, resp.Get(48))
+}
+
+// CheckBalance asks the host for the ledger balance of the account
+func (c *Client) CheckBalance(card Card) (float64, error) {
+	ledger, _, err := c.Balances(card)
+	return ledger, err
+}
+
+// Balances asks the host for the ledger and the available balance of the account
+func (c *Client) Balances(card Card) (ledger, available float64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.inquire(c.cardRequest(iso8583.MTIAuthorizationRequest, ProcBalanceInquiry, card, 0))
+	if err != nil {
+		return 0, 0, err
+	}
+	return ParseBalances(resp.Get(54))
+}
+
+// Deposit asks the host to credit the account with the cash accepted by the terminal
+func (c *Client) Deposit(card Card, amount float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.financial(c.cardRequest(iso8583.MTIFinancialRequest, ProcDeposit, card, amount))
+	return err
+}
+
+// Withdraw asks the host to debit the account for a cash withdrawal
+func (c *Client) Withdraw(card Card, amount float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.financial(c.cardRequest(iso8583.MTIFinancialRequest, ProcWithdrawal, card, amount))
+	return err
+}
+
+// Transfer asks the host to move money from the account to the target account
+func (c *Client) Transfer(card Card, targetID int, amount float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := c.cardRequest(iso8583.MTIFinancialRequest, ProcTransfer, card, amount)
+	req.Set(102, strconv.Itoa(card.AccountID))
+	req.Set(103, strconv.Itoa(targetID))
+	_, err := c.financial(req)
+	return err
+}
+
+// responseError maps an ISO 8583 response code back onto the domain errors
+func responseError(code string) error {
+	switch code {
+	case RespApproved:
+		return nil
+	case RespInProgress:
+		return transaction.ErrPendingApproval
+	case RespInsufficientFunds:
+		return transaction.ErrInsufficientBalance
+	case RespInvalidAmount:
+		return transaction.ErrInvalidAmount
+	case RespInvalidAccount:
+		return transaction.ErrAccountNotFound
+	case RespIncorrectPIN:
+		return user.ErrWrongPIN
+	case RespPINTriesExceeded:
+		return user.ErrAccountLocked
+	case RespRestrictedCard:
+		return user.ErrAccountFrozen
+	default:
+		return fmt.Errorf("transaksi ditolak host (kode %s)", code)
+	}
+}
@@ -0,0 +1,152 @@
+package host
+
+import (
+	"atm-simulation/pkg/iso8583"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// OpenReversals loads the reversal advices that still wait for an approved 0430 from the file
+// and keeps the queue there from now on, so a reversal survives a restart of the terminal
+// Without a file the queue is only kept in memory
+func (c *Client) OpenReversals(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var pending []*iso8583.Message
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return fmt.Errorf("antrean reversal rusak: %w", err)
+		}
+	}
+	c.reversalFile = path
+	c.reversals = pending
+	return nil
+}
+
+// PendingReversals returns how many reversal advices wait for an approved response
+func (c *Client) PendingReversals() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.reversals)
+}
+
+// ResendReversals sends the queued reversal advices again
+func (c *Client) ResendReversals() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sendReversals()
+}
+
+// Watch resends the queued reversal advices every interval until the stop channel is closed,
+// so they reach the host also while no customer uses the terminal
+func (c *Client) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		if c.PendingReversals() == 0 {
+			continue
+		}
+		if err := c.ResendReversals(); err != nil {
+			log.Printf("terminal %s: reversal belum terkirim: %v", c.TerminalID, err)
+		}
+	}
+}
+
+// reversal builds the 0420 reversal advice of a financial request
+func (c *Client) reversal(original *iso8583.Message) *iso8583.Message {
+	advice := c.newRequest(iso8583.MTIReversalAdvice)
+	for _, field := range []int{2, 3, 4, 49} {
+		if original.Has(field) {
+			advice.Set(field, original.Get(field))
+		}
+	}
+	// Original data elements: MTI, STAN, transmission time, acquirer and forwarder IDs
+	advice.Set(90, original.MTI+original.Get(11)+original.Get(7)+fmt.Sprintf("%022d", 0))
+	return advice
+}
+
+// queueReversal adds a reversal advice to the queue and writes the queue to disk before it is sent
+// The caller must hold the lock
+func (c *Client) queueReversal(advice *iso8583.Message) error {
+	c.reversals = append(c.reversals, advice)
+	return c.saveReversals()
+}
+
+// sendReversals sends the queued reversal advices in order and drops those the host approved
+// Advices the host declines or does not answer stay queued and are sent again later
+// The caller must hold the lock
+func (c *Client) sendReversals() error {
+	if len(c.reversals) == 0 {
+		return nil
+	}
+
+	var kept []*iso8583.Message
+	var sendErr error
+	for _, advice := range c.reversals {
+		if sendErr != nil {
+			kept = append(kept, advice)
+			continue
+		}
+		resp, err := c.exchange(advice)
+		if err != nil {
+			sendErr = err
+			kept = append(kept, advice)
+			continue
+		}
+		if code := resp.Get(39); code != RespApproved {
+			log.Printf("terminal %s: reversal %s ditolak host (kode %s), dikirim ulang nanti", c.TerminalID, advice.Get(90)[:20], code)
+			kept = append(kept, advice)
+		}
+	}
+
+	changed := len(kept) != len(c.reversals)
+	c.reversals = kept
+	if changed {
+		if err := c.saveReversals(); err != nil {
+			return err
+		}
+	}
+	return sendErr
+}
+
+// saveReversals rewrites the queue file, the caller must hold the lock
+func (c *Client) saveReversals() error {
+	if c.reversalFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.reversals)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.reversalFile + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.reversalFile)
+}
@@ -0,0 +1,171 @@
+package host
+
+import (
+	"atm-simulation/pkg/iso8583"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHost answers every request with respond; a nil response drops the connection unanswered
+type fakeHost struct {
+	spec    iso8583.Spec
+	respond func(req *iso8583.Message) *iso8583.Message
+}
+
+func (h *fakeHost) serve(t *testing.T, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				data, err := iso8583.ReadFrame(conn)
+				if err != nil {
+					return
+				}
+				req, err := iso8583.Unpack(h.spec, data)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp := h.respond(req)
+				if resp == nil {
+					return
+				}
+				out, err := resp.Pack(h.spec)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := iso8583.WriteFrame(conn, out); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// startFakeHost runs a fake host until the test ends and returns its address
+func startFakeHost(t *testing.T, h *fakeHost) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go h.serve(t, ln)
+	return ln.Addr().String()
+}
+
+// answer responds to a request with the response code
+func answer(req *iso8583.Message, code string) *iso8583.Message {
+	resp := req.Response(11, 41, 90)
+	resp.Set(39, code)
+	return resp
+}
+
+func TestReversalQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		advices  []string // response codes to the 0420 advices, in order; "" drops the connection
+		resends  int      // ResendReversals calls after the withdrawal
+		pending  int      // advices still queued at the end
+		received int      // 0420 advices the host received
+	}{
+		{"approved right away", []string{RespApproved}, 0, 0, 1},
+		{"host down, approved on resend", []string{"", RespApproved}, 1, 0, 2},
+		{"declined, approved on resend", []string{RespSystemError, RespApproved}, 1, 0, 2},
+		{"declined on every try", []string{RespSystemError, RespSystemError, RespSystemError}, 2, 1, 3},
+		{"never answered", []string{"", "", ""}, 2, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := iso8583.DefaultSpec()
+			var mu sync.Mutex
+			advice := 0
+			h := &fakeHost{spec: spec, respond: func(req *iso8583.Message) *iso8583.Message {
+				switch req.MTI {
+				case iso8583.MTIFinancialRequest:
+					// The withdrawal is taken but never answered
+					return nil
+				case iso8583.MTIReversalAdvice:
+					mu.Lock()
+					defer mu.Unlock()
+					code := tt.advices[advice]
+					advice++
+					if code == "" {
+						return nil
+					}
+					return answer(req, code)
+				}
+				return answer(req, RespApproved)
+			}}
+			addr := startFakeHost(t, h)
+
+			path := filepath.Join(t.TempDir(), "reversals.json")
+			client := NewClient([]string{addr}, "ATM-001", spec)
+			client.Timeout = time.Second
+			if err := client.OpenReversals(path); err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			err := client.Withdraw(Card{AccountID: 12, PINBlock: "041225EEEEEEEEEE"}, 50000)
+			if !errors.Is(err, ErrHostUnavailable) {
+				t.Fatalf("Withdraw = %v, want ErrHostUnavailable", err)
+			}
+			for i := 0; i < tt.resends; i++ {
+				client.ResendReversals()
+			}
+
+			if got := client.PendingReversals(); got != tt.pending {
+				t.Errorf("%d reversals pending, want %d", got, tt.pending)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if advice != tt.received {
+				t.Errorf("host received %d advices, want %d", advice, tt.received)
+			}
+
+			// The queue survives a restart of the terminal
+			restarted := NewClient([]string{addr}, "ATM-001", spec)
+			if err := restarted.OpenReversals(path); err != nil {
+				t.Fatal(err)
+			}
+			if got := restarted.PendingReversals(); got != tt.pending {
+				t.Errorf("%d reversals pending after a restart, want %d", got, tt.pending)
+			}
+		})
+	}
+}
+
+func TestReversalAdvice(t *testing.T) {
+	client := NewClient([]string{"127.0.0.1:0"}, "ATM-001", iso8583.DefaultSpec())
+	original := client.cardRequest(iso8583.MTIFinancialRequest, ProcWithdrawal, Card{AccountID: 12, PINBlock: "041225EEEEEEEEEE", Currency: "IDR"}, 50000)
+	advice := client.reversal(original)
+
+	if advice.MTI != iso8583.MTIReversalAdvice {
+		t.Errorf("MTI %s, want %s", advice.MTI, iso8583.MTIReversalAdvice)
+	}
+	for _, field := range []int{2, 3, 4, 49} {
+		if advice.Get(field) != original.Get(field) {
+			t.Errorf("field %d = %q, want %q", field, advice.Get(field), original.Get(field))
+		}
+	}
+	if advice.Has(52) {
+		t.Error("the advice carries the PIN block")
+	}
+	want := "0200" + original.Get(11) + original.Get(7)
+	if got := advice.Get(90)[:20]; got != want {
+		t.Errorf("field 90 starts with %s, want %s", got, want)
+	}
+	if advice.Get(11) == original.Get(11) {
+		t.Error("the advice reuses the STAN of the original")
+	}
+}
@@ -0,0 +1,518 @@
+package host
+
+import (
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/iso8583"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Processing codes (first two digits of field 3) understood by the host
+const (
+	ProcWithdrawal     = "01"
+	ProcDeposit        = "21"
+	ProcBalanceInquiry = "31"
+	ProcTransfer       = "40"
+
+	// Private use codes of 0100 requests that let a terminal work without a database of its own
+	ProcLogin       = "90" // verify the PIN, returns the account in field 48
+	ProcCardLookup  = "91" // account ID in field 102 of the customer name in field 48, as read from a card
+	ProcNameInquiry = "92" // name and currency in field 48 of the target account in field 103
+)
+
+// Response codes (field 39) returned by the host
+const (
+	RespApproved          = "00"
+	RespInProgress        = "09"
+	RespInvalidTxn        = "12"
+	RespInvalidAmount     = "13"
+	RespInvalidAccount    = "14"
+	RespFormatError       = "30"
+	RespInsufficientFunds = "51"
+	RespIncorrectPIN      = "55"
+	RespRestrictedCard    = "62"
+	RespPINTriesExceeded  = "75"
+	RespOriginalNotFound  = "25"
+	RespDuplicate         = "94"
+	RespSystemError       = "96"
+)
+
+// Network management codes (field 70)
+const (
+	NetSignOn  = "001"
+	NetSignOff = "002"
+	NetEcho    = "301"
+)
+
+// Server is a TCP listener that authorizes ISO 8583 requests against the ledger
+type Server struct {
+	Spec iso8583.Spec
+
+	mu sync.Mutex
+	// Reversals being posted, keyed by the reference of the original request, so a repeated
+	// advice arriving meanwhile is not posted alongside. Reversals that were posted are found
+	// in the transactions table, by every host sharing the database
+	reversing map[string]bool
+}
+
+// NewServer creates a host using the given field spec
+func NewServer(spec iso8583.Spec) *Server {
+	return &Server{Spec: spec, reversing: make(map[string]bool)}
+}
+
+// Serve accepts connections on the listener until it is closed
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn answers every framed message received on the connection
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		data, err := iso8583.ReadFrame(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("host: %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		resp := s.Handle(data, conn.RemoteAddr().String())
+		if resp == nil {
+			continue
+		}
+		if err := iso8583.WriteFrame(conn, resp); err != nil {
+			log.Printf("host: %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// Handle processes one packed request and returns the packed response
+func (s *Server) Handle(data []byte, source string) []byte {
+	req, err := iso8583.Unpack(s.Spec, data)
+	if err != nil {
+		log.Printf("host: pesan dari %s tidak dapat dibaca: %v", source, err)
+		if len(data) < 4 {
+			return nil
+		}
+		// Answer with a format error so the terminal does not wait for a timeout
+		req = iso8583.NewMessage(string(data[:4]))
+		resp := req.Response()
+		resp.Set(39, RespFormatError)
+		return s.pack(resp)
+	}
+
+	var resp *iso8583.Message
+	switch req.MTI {
+	case iso8583.MTINetworkRequest:
+		resp = req.Response(7, 11, 70)
+		resp.Set(39, RespApproved)
+	case iso8583.MTIAuthorizationRequest:
+		resp = s.authorize(req, source)
+	case iso8583.MTIFinancialRequest:
+		resp = s.financial(req, source)
+	case iso8583.MTIReversalAdvice:
+		resp = s.reverse(req)
+	default:
+		resp = req.Response(11, 41)
+		resp.Set(39, RespInvalidTxn)
+	}
+	return s.pack(resp)
+}
+
+// pack encodes a response, logging failures
+func (s *Server) pack(resp *iso8583.Message) []byte {
+	data, err := resp.Pack(s.Spec)
+	if err != nil {
+		log.Printf("host: respons %s tidak dapat dikemas: %v", resp.MTI, err)
+		return nil
+	}
+	return data
+}
+
+// authenticate checks the account in field 2 and the PIN block in field 52
+func (s *Server) authenticate(req *iso8583.Message, source string) (*user.Account, string) {
+	pan := req.Get(2)
+	accountID, err := strconv.Atoi(pan)
+	if err != nil {
+		return nil, RespInvalidAccount
+	}
+	pin, err := iso8583.DecodePINBlock(req.Get(52), pan)
+	if err != nil {
+		return nil, RespIncorrectPIN
+	}
+	account, err := user.VerifyPIN(accountID, pin, "iso8583:"+req.Get(41)+"@"+source)
+	if err != nil {
+		return nil, responseCode(err)
+	}
+	return account, RespApproved
+}
+
+// authorize answers a 0100 request: it verifies the PIN and, for balance inquiries, returns
+// the balance, or for other processing codes checks that the amount is covered without posting it
+// The private use codes look up a card, log a customer in or name the target of a transfer
+func (s *Server) authorize(req *iso8583.Message, source string) *iso8583.Message {
+	resp := req.Response(2, 3, 4, 7, 11, 37, 41, 49, 103)
+	if processingCode(req) == ProcCardLookup {
+		return s.lookupCard(req, resp)
+	}
+	account, code := s.authenticate(req, source)
+	if code != RespApproved {
+		resp.Set(39, code)
+		return resp
+	}
+	accountID := account.ID
+
+	switch processingCode(req) {
+	case ProcLogin:
+		resp.Set(38, authCode())
+		resp.Set(39, RespApproved)
+		resp.Set(48, formatAccount(account))
+		resp.Set(102, strconv.Itoa(accountID))
+		return resp
+	case ProcNameInquiry:
+		return s.nameInquiry(req, resp)
+	}
+
+	balance, err := transaction.CheckBalance(accountID)
+	if err != nil {
+		resp.Set(39, responseCode(err))
+		return resp
+	}
+
+	if processingCode(req) != ProcBalanceInquiry {
+		amount, err := parseAmount(req.Get(4))
+		if err != nil {
+			resp.Set(39, RespInvalidAmount)
+			return resp
+		}
+		if amount > balance.Available {
+			resp.Set(39, RespInsufficientFunds)
+			return resp
+		}
+	}
+
+	resp.Set(38, authCode())
+	resp.Set(39, RespApproved)
+	resp.Set(54, formatBalance(balance, account.Currency))
+	return resp
+}
+
+// lookupCard answers the account ID of the customer named in field 48; no PIN is needed,
+// just as a card can be read before the PIN is entered
+func (s *Server) lookupCard(req *iso8583.Message, resp *iso8583.Message) *iso8583.Message {
+	data, err := url.ParseQuery(req.Get(48))
+	if err != nil || data.Get("name") == "" {
+		resp.Set(39, RespFormatError)
+		return resp
+	}
+	accountID, err := user.LookupID(data.Get("name"))
+	if err != nil {
+		resp.Set(39, responseCode(err))
+		return resp
+	}
+	resp.Set(39, RespApproved)
+	resp.Set(102, strconv.Itoa(accountID))
+	return resp
+}
+
+// nameInquiry answers the name and currency of the target account in field 103, within this bank
+func (s *Server) nameInquiry(req *iso8583.Message, resp *iso8583.Message) *iso8583.Message {
+	targetID, err := strconv.Atoi(req.Get(103))
+	if err != nil {
+		resp.Set(39, RespInvalidAccount)
+		return resp
+	}
+	target, err := user.Get(targetID)
+	if err == nil && target.BankCode != user.BankCode {
+		err = user.ErrAccountNotFound
+	}
+	if err != nil {
+		resp.Set(39, responseCode(err))
+		return resp
+	}
+	resp.Set(39, RespApproved)
+	resp.Set(48, url.Values{"name": {target.Name}, "currency": {target.Currency}}.Encode())
+	return resp
+}
+
+// financial answers a 0200 request by posting it to the ledger
+func (s *Server) financial(req *iso8583.Message, source string) *iso8583.Message {
+	resp := req.Response(2, 3, 4, 7, 11, 37, 41, 49, 102, 103)
+	account, code := s.authenticate(req, source)
+	if code != RespApproved {
+		resp.Set(39, code)
+		return resp
+	}
+	accountID := account.ID
+
+	var amount float64
+	var targetID int
+	var err error
+	proc := processingCode(req)
+	if proc == ProcWithdrawal || proc == ProcDeposit || proc == ProcTransfer {
+		amount, err = parseAmount(req.Get(4))
+		if err != nil {
+			resp.Set(39, RespInvalidAmount)
+			return resp
+		}
+	}
+
+	switch proc {
+	case ProcBalanceInquiry:
+		// Nothing is posted for a balance inquiry
+	case ProcDeposit:
+		err = transaction.DepositWithReference(accountID, amount, postingReference(req.Get(41), req.Get(11), req.Get(7)))
+	case ProcWithdrawal:
+		err = transaction.WithdrawWithReference(accountID, amount, postingReference(req.Get(41), req.Get(11), req.Get(7)))
+	case ProcTransfer:
+		targetID, err = strconv.Atoi(req.Get(103))
+		if err != nil {
+			resp.Set(39, RespInvalidAccount)
+			return resp
+		}
+		err = transaction.TransferWithReference(accountID, targetID, amount, postingReference(req.Get(41), req.Get(11), req.Get(7)))
+	default:
+		resp.Set(39, RespInvalidTxn)
+		return resp
+	}
+	if err != nil {
+		resp.Set(39, responseCode(err))
+		return resp
+	}
+
+	// The request is posted by now, so it is approved even when the balance cannot be read;
+	// a decline would keep the terminal from dispensing without it sending a reversal
+	resp.Set(38, authCode())
+	resp.Set(39, RespApproved)
+	balance, err := transaction.CheckBalance(accountID)
+	if err != nil {
+		log.Printf("host: saldo akun %d tidak dapat dibaca: %v", accountID, err)
+		return resp
+	}
+	resp.Set(54, formatBalance(balance, account.Currency))
+	return resp
+}
+
+// reverse answers a 0420 advice by undoing the original 0200 named in field 90
+// An advice whose original has not arrived yet is approved as well, see transaction.Reverse
+func (s *Server) reverse(req *iso8583.Message) *iso8583.Message {
+	resp := req.Response(2, 3, 4, 7, 11, 41, 90)
+
+	// Field 90 starts with the original MTI, STAN and transmission time
+	original := req.Get(90)
+	if len(original) < 20 {
+		resp.Set(39, RespFormatError)
+		return resp
+	}
+	reference := postingReference(req.Get(41), original[4:10], original[10:20])
+
+	s.mu.Lock()
+	if s.reversing[reference] {
+		s.mu.Unlock()
+		resp.Set(39, RespInProgress)
+		return resp
+	}
+	s.reversing[reference] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.reversing, reference)
+		s.mu.Unlock()
+	}()
+
+	err := transaction.Reverse(reference)
+	switch {
+	case errors.Is(err, transaction.ErrAlreadyReversed):
+		// A repeated advice is acknowledged without reversing twice
+		resp.Set(39, RespApproved)
+	case errors.Is(err, transaction.ErrOriginalNotFound):
+		resp.Set(39, RespOriginalNotFound)
+	case err != nil:
+		resp.Set(39, responseCode(err))
+	default:
+		resp.Set(39, RespApproved)
+	}
+	return resp
+}
+
+// responseCode maps a domain error onto an ISO 8583 response code
+func responseCode(err error) string {
+	switch {
+	case errors.Is(err, user.ErrAccountLocked):
+		return RespPINTriesExceeded
+	case errors.Is(err, user.ErrWrongPIN):
+		return RespIncorrectPIN
+	case errors.Is(err, user.ErrAccountFrozen), errors.Is(err, user.ErrAccountDormant):
+		return RespRestrictedCard
+	case errors.Is(err, user.ErrAccountNotFound), errors.Is(err, user.ErrAccountClosed), errors.Is(err, transaction.ErrAccountNotFound),
+		errors.Is(err, transaction.ErrTargetUnavailable),
+		errors.Is(err, transaction.ErrTargetNotFound), errors.Is(err, transaction.ErrSameAccount):
+		return RespInvalidAccount
+	case errors.Is(err, transaction.ErrInsufficientBalance):
+		return RespInsufficientFunds
+	case errors.Is(err, transaction.ErrPendingApproval):
+		return RespInProgress
+	case errors.Is(err, transaction.ErrInvalidAmount):
+		return RespInvalidAmount
+	case errors.Is(err, transaction.ErrCurrencyMismatch):
+		return RespInvalidTxn
+	case errors.Is(err, transaction.ErrDuplicateReference):
+		return RespDuplicate
+	case errors.Is(err, transaction.ErrAlreadyReversed):
+		// The reversal advice of the request overtook it, so the request is no longer valid
+		return RespInvalidTxn
+	default:
+		log.Println("host:", err)
+		return RespSystemError
+	}
+}
+
+// processingCode returns the transaction type part of field 3
+func processingCode(req *iso8583.Message) string {
+	code := req.Get(3)
+	if len(code) < 2 {
+		return ""
+	}
+	return code[:2]
+}
+
+// postingReference identifies a financial request in the transactions table by terminal, STAN and
+// transmission time; the time tells apart requests of a terminal whose STAN has wrapped around
+func postingReference(terminal, stan, sent string) string {
+	return "iso8583:" + terminal + "/" + stan + "/" + sent
+}
+
+// authCode generates a random 6 digit authorization code for field 38
+func authCode() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("%06d", (int(b[0])<<16|int(b[1])<<8|int(b[2]))%1000000)
+}
+
+// FormatAmount encodes an amount as the minor units used in field 4
+func FormatAmount(amount float64) string {
+	return fmt.Sprintf("%012d", int64(math.Round(amount*100)))
+}
+
+// parseAmount decodes the minor units of field 4
+func parseAmount(value string) (float64, error) {
+	minor, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || minor <= 0 {
+		return 0, transaction.ErrInvalidAmount
+	}
+	return float64(minor) / 100, nil
+}
+
+// Amount types of field 54
+const (
+	amountTypeLedger    = "01"
+	amountTypeAvailable = "02"
+)
+
+// additionalAmountLength is the length of one additional amount in field 54
+const additionalAmountLength = 20
+
+// formatBalance encodes the balances as two additional amounts in field 54, each made of account type 00,
+// amount type (01 ledger, 02 available), the numeric code of the account currency, sign and 12 digit minor units
+func formatBalance(balance transaction.Balance, code string) string {
+	numeric := numericCurrency(code)
+	return formatAdditionalAmount(amountTypeLedger, numeric, balance.Ledger) + formatAdditionalAmount(amountTypeAvailable, numeric, balance.Available)
+}
+
+// formatAdditionalAmount encodes one additional amount of field 54
+func formatAdditionalAmount(amountType, numeric string, amount float64) string {
+	sign := "C"
+	if amount < 0 {
+		sign = "D"
+		amount = -amount
+	}
+	return "00" + amountType + numeric + sign + FormatAmount(amount)
+}
+
+// numericCurrency returns the ISO 4217 numeric code of a currency, that of the base currency when it is unknown
+func numericCurrency(code string) string {
+	c, err := currency.Lookup(code)
+	if err != nil {
+		c, _ = currency.Lookup(currency.Base)
+	}
+	return c.Numeric
+}
+
+// ParseBalances decodes the ledger and the available balance from field 54
+// A host that only sends the ledger balance is taken to have nothing on hold
+func ParseBalances(value string) (ledger, available float64, err error) {
+	if len(value) < additionalAmountLength {
+		return 0, 0, fmt.Errorf("field 54 tidak valid")
+	}
+	found := false
+	for ; len(value) >= additionalAmountLength; value = value[additionalAmountLength:] {
+		minor, err := strconv.ParseInt(value[8:20], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("field 54 tidak valid: %w", err)
+		}
+		amount := float64(minor) / 100
+		if value[7] == 'D' {
+			amount = -amount
+		}
+		switch value[2:4] {
+		case amountTypeLedger:
+			ledger = amount
+		case amountTypeAvailable:
+			available, found = amount, true
+		}
+	}
+	if !found {
+		available = ledger
+	}
+	return ledger, available, nil
+}
+
+// formatAccount describes the account in field 48 of a login response, without its PIN or balance
+func formatAccount(account *user.Account) string {
+	return url.Values{
+		"name":     {account.Name},
+		"status":   {account.Status},
+		"currency": {account.Currency},
+	}.Encode()
+}
+
+// parseAccount reads the account described in field 48
+func parseAccount(accountID int, value string) (*user.Account, error) {
+	data, err := url.ParseQuery(value)
+	if err != nil || data.Get("name") == "" {
+		return nil, fmt.Errorf("field 48 tidak valid")
+	}
+	return &user.Account{
+		ID:       accountID,
+		Name:     data.Get("name"),
+		Status:   data.Get("status"),
+		Currency: data.Get("currency"),
+	}, nil
+}
+
+// transmissionTime formats field 7 (MMDDhhmmss in UTC)
+func transmissionTime(t time.Time) string {
+	return t.UTC().Format("0102150405")
+}
@@ -0,0 +1,132 @@
+package i18n
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+	"golang.org/x/text/number"
+)
+
+// Default is the language used until a customer chooses one
+const Default = "id"
+
+// Language is a language the terminal can be used in
+type Language struct {
+	Code string
+	Name string
+}
+
+// Languages lists the languages offered at the start of a session, in menu order
+var Languages = []Language{
+	{Code: "id", Name: "Bahasa Indonesia"},
+	{Code: "en", Name: "English"},
+}
+
+// printers holds one printer per language, built from the message catalog
+var printers = make(map[string]*Printer)
+
+func init() {
+	builder := catalog.NewBuilder(catalog.Fallback(language.Indonesian))
+	for _, l := range Languages {
+		tag := language.MustParse(l.Code)
+		for key, msg := range messages[l.Code] {
+			if err := builder.SetString(tag, key, msg); err != nil {
+				panic(err)
+			}
+		}
+		printers[l.Code] = &Printer{lang: l.Code, p: message.NewPrinter(tag, message.Catalog(builder))}
+	}
+}
+
+// Printer translates messages and formats numbers and dates for one language
+type Printer struct {
+	lang string
+	p    *message.Printer
+}
+
+// New returns the printer of a language, the default language when it is not supported
+func New(lang string) *Printer {
+	if p, ok := printers[lang]; ok {
+		return p
+	}
+	return printers[Default]
+}
+
+// Supported reports whether the terminal can be used in the language
+func Supported(lang string) bool {
+	_, ok := printers[lang]
+	return ok
+}
+
+// Lang returns the code of the printer's language
+func (p *Printer) Lang() string {
+	return p.lang
+}
+
+// T returns the message with the given catalog key, formatted with the arguments
+func (p *Printer) T(key string, args ...interface{}) string {
+	return p.p.Sprintf(key, args...)
+}
+
+// Number formats a number with the separators of the language, e.g. "1.234,5" or "1,234.5"
+func (p *Printer) Number(x float64, maxDecimals int) string {
+	return p.p.Sprint(number.Decimal(x, number.MaxFractionDigits(maxDecimals)))
+}
+
+// DateTime formats a time with the date layout of the language
+func (p *Printer) DateTime(t time.Time) string {
+	return t.Format(p.T("format.datetime"))
+}
+
+// Date formats a time with the date layout of the language, without the time of day
+func (p *Printer) Date(t time.Time) string {
+	return t.Format(p.T("format.date"))
+}
+
+// Timestamp formats a timestamp read from the database, which is kept as is when it cannot be parsed
+func (p *Printer) Timestamp(s string) string {
+	t, err := time.Parse(time.DateTime, s)
+	if err != nil {
+		return s
+	}
+	return p.DateTime(t)
+}
+
+// Keyed is implemented by errors that carry the catalog key of their translation,
+// together with the arguments of the message, such as the number of PIN attempts left
+type Keyed interface {
+	MessageKey() (key string, args []interface{})
+}
+
+// keyedError is a sentinel error with a translation in the catalog
+type keyedError struct {
+	key  string
+	text string
+}
+
+func (e *keyedError) Error() string {
+	return e.text
+}
+
+func (e *keyedError) MessageKey() (string, []interface{}) {
+	return e.key, nil
+}
+
+// NewError returns an error with the given text, which Error shows as the catalog message with the key
+func NewError(key, text string) error {
+	return &keyedError{key: key, text: text}
+}
+
+// Error returns the translation of the first error in the chain that carries a catalog key,
+// or the error's own text when none does
+func (p *Printer) Error(err error) string {
+	var keyed Keyed
+	if errors.As(err, &keyed) {
+		key, args := keyed.MessageKey()
+		return p.T(key, args...)
+	}
+	return err.Error()
+}
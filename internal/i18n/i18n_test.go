@@ -0,0 +1,193 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// keyPattern matches string literals shaped like catalog keys, e.g. "menu.title"
+var keyPattern = regexp.MustCompile(`^[a-z]+\.[a-z0-9_]+$`)
+
+// verbPattern matches the formatting verbs of a message
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+// allKeys returns the keys defined by any language
+func allKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, msgs := range messages {
+		for key := range msgs {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func TestEveryLanguageHasEveryKey(t *testing.T) {
+	for _, l := range Languages {
+		msgs, ok := messages[l.Code]
+		if !ok {
+			t.Errorf("no catalog for language %s", l.Code)
+			continue
+		}
+		for key := range allKeys() {
+			if _, ok := msgs[key]; !ok {
+				t.Errorf("%s: missing key %q", l.Code, key)
+			}
+		}
+	}
+}
+
+func TestTranslationsUseTheSameVerbs(t *testing.T) {
+	for key, reference := range messages[Default] {
+		want := verbPattern.FindAllString(reference, -1)
+		for _, l := range Languages {
+			got := verbPattern.FindAllString(messages[l.Code][key], -1)
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("%s: %q uses verbs %v, %s uses %v", l.Code, key, got, Default, want)
+			}
+		}
+	}
+}
+
+// TestErrorKeysExist looks for the catalog keys errors carry throughout the source
+// and fails when one of them is not in the catalog
+func TestErrorKeysExist(t *testing.T) {
+	keys := allKeys()
+	fset := token.NewFileSet()
+	for _, dir := range []string{"cmd", "internal", "pkg"} {
+		err := filepath.WalkDir(filepath.Join("..", "..", dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+				return err
+			}
+			f, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(f, func(n ast.Node) bool {
+				lit, ok := n.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+				value, err := strconv.Unquote(lit.Value)
+				if err == nil && strings.HasPrefix(value, "error.") && keyPattern.MatchString(value) && !keys[value] {
+					t.Errorf("%s: unknown catalog key %q", fset.Position(lit.Pos()), value)
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// detailError is a keyed error with arguments, like user.WrongPINError
+type detailError struct {
+	remaining int
+}
+
+func (e *detailError) Error() string {
+	return "PIN salah"
+}
+
+func (e *detailError) MessageKey() (string, []interface{}) {
+	return "error.wrong_pin_attempts_left", []interface{}{e.remaining}
+}
+
+func TestError(t *testing.T) {
+	sentinel := NewError("error.wrong_pin", "PIN salah")
+	tests := []struct {
+		name string
+		err  error
+		id   string
+		en   string
+	}{
+		{"sentinel", sentinel, "PIN salah", "Wrong PIN"},
+		{"wrapped sentinel", fmt.Errorf("nasabah 12: %w", sentinel), "PIN salah", "Wrong PIN"},
+		{"details", &detailError{remaining: 2}, "PIN salah, sisa percobaan 2 kali", "Wrong PIN, 2 attempts left"},
+		{"wrapped details", fmt.Errorf("nasabah 12: %w", &detailError{remaining: 2}), "PIN salah, sisa percobaan 2 kali", "Wrong PIN, 2 attempts left"},
+		{"no key", errors.New("koneksi terputus"), "koneksi terputus", "koneksi terputus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New("id").Error(tt.err); got != tt.id {
+				t.Errorf("id: %q, want %q", got, tt.id)
+			}
+			if got := New("en").Error(tt.err); got != tt.en {
+				t.Errorf("en: %q, want %q", got, tt.en)
+			}
+		})
+	}
+	if got := sentinel.Error(); got != "PIN salah" {
+		t.Errorf("sentinel text %q", got)
+	}
+}
+
+// TestKeysUsedByTerminalExist looks for catalog keys in the terminal's source and fails
+// when one of them is not in the catalog, so a typo does not show up as a raw key on screen
+func TestKeysUsedByTerminalExist(t *testing.T) {
+	keys := allKeys()
+	namespaces := make(map[string]bool)
+	for key := range keys {
+		namespaces[strings.SplitN(key, ".", 2)[0]] = true
+	}
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "cmd", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no terminal sources found")
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil || !keyPattern.MatchString(value) {
+				return true
+			}
+			if namespaces[strings.SplitN(value, ".", 2)[0]] && !keys[value] {
+				t.Errorf("%s: unknown catalog key %q", fset.Position(lit.Pos()), value)
+			}
+			return true
+		})
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	id, en := New("id"), New("en")
+	if got := id.Number(1234567.5, 2); got != "1.234.567,5" {
+		t.Errorf("id number = %q", got)
+	}
+	if got := en.Number(1234567.5, 2); got != "1,234,567.5" {
+		t.Errorf("en number = %q", got)
+	}
+	if got := id.Timestamp("2025-04-30 14:05:00"); got != "30/04/2025 14:05:00" {
+		t.Errorf("id timestamp = %q", got)
+	}
+	if got := en.Timestamp("2025-04-30 14:05:00"); got != "04/30/2025 02:05:00 PM" {
+		t.Errorf("en timestamp = %q", got)
+	}
+	if got := New("fr").Lang(); got != Default {
+		t.Errorf("unsupported language falls back to %q", got)
+	}
+}
@@ -0,0 +1,584 @@
+package i18n
+
+// messages is the catalog of the terminal, keyed by language code and message key
+// Every language must define every key; i18n_test.go checks that
+var messages = map[string]map[string]string{
+	"id": {
+		"format.datetime":  "02/01/2006 15:04:05",
+		"format.date":      "02/01/2006",
+		"format.day_month": "02/01",
+
+		"language.prompt": "Pilih bahasa / Choose language (1-%d): ",
+
+		"menu.title":          "===== Menu Utama =====",
+		"menu.register":       "1. Daftar",
+		"menu.login":          "2. Login",
+		"menu.balance":        "3. Cek Saldo",
+		"menu.deposit":        "4. Setor Tunai",
+		"menu.withdraw":       "5. Tarik Tunai",
+		"menu.transfer":       "6. Transfer",
+		"menu.profile":        "7. Lihat Profil",
+		"menu.change_pin":     "8. Ganti PIN",
+		"menu.logout":         "9. Log Out",
+		"menu.history":        "10. Riwayat Transaksi",
+		"menu.mini_statement": "11. Mini Statement",
+		"menu.exit":           "12. Keluar",
+		"menu.prompt":         "Pilih menu (1-%d): ",
+		"menu.invalid_choice": "Pilihan tidak valid, coba lagi.",
+		"menu.goodbye":        "Terima kasih telah menggunakan aplikasi ATM!",
+
+		"common.back_to_menu":  "Kembali ke menu utama...\n",
+		"common.not_permitted": "Anda tidak memiliki akses untuk operasi ini.",
+
+		"prompt.name": "Masukkan nama: ",
+		"prompt.pin":  "Masukkan PIN: ",
+
+		"register.currency_prompt":    "Masukkan mata uang akun (%s, kosongkan untuk %s): ",
+		"register.failed":             "Gagal membuat akun: %s",
+		"register.success":            "Akun berhasil dibuat! ID Akun: %d",
+		"register.unavailable_remote": "Pembukaan akun tidak tersedia di terminal ini, silakan hubungi kantor cabang.",
+
+		"login.already":        "Anda sudah login, silakan log out terlebih dahulu.",
+		"login.failed":         "Login gagal: %s",
+		"login.welcome":        "Login berhasil! Selamat datang, %s.",
+		"login.offline_notice": "Terminal sedang offline: hanya cek saldo dan penarikan yang tersedia.",
+		"login.frozen_notice":  "Akun Anda dibekukan: Anda masih dapat menerima dana, tetapi tidak dapat menarik atau mengirim dana.",
+
+		"logout.not_logged_in": "Anda belum login.",
+		"logout.success":       "Anda telah berhasil log out.",
+
+		"balance.login_first": "Silakan login terlebih dahulu untuk melihat saldo.",
+		"balance.failed":      "Gagal memeriksa saldo: %s",
+		"balance.current":     "Saldo Anda saat ini: %s",
+		"balance.available":   "Saldo tersedia: %s (sebagian dana sedang ditahan)",
+
+		"deposit.login_first":        "Silakan login terlebih dahulu untuk deposit.",
+		"deposit.amount_prompt":      "Masukkan jumlah deposit: ",
+		"deposit.failed":             "Gagal melakukan deposit: %s",
+		"deposit.success":            "Deposit berhasil! Saldo Anda sekarang: %s",
+		"deposit.success_no_balance": "Deposit berhasil!",
+
+		"withdraw.login_first":        "Silakan login terlebih dahulu untuk melakukan penarikan.",
+		"withdraw.amount_prompt":      "Masukkan jumlah penarikan: ",
+		"withdraw.failed":             "Gagal melakukan penarikan: %s",
+		"withdraw.success":            "Penarikan berhasil! Saldo Anda sekarang: %s",
+		"withdraw.success_no_balance": "Penarikan berhasil! Silakan ambil uang Anda.",
+
+		"transfer.login_first":        "Silakan login terlebih dahulu untuk melakukan transfer.",
+		"transfer.bank_prompt":        "Masukkan kode bank tujuan (kosongkan untuk bank sendiri, %s): ",
+		"transfer.target_prompt":      "Masukkan ID akun tujuan: ",
+		"transfer.target_not_found":   "User ID tujuan tidak terdaftar.",
+		"transfer.target_found":       "Akun tujuan ditemukan: %s (ID: %d)",
+		"transfer.confirm_prompt":     "Apakah Anda yakin ingin mentransfer ke akun ini? (y/n): ",
+		"transfer.cancelled":          "Transfer dibatalkan. Kembali ke menu utama.",
+		"transfer.amount_prompt":      "Masukkan jumlah transfer: ",
+		"transfer.failed":             "Gagal melakukan transfer: %s",
+		"transfer.success":            "Transfer berhasil! Saldo Anda sekarang: %s",
+		"transfer.success_no_balance": "Transfer berhasil!",
+		"transfer.pending_approval":   "Transfer sebesar %s melebihi batas dan menunggu persetujuan bank. Dananya ditahan sampai transfer disetujui, ditolak atau kedaluwarsa.",
+		"transfer.pending_number":     "Nomor permintaan: %d, berlaku sampai %s",
+
+		"fx.unavailable":    "Transfer antar mata uang tidak tersedia: tabel kurs belum dimuat.",
+		"fx.amount_prompt":  "Masukkan jumlah transfer dalam %s: ",
+		"fx.quote_failed":   "Gagal menghitung kurs: %s",
+		"fx.rate":           "Kurs: 1 %s = %s %s",
+		"fx.summary":        "Anda mengirim %s, %s menerima %s",
+		"fx.confirm_prompt": "Lanjutkan transfer dengan kurs ini? (y/n): ",
+
+		"interbank.account_prompt": "Masukkan nomor rekening tujuan: ",
+		"interbank.inquiry_failed": "Gagal memeriksa rekening tujuan: %s",
+		"interbank.account_found":  "Rekening tujuan ditemukan: %s (bank %s, rekening %d)",
+		"interbank.confirm_prompt": "Apakah Anda yakin ingin mentransfer ke rekening ini? (y/n): ",
+		"interbank.accepted":       "Transfer antarbank diterima dan sedang diproses kliring (referensi %s).",
+		"interbank.balance":        "Saldo Anda sekarang: %s",
+
+		"profile.login_first": "Silakan login terlebih dahulu untuk melihat profil.",
+		"profile.title":       "===== Profil Akun =====",
+		"profile.account_id":  "ID Akun: %d",
+		"profile.name":        "Nama: %s",
+		"profile.balance":     "Saldo: %s",
+
+		"pin.login_first":      "Silakan login terlebih dahulu untuk mengganti PIN.",
+		"pin.old_prompt":       "Masukkan PIN lama: ",
+		"pin.old_wrong":        "PIN lama salah. Gagal mengganti PIN.",
+		"pin.new_prompt":       "Masukkan PIN baru: ",
+		"pin.failed":           "Gagal mengganti PIN: %s",
+		"pin.success":          "PIN berhasil diganti.",
+		"pin.confirm_prompt":   "Masukkan ulang PIN baru: ",
+		"pin.confirm_mismatch": "PIN tidak sama, silakan ulangi.",
+		"pin.policy_length":    "PIN baru harus terdiri dari %d angka.",
+
+		"history.login_first":            "Silakan login terlebih dahulu untuk melihat riwayat transaksi.",
+		"history.menu_title":             "===== Pilih Jenis Transaksi =====",
+		"history.menu_transfer_in":       "1. Transferan Masuk",
+		"history.menu_transfer_out":      "2. Transferan Keluar",
+		"history.menu_withdraw":          "3. Tarik Tunai",
+		"history.menu_deposit":           "4. Setor Tunai",
+		"history.menu_hold":              "5. Dana Ditahan",
+		"history.menu_back":              "6. Kembali ke menu utama",
+		"history.failed":                 "Gagal memuat riwayat transaksi: %s",
+		"history.title":                  "===== Riwayat Transaksi =====",
+		"history.title_deposit":          "===== Riwayat Transaksi Deposit =====",
+		"history.title_withdraw":         "===== Riwayat Transaksi Tarik Tunai =====",
+		"history.type":                   "Tipe Transaksi: %s",
+		"history.type_deposit":           "Setor Tunai",
+		"history.type_withdraw":          "Tarik Tunai",
+		"history.type_transfer_in":       "Transferan Masuk",
+		"history.type_transfer_out":      "Transferan Keluar",
+		"history.type_interbank_out":     "Transfer Antarbank Keluar",
+		"history.type_interbank_in":      "Transfer Antarbank Masuk",
+		"history.type_interbank_refund":  "Pengembalian Transfer Antarbank",
+		"history.type_adjustment_credit": "Koreksi Saldo (Kredit)",
+		"history.type_adjustment_debit":  "Koreksi Saldo (Debit)",
+		"history.type_payout":            "Pembayaran Saldo Penutupan Akun",
+		"history.type_capture":           "Pendebetan Dana Ditahan",
+		"history.type_hold":              "Dana Ditahan (belum didebet)",
+		"history.type_interest":          "Bunga",
+		"history.type_fee":               "Biaya Administrasi Bulanan",
+		"history.type_reversal_in":       "Pembatalan Transaksi (Kredit)",
+		"history.type_reversal_out":      "Pembatalan Transaksi (Debit)",
+		"history.amount":                 "Jumlah: %s",
+		"history.date":                   "Tanggal: %s",
+		"history.name":                   "Nama: %s",
+		"history.target_id":              "Target ID: %d",
+		"history.target_name_failed":     "Gagal mendapatkan nama akun tujuan: %s",
+
+		"statement.title":           "Rekening Koran",
+		"statement.account":         "Rekening: %d - %s",
+		"statement.currency":        "Mata uang: %s",
+		"statement.period":          "Periode: %s s/d %s",
+		"statement.generated":       "Dibuat: %s",
+		"statement.col_date":        "Tanggal",
+		"statement.col_type":        "Jenis",
+		"statement.col_description": "Keterangan",
+		"statement.col_debit":       "Debit",
+		"statement.col_credit":      "Kredit",
+		"statement.col_balance":     "Saldo",
+		"statement.col_count":       "Jumlah Transaksi",
+		"statement.col_total":       "Total",
+		"statement.opening":         "Saldo Awal",
+		"statement.closing":         "Saldo Akhir",
+		"statement.summary":         "Ringkasan per Jenis Transaksi",
+		"statement.counterparty":    "Akun %d",
+		"statement.no_transactions": "Tidak ada transaksi pada periode ini",
+		"statement.page":            "Halaman %d",
+
+		"receipt.prompt":     "Cetak struk? (y/n): ",
+		"receipt.account_id": "ID Akun : %d",
+		"receipt.line":       "%-8s: %s",
+		"receipt.balance":    "Saldo   : %s",
+		"receipt.deposit":    "SETOR",
+		"receipt.withdraw":   "TARIK",
+		"receipt.transfer":   "TRANSFER",
+
+		"mini.login_first":            "Silakan login terlebih dahulu untuk melihat mini statement.",
+		"mini.failed":                 "Gagal memuat mini statement: %s",
+		"mini.title":                  "MUTASI REKENING",
+		"mini.empty":                  "Belum ada transaksi",
+		"mini.type_deposit":           "SETOR",
+		"mini.type_withdraw":          "TARIK",
+		"mini.type_transfer_in":       "TRF MSK",
+		"mini.type_transfer_out":      "TRF KLR",
+		"mini.type_reversal_in":       "BATAL CR",
+		"mini.type_reversal_out":      "BATAL DB",
+		"mini.type_interbank_out":     "ANTBANK-",
+		"mini.type_interbank_in":      "ANTBANK+",
+		"mini.type_interbank_refund":  "RETUR",
+		"mini.type_adjustment_credit": "KOREKSI+",
+		"mini.type_adjustment_debit":  "KOREKSI-",
+		"mini.type_payout":            "TUTUP",
+		"mini.type_capture":           "DEBET",
+		"mini.type_interest":          "BUNGA",
+		"mini.type_fee":               "BIAYA",
+
+		"session.max_duration":     "Batas waktu sesi telah tercapai.",
+		"session.more_time_prompt": "Apakah Anda membutuhkan waktu tambahan? (y/n) [%2d] ",
+		"session.logged_out":       "Anda telah log out secara otomatis.",
+
+		"input.invalid_amount": "Jumlah uang tidak valid, coba lagi.",
+		"input.invalid_pin":    "PIN harus terdiri dari %d sampai %d angka, coba lagi.",
+		"input.pin_keys":       "Backspace: hapus satu angka · Del: hapus semua · Esc: batal",
+		"input.pin_cancelled":  "Input PIN dibatalkan.",
+
+		"tui.welcome":            "Selamat Datang",
+		"tui.choose_language":    "Pilih bahasa / Choose language",
+		"tui.name_prompt":        "Masukkan nama Anda",
+		"tui.pin_prompt":         "Masukkan PIN Anda",
+		"tui.greeting":           "Selamat datang, %s",
+		"tui.choose_transaction": "Silakan pilih transaksi",
+		"tui.balance":            "Cek Saldo",
+		"tui.withdraw":           "Tarik Tunai",
+		"tui.deposit":            "Setor Tunai",
+		"tui.transfer":           "Transfer",
+		"tui.profile":            "Profil",
+		"tui.mini_statement":     "Mutasi Rekening",
+		"tui.finish":             "Selesai",
+		"tui.cancel":             "Batal",
+		"tui.back":               "Kembali",
+		"tui.yes":                "Ya",
+		"tui.no":                 "Tidak",
+		"tui.choose_amount":      "Pilih jumlah penarikan",
+		"tui.other_amount":       "Jumlah Lain",
+		"tui.amount_withdraw":    "Masukkan jumlah penarikan",
+		"tui.amount_deposit":     "Masukkan jumlah setoran",
+		"tui.amount_transfer":    "Masukkan jumlah transfer",
+		"tui.target_prompt":      "Masukkan ID akun tujuan",
+		"tui.confirm_target":     "Transfer ke %s (ID %d)?",
+		"tui.processing":         "Transaksi Anda sedang diproses",
+		"tui.counting":           "Menghitung uang",
+		"tui.take_cash":          "Silakan ambil uang Anda",
+		"tui.take_card":          "Silakan ambil kartu Anda",
+		"tui.keys":               "F1-F8 tombol samping · Enter OK · Del hapus · Esc batal · Ctrl+C matikan",
+		"tui.more_time":          "Butuh waktu tambahan? Tekan tombol apa saja (%d)",
+
+		"error.name_taken":                      "Nama pengguna sudah terdaftar, silakan pilih nama lain",
+		"error.account_not_found":               "Akun tidak ditemukan",
+		"error.account_locked":                  "Akun terkunci, silakan hubungi bank",
+		"error.wrong_pin":                       "PIN salah",
+		"error.wrong_pin_attempts_left":         "PIN salah, sisa percobaan %d kali",
+		"error.wrong_pin_locked":                "PIN salah %d kali, akun terkunci, silakan hubungi bank",
+		"error.account_frozen":                  "Akun dibekukan, silakan hubungi bank",
+		"error.account_dormant":                 "Akun tidak aktif karena lama tidak digunakan, silakan hubungi bank",
+		"error.account_closed":                  "Akun sudah ditutup",
+		"error.target_unavailable":              "Akun tujuan tidak dapat menerima dana",
+		"error.pending_approval":                "Transfer melebihi batas dan menunggu persetujuan bank",
+		"error.transfer_not_found":              "Transfer tertunda tidak ditemukan",
+		"error.transfer_not_pending":            "Transfer sudah tidak menunggu persetujuan",
+		"error.transfer_expired":                "Transfer sudah kedaluwarsa",
+		"error.pin_length":                      "Panjang PIN tidak sesuai ketentuan",
+		"error.pin_not_numeric":                 "PIN hanya boleh berisi angka",
+		"error.pin_sequence":                    "PIN tidak boleh berupa angka berurutan",
+		"error.pin_repeated":                    "PIN tidak boleh berupa angka yang berulang",
+		"error.pin_date":                        "PIN tidak boleh berupa tanggal, seperti tanggal lahir",
+		"error.pin_reused":                      "PIN sudah pernah digunakan, pilih PIN lain",
+		"error.target_not_found":                "User ID tujuan tidak terdaftar",
+		"error.insufficient_balance":            "Saldo tidak mencukupi",
+		"error.invalid_amount":                  "Jumlah uang tidak valid",
+		"error.same_account":                    "Tidak dapat mentransfer ke akun sendiri",
+		"error.no_history":                      "Tidak ada riwayat transaksi untuk kategori ini",
+		"error.currency_mismatch":               "Mata uang akun tujuan berbeda, transfer memerlukan konversi kurs",
+		"error.quote_mismatch":                  "Kurs tidak sesuai dengan mata uang kedua akun",
+		"error.unknown_currency":                "Mata uang tidak dikenal",
+		"error.no_rate":                         "Kurs untuk mata uang ini tidak tersedia",
+		"error.unknown_bank":                    "Kode bank tidak dikenal",
+		"error.same_bank":                       "Bank tujuan sama dengan bank asal, gunakan transfer biasa",
+		"error.beneficiary_not_found":           "Rekening tujuan tidak ditemukan di bank tujuan",
+		"error.foreign_currency":                "Kliring antarbank hanya melayani rekening dalam IDR",
+		"error.host_unavailable":                "Host tidak dapat dihubungi",
+		"error.not_cached":                      "Akun tidak tersedia dalam mode offline",
+		"error.offline_limit":                   "Jumlah melebihi batas penarikan offline",
+		"error.offline_limit_left":              "Jumlah melebihi batas penarikan offline, sisa %.2f",
+		"error.service_offline":                 "Layanan tidak tersedia saat terminal offline",
+		"error.session_timeout":                 "Sesi berakhir karena tidak ada aktivitas",
+		"error.interbank_not_supported_by_host": "Transfer antarbank belum didukung melalui host",
+		"error.fx_not_supported_by_host":        "Transfer antar mata uang belum didukung melalui host",
+		"error.hold_not_found":                  "Dana yang ditahan tidak ditemukan",
+		"error.hold_not_active":                 "Dana sudah tidak ditahan",
+		"error.hold_expired":                    "Masa penahanan dana sudah berakhir",
+		"error.capture_exceeds_hold":            "Jumlah melebihi dana yang ditahan",
+		"error.original_not_found":              "Transaksi asal tidak ditemukan",
+		"error.already_reversed":                "Transaksi sudah dibatalkan",
+		"error.duplicate_reference":             "Referensi transaksi sudah dipakai",
+		"error.no_clearing":                     "Kliring antarbank tidak tersedia untuk memproses transfer ini",
+		"error.standing_order_not_found":        "Standing order tidak ditemukan",
+		"error.standing_order_day":              "Tanggal standing order harus 1 sampai 31",
+		"error.reason_required":                 "Alasan wajib diisi",
+		"error.account_active":                  "Akun sudah aktif",
+		"error.account_unlocked":                "Akun tidak terkunci",
+		"error.invalid_adjustment":              "Jumlah koreksi tidak valid",
+		"error.balance_negative":                "Koreksi membuat saldo menjadi negatif",
+		"error.unknown_role":                    "Peran tidak dikenal",
+		"error.not_permitted":                   "Peran tidak memiliki izin untuk aksi ini",
+		"error.out_of_scope":                    "Aksi tidak tersedia dalam sesi ini",
+		"error.self_approval":                   "Permintaan tidak boleh disetujui oleh pembuatnya sendiri",
+	},
+	"en": {
+		"format.datetime":  "01/02/2006 03:04:05 PM",
+		"format.date":      "01/02/2006",
+		"format.day_month": "01/02",
+
+		"language.prompt": "Pilih bahasa / Choose language (1-%d): ",
+
+		"menu.title":          "===== Main Menu =====",
+		"menu.register":       "1. Register",
+		"menu.login":          "2. Login",
+		"menu.balance":        "3. Check Balance",
+		"menu.deposit":        "4. Deposit",
+		"menu.withdraw":       "5. Withdraw",
+		"menu.transfer":       "6. Transfer",
+		"menu.profile":        "7. View Profile",
+		"menu.change_pin":     "8. Change PIN",
+		"menu.logout":         "9. Log Out",
+		"menu.history":        "10. View Transaction History",
+		"menu.mini_statement": "11. Mini Statement",
+		"menu.exit":           "12. Exit",
+		"menu.prompt":         "Choose an option (1-%d): ",
+		"menu.invalid_choice": "Invalid choice, please try again.",
+		"menu.goodbye":        "Thank you for using the ATM!",
+
+		"common.back_to_menu":  "Returning to the main menu...\n",
+		"common.not_permitted": "You are not allowed to perform this operation.",
+
+		"prompt.name": "Enter name: ",
+		"prompt.pin":  "Enter PIN: ",
+
+		"register.currency_prompt":    "Enter the account currency (%s, leave empty for %s): ",
+		"register.failed":             "Could not create the account: %s",
+		"register.success":            "Account created! Account ID: %d",
+		"register.unavailable_remote": "Opening an account is not available at this terminal, please visit a branch.",
+
+		"login.already":        "You are already logged in, please log out first.",
+		"login.failed":         "Login failed: %s",
+		"login.welcome":        "Login successful! Welcome, %s.",
+		"login.offline_notice": "The terminal is offline: only balance inquiries and withdrawals are available.",
+		"login.frozen_notice":  "Your account is frozen: you can still receive money, but you cannot withdraw or send it.",
+
+		"logout.not_logged_in": "You are not logged in.",
+		"logout.success":       "You have logged out.",
+
+		"balance.login_first": "Please log in first to check your balance.",
+		"balance.failed":      "Could not check the balance: %s",
+		"balance.current":     "Your current balance: %s",
+		"balance.available":   "Available balance: %s (some funds are on hold)",
+
+		"deposit.login_first":        "Please log in first to make a deposit.",
+		"deposit.amount_prompt":      "Enter the deposit amount: ",
+		"deposit.failed":             "Deposit failed: %s",
+		"deposit.success":            "Deposit successful! Your balance is now: %s",
+		"deposit.success_no_balance": "Deposit successful!",
+
+		"withdraw.login_first":        "Please log in first to make a withdrawal.",
+		"withdraw.amount_prompt":      "Enter the withdrawal amount: ",
+		"withdraw.failed":             "Withdrawal failed: %s",
+		"withdraw.success":            "Withdrawal successful! Your balance is now: %s",
+		"withdraw.success_no_balance": "Withdrawal successful! Please take your cash.",
+
+		"transfer.login_first":        "Please log in first to make a transfer.",
+		"transfer.bank_prompt":        "Enter the destination bank code (leave empty for this bank, %s): ",
+		"transfer.target_prompt":      "Enter the destination account ID: ",
+		"transfer.target_not_found":   "The destination account ID is not registered.",
+		"transfer.target_found":       "Destination account found: %s (ID: %d)",
+		"transfer.confirm_prompt":     "Are you sure you want to transfer to this account? (y/n): ",
+		"transfer.cancelled":          "Transfer cancelled. Returning to the main menu.",
+		"transfer.amount_prompt":      "Enter the transfer amount: ",
+		"transfer.failed":             "Transfer failed: %s",
+		"transfer.success":            "Transfer successful! Your balance is now: %s",
+		"transfer.success_no_balance": "Transfer successful!",
+		"transfer.pending_approval":   "The transfer of %s is above the limit and awaits approval by the bank. The funds are held until the transfer is approved, rejected or expires.",
+		"transfer.pending_number":     "Request number: %d, valid until %s",
+
+		"fx.unavailable":    "Transfers between currencies are unavailable: no rate table is loaded.",
+		"fx.amount_prompt":  "Enter the transfer amount in %s: ",
+		"fx.quote_failed":   "Could not quote the exchange rate: %s",
+		"fx.rate":           "Rate: 1 %s = %s %s",
+		"fx.summary":        "You send %s, %s receives %s",
+		"fx.confirm_prompt": "Continue the transfer at this rate? (y/n): ",
+
+		"interbank.account_prompt": "Enter the destination account number: ",
+		"interbank.inquiry_failed": "Could not check the destination account: %s",
+		"interbank.account_found":  "Destination account found: %s (bank %s, account %d)",
+		"interbank.confirm_prompt": "Are you sure you want to transfer to this account? (y/n): ",
+		"interbank.accepted":       "The interbank transfer was accepted and is being cleared (reference %s).",
+		"interbank.balance":        "Your balance is now: %s",
+
+		"profile.login_first": "Please log in first to view your profile.",
+		"profile.title":       "===== Account Profile =====",
+		"profile.account_id":  "Account ID: %d",
+		"profile.name":        "Name: %s",
+		"profile.balance":     "Balance: %s",
+
+		"pin.login_first":      "Please log in first to change your PIN.",
+		"pin.old_prompt":       "Enter your old PIN: ",
+		"pin.old_wrong":        "The old PIN is wrong. The PIN was not changed.",
+		"pin.new_prompt":       "Enter your new PIN: ",
+		"pin.failed":           "Could not change the PIN: %s",
+		"pin.success":          "PIN changed.",
+		"pin.confirm_prompt":   "Enter the new PIN again: ",
+		"pin.confirm_mismatch": "The PINs do not match, please try again.",
+		"pin.policy_length":    "The new PIN must have %d digits.",
+
+		"history.login_first":            "Please log in first to view your transaction history.",
+		"history.menu_title":             "===== Choose Transaction Type =====",
+		"history.menu_transfer_in":       "1. Incoming Transfers",
+		"history.menu_transfer_out":      "2. Outgoing Transfers",
+		"history.menu_withdraw":          "3. Withdrawals",
+		"history.menu_deposit":           "4. Deposits",
+		"history.menu_hold":              "5. Funds on Hold",
+		"history.menu_back":              "6. Back to the main menu",
+		"history.failed":                 "Could not load the transaction history: %s",
+		"history.title":                  "===== Transaction History =====",
+		"history.title_deposit":          "===== Deposit History =====",
+		"history.title_withdraw":         "===== Withdrawal History =====",
+		"history.type":                   "Transaction Type: %s",
+		"history.type_deposit":           "Deposit",
+		"history.type_withdraw":          "Withdrawal",
+		"history.type_transfer_in":       "Incoming Transfer",
+		"history.type_transfer_out":      "Outgoing Transfer",
+		"history.type_interbank_out":     "Outgoing Interbank Transfer",
+		"history.type_interbank_in":      "Incoming Interbank Transfer",
+		"history.type_interbank_refund":  "Interbank Transfer Refund",
+		"history.type_adjustment_credit": "Balance Adjustment (Credit)",
+		"history.type_adjustment_debit":  "Balance Adjustment (Debit)",
+		"history.type_payout":            "Closing Balance Payout",
+		"history.type_capture":           "Captured Hold",
+		"history.type_hold":              "Funds on Hold (not yet debited)",
+		"history.type_interest":          "Interest",
+		"history.type_fee":               "Monthly Account Fee",
+		"history.type_reversal_in":       "Reversal (Credit)",
+		"history.type_reversal_out":      "Reversal (Debit)",
+		"history.amount":                 "Amount: %s",
+		"history.date":                   "Date: %s",
+		"history.name":                   "Name: %s",
+		"history.target_id":              "Target ID: %d",
+		"history.target_name_failed":     "Could not get the destination account name: %s",
+
+		"statement.title":           "Account Statement",
+		"statement.account":         "Account: %d - %s",
+		"statement.currency":        "Currency: %s",
+		"statement.period":          "Period: %s to %s",
+		"statement.generated":       "Generated: %s",
+		"statement.col_date":        "Date",
+		"statement.col_type":        "Type",
+		"statement.col_description": "Description",
+		"statement.col_debit":       "Debit",
+		"statement.col_credit":      "Credit",
+		"statement.col_balance":     "Balance",
+		"statement.col_count":       "Transactions",
+		"statement.col_total":       "Total",
+		"statement.opening":         "Opening Balance",
+		"statement.closing":         "Closing Balance",
+		"statement.summary":         "Totals per Transaction Type",
+		"statement.counterparty":    "Account %d",
+		"statement.no_transactions": "No transactions in this period",
+		"statement.page":            "Page %d",
+
+		"receipt.prompt":     "Print a receipt? (y/n): ",
+		"receipt.account_id": "Account : %d",
+		"receipt.line":       "%-8s: %s",
+		"receipt.balance":    "Balance : %s",
+		"receipt.deposit":    "DEPOSIT",
+		"receipt.withdraw":   "WITHDRAW",
+		"receipt.transfer":   "TRANSFER",
+
+		"mini.login_first":            "Please log in first to view the mini statement.",
+		"mini.failed":                 "Could not load the mini statement: %s",
+		"mini.title":                  "MINI STATEMENT",
+		"mini.empty":                  "No transactions yet",
+		"mini.type_deposit":           "DEP",
+		"mini.type_withdraw":          "WDL",
+		"mini.type_transfer_in":       "TRF IN",
+		"mini.type_transfer_out":      "TRF OUT",
+		"mini.type_reversal_in":       "REV CR",
+		"mini.type_reversal_out":      "REV DB",
+		"mini.type_interbank_out":     "IBT OUT",
+		"mini.type_interbank_in":      "IBT IN",
+		"mini.type_interbank_refund":  "IBT RFND",
+		"mini.type_adjustment_credit": "ADJ CR",
+		"mini.type_adjustment_debit":  "ADJ DB",
+		"mini.type_payout":            "PAYOUT",
+		"mini.type_capture":           "CAPTURE",
+		"mini.type_interest":          "INTEREST",
+		"mini.type_fee":               "FEE",
+
+		"session.max_duration":     "The session time limit has been reached.",
+		"session.more_time_prompt": "Do you need more time? (y/n) [%2d] ",
+		"session.logged_out":       "You have been logged out automatically.",
+
+		"input.invalid_amount": "Invalid amount, please try again.",
+		"input.invalid_pin":    "The PIN must have %d to %d digits, please try again.",
+		"input.pin_keys":       "Backspace: delete a digit · Del: clear · Esc: cancel",
+		"input.pin_cancelled":  "PIN entry cancelled.",
+
+		"tui.welcome":            "Welcome",
+		"tui.choose_language":    "Pilih bahasa / Choose language",
+		"tui.name_prompt":        "Enter your name",
+		"tui.pin_prompt":         "Enter your PIN",
+		"tui.greeting":           "Welcome, %s",
+		"tui.choose_transaction": "Please select a transaction",
+		"tui.balance":            "Balance",
+		"tui.withdraw":           "Withdraw Cash",
+		"tui.deposit":            "Deposit Cash",
+		"tui.transfer":           "Transfer",
+		"tui.profile":            "Profile",
+		"tui.mini_statement":     "Mini Statement",
+		"tui.finish":             "Finish",
+		"tui.cancel":             "Cancel",
+		"tui.back":               "Back",
+		"tui.yes":                "Yes",
+		"tui.no":                 "No",
+		"tui.choose_amount":      "Select the amount to withdraw",
+		"tui.other_amount":       "Other Amount",
+		"tui.amount_withdraw":    "Enter the withdrawal amount",
+		"tui.amount_deposit":     "Enter the deposit amount",
+		"tui.amount_transfer":    "Enter the transfer amount",
+		"tui.target_prompt":      "Enter the destination account ID",
+		"tui.confirm_target":     "Transfer to %s (ID %d)?",
+		"tui.processing":         "Processing your transaction",
+		"tui.counting":           "Counting your cash",
+		"tui.take_cash":          "Please take your cash",
+		"tui.take_card":          "Please take your card",
+		"tui.keys":               "F1-F8 side buttons · Enter OK · Del clear · Esc cancel · Ctrl+C shut down",
+		"tui.more_time":          "Need more time? Press any key (%d)",
+
+		"error.name_taken":                      "The name is already registered, please choose another one",
+		"error.account_not_found":               "Account not found",
+		"error.account_locked":                  "The account is locked, please contact the bank",
+		"error.wrong_pin":                       "Wrong PIN",
+		"error.wrong_pin_attempts_left":         "Wrong PIN, %d attempts left",
+		"error.wrong_pin_locked":                "Wrong PIN %d times, the account is locked, please contact the bank",
+		"error.account_frozen":                  "The account is frozen, please contact the bank",
+		"error.account_dormant":                 "The account is dormant after a long time without use, please contact the bank",
+		"error.account_closed":                  "The account is closed",
+		"error.target_unavailable":              "The destination account cannot receive money",
+		"error.pending_approval":                "The transfer is above the limit and awaits approval by the bank",
+		"error.transfer_not_found":              "Pending transfer not found",
+		"error.transfer_not_pending":            "The transfer no longer awaits approval",
+		"error.transfer_expired":                "The transfer has expired",
+		"error.pin_length":                      "The PIN does not have the required length",
+		"error.pin_not_numeric":                 "The PIN may only contain digits",
+		"error.pin_sequence":                    "The PIN may not be a run of consecutive digits",
+		"error.pin_repeated":                    "The PIN may not repeat the same digits",
+		"error.pin_date":                        "The PIN may not be a date, such as a birthdate",
+		"error.pin_reused":                      "The PIN was used before, please choose another one",
+		"error.target_not_found":                "The destination account ID is not registered",
+		"error.insufficient_balance":            "Insufficient balance",
+		"error.invalid_amount":                  "Invalid amount",
+		"error.same_account":                    "You cannot transfer to your own account",
+		"error.no_history":                      "There are no transactions in this category",
+		"error.currency_mismatch":               "The destination account is in another currency, the transfer needs a conversion",
+		"error.quote_mismatch":                  "The rate does not match the currencies of both accounts",
+		"error.unknown_currency":                "Unknown currency",
+		"error.no_rate":                         "No exchange rate is available for this currency",
+		"error.unknown_bank":                    "Unknown bank code",
+		"error.same_bank":                       "The destination bank is this bank, use a regular transfer",
+		"error.beneficiary_not_found":           "The destination account was not found at the destination bank",
+		"error.foreign_currency":                "Interbank clearing only serves IDR accounts",
+		"error.host_unavailable":                "The host cannot be reached",
+		"error.not_cached":                      "The account is not available in offline mode",
+		"error.offline_limit":                   "The amount exceeds the offline withdrawal limit",
+		"error.offline_limit_left":              "The amount exceeds the offline withdrawal limit, %.2f left",
+		"error.service_offline":                 "This service is unavailable while the terminal is offline",
+		"error.session_timeout":                 "The session ended because of inactivity",
+		"error.interbank_not_supported_by_host": "Interbank transfers are not supported through the host yet",
+		"error.fx_not_supported_by_host":        "Transfers between currencies are not supported through the host yet",
+		"error.hold_not_found":                  "The hold was not found",
+		"error.hold_not_active":                 "The funds are no longer on hold",
+		"error.hold_expired":                    "The hold has expired",
+		"error.capture_exceeds_hold":            "The amount exceeds the funds on hold",
+		"error.original_not_found":              "The original transaction was not found",
+		"error.already_reversed":                "The transaction was already reversed",
+		"error.duplicate_reference":             "The transaction reference is already used",
+		"error.no_clearing":                     "Interbank clearing is not available to process this transfer",
+		"error.standing_order_not_found":        "Standing order not found",
+		"error.standing_order_day":              "The day of a standing order must be 1 to 31",
+		"error.reason_required":                 "A reason is required",
+		"error.account_active":                  "The account is already active",
+		"error.account_unlocked":                "The account is not locked",
+		"error.invalid_adjustment":              "Invalid adjustment amount",
+		"error.balance_negative":                "The adjustment would make the balance negative",
+		"error.unknown_role":                    "Unknown role",
+		"error.not_permitted":                   "The role is not permitted to perform this action",
+		"error.out_of_scope":                    "This action is not available in this session",
+		"error.self_approval":                   "A request may not be approved by its own maker",
+	},
+}
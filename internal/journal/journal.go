@@ -0,0 +1,364 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types written to the electronic journal
+const (
+	EventTerminalUp   = "TERMINAL_UP"
+	EventTerminalDown = "TERMINAL_DOWN"
+	EventCardIn       = "CARD_IN"
+	EventCardOut      = "CARD_OUT"
+	EventPINOK        = "PIN_OK"
+	EventPINFail      = "PIN_FAIL"
+	EventMenu         = "MENU"
+	EventDeposit      = "DEPOSIT"
+	EventDispense     = "DISPENSE"
+	EventDispenseFail = "DISPENSE_FAIL"
+	EventTransfer     = "TRANSFER"
+	EventReceipt      = "RECEIPT"
+	EventTimeout      = "SESSION_TIMEOUT"
+	EventStandIn      = "STANDIN_APPROVED"
+	EventReplenish    = "CASH_REPLENISH"
+	EventError        = "ERROR"
+)
+
+// currentFile is the name of the journal file that is currently being written
+const currentFile = "journal.log"
+
+// DefaultMaxSize is the size in bytes after which the journal file is rotated
+const DefaultMaxSize = 1 << 20
+
+// genesisHash is the previous hash of the very first entry in a journal
+var genesisHash = strings.Repeat("0", 64)
+
+// Entry is a single record in the electronic journal
+type Entry struct {
+	Seq       int64  `json:"seq"`
+	Time      string `json:"time"`
+	Terminal  string `json:"terminal"`
+	Session   string `json:"session,omitempty"`
+	AccountID int    `json:"account_id,omitempty"`
+	Event     string `json:"event"`
+	Detail    string `json:"detail,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// computeHash returns the hash of the entry chained to its previous hash
+func (e Entry) computeHash() string {
+	data := strings.Join([]string{
+		strconv.FormatInt(e.Seq, 10),
+		e.Time,
+		e.Terminal,
+		e.Session,
+		strconv.Itoa(e.AccountID),
+		e.Event,
+		e.Detail,
+		e.PrevHash,
+	}, "|")
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// Journal is an append-only, hash chained log of terminal activity
+type Journal struct {
+	mu       sync.Mutex
+	dir      string
+	terminal string
+	maxSize  int64
+	file     *os.File
+	size     int64
+	seq      int64
+	lastHash string
+}
+
+// Open opens (or creates) the journal in the given directory
+// The sequence number and hash chain continue from the last written entry
+func Open(dir, terminal string, maxSize int64) (*Journal, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{dir: dir, terminal: terminal, maxSize: maxSize, lastHash: genesisHash}
+
+	// Resume the chain from the last entry of the newest journal file
+	files, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		last, err := lastEntry(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			j.seq = last.Seq
+			j.lastHash = last.Hash
+			break
+		}
+	}
+
+	if err := j.openCurrent(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// openCurrent opens the current journal file for appending
+func (j *Journal) openCurrent() error {
+	f, err := os.OpenFile(filepath.Join(j.dir, currentFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.file = f
+	j.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file and renames it after its last sequence number
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	rotated := filepath.Join(j.dir, fmt.Sprintf("journal-%010d.log", j.seq))
+	if err := os.Rename(filepath.Join(j.dir, currentFile), rotated); err != nil {
+		return err
+	}
+	return j.openCurrent()
+}
+
+// Write appends a new entry to the journal
+func (j *Journal) Write(session string, accountID int, event, detail string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("journal sudah ditutup")
+	}
+
+	entry := Entry{
+		Seq:       j.seq + 1,
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Terminal:  j.terminal,
+		Session:   session,
+		AccountID: accountID,
+		Event:     event,
+		Detail:    detail,
+		PrevHash:  j.lastHash,
+	}
+	entry.Hash = entry.computeHash()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// Rotate before writing if the entry would exceed the maximum file size
+	if j.size > 0 && j.size+int64(len(line)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	if err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+
+	j.size += int64(n)
+	j.seq = entry.Seq
+	j.lastHash = entry.Hash
+	return nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// journalFiles lists the journal files in the directory, oldest first
+func journalFiles(dir string) ([]string, error) {
+	rotated, err := filepath.Glob(filepath.Join(dir, "journal-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	// Rotated files are named after zero-padded sequence numbers, so they sort by age
+	sort.Strings(rotated)
+
+	current := filepath.Join(dir, currentFile)
+	if _, err := os.Stat(current); err == nil {
+		rotated = append(rotated, current)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// readEntries calls fn for each entry in the file together with its line number
+func readEntries(path string, fn func(line int, e Entry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("%s:%d: entri tidak dapat dibaca: %v", path, line, err)
+		}
+		if err := fn(line, e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// lastEntry returns the last entry of a journal file, or nil when it is empty
+func lastEntry(path string) (*Entry, error) {
+	var last *Entry
+	err := readEntries(path, func(_ int, e Entry) error {
+		last = &e
+		return nil
+	})
+	return last, err
+}
+
+// VerifyResult summarises a successful journal verification
+type VerifyResult struct {
+	Files   int
+	Entries int64
+}
+
+// Verify walks every journal file in order and checks the sequence numbers and hash chain
+// It returns an error describing the first entry that was modified, removed or reordered
+func Verify(dir string) (*VerifyResult, error) {
+	files, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{Files: len(files)}
+	prevHash := genesisHash
+	var prevSeq int64
+	for _, path := range files {
+		err := readEntries(path, func(line int, e Entry) error {
+			if e.Seq != prevSeq+1 {
+				return fmt.Errorf("%s:%d: nomor urut %d, seharusnya %d", path, line, e.Seq, prevSeq+1)
+			}
+			if e.PrevHash != prevHash {
+				return fmt.Errorf("%s:%d: rantai hash terputus pada entri %d", path, line, e.Seq)
+			}
+			if e.computeHash() != e.Hash {
+				return fmt.Errorf("%s:%d: isi entri %d telah diubah", path, line, e.Seq)
+			}
+			prevSeq = e.Seq
+			prevHash = e.Hash
+			result.Entries++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Filter selects journal entries during a search
+// Zero values match everything
+type Filter struct {
+	Event     string
+	AccountID int
+	Session   string
+	Text      string
+	From      time.Time
+	To        time.Time
+}
+
+// match reports whether the entry satisfies the filter
+func (f Filter) match(e Entry) bool {
+	if f.Event != "" && !strings.EqualFold(f.Event, e.Event) {
+		return false
+	}
+	if f.AccountID != 0 && f.AccountID != e.AccountID {
+		return false
+	}
+	if f.Session != "" && f.Session != e.Session {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(e.Detail), strings.ToLower(f.Text)) {
+		return false
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil {
+			return false
+		}
+		if !f.From.IsZero() && t.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && t.After(f.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns all journal entries matching the filter, oldest first
+func Search(dir string, filter Filter) ([]Entry, error) {
+	files, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, path := range files {
+		err := readEntries(path, func(_ int, e Entry) error {
+			if filter.match(e) {
+				entries = append(entries, e)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
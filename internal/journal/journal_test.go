@@ -0,0 +1,184 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeJournal writes count entries to a fresh journal that rotates after maxSize bytes
+func writeJournal(t *testing.T, count int, maxSize int64) string {
+	t.Helper()
+	dir := t.TempDir()
+	j, err := Open(dir, "ATM-001", maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= count; i++ {
+		if err := j.Write("s1", 12, EventMenu, fmt.Sprintf("entry %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// editFile rewrites every entry of a journal file through fn, dropping it when fn returns false
+func editFile(t *testing.T, path string, fn func(e *Entry) bool) {
+	t.Helper()
+	var lines []string
+	err := readEntries(path, func(_ int, e Entry) error {
+		if !fn(&e) {
+			return nil
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, string(line)+"\n")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		count   int
+		files   int
+	}{
+		{"single file", DefaultMaxSize, 5, 1},
+		{"rotated after every two entries", 600, 6, 3},
+		{"rotated after every entry", 1, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeJournal(t, tt.count, tt.maxSize)
+			result, err := Verify(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Files != tt.files || result.Entries != int64(tt.count) {
+				t.Errorf("Verify = %d files, %d entries, want %d files, %d entries",
+					result.Files, result.Entries, tt.files, tt.count)
+			}
+		})
+	}
+}
+
+func TestVerifyAfterReopen(t *testing.T) {
+	dir := writeJournal(t, 3, 600)
+	j, err := Open(dir, "ATM-001", 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Write("s2", 12, EventCardOut, ""); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	result, err := Verify(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Entries != 4 {
+		t.Errorf("Verify = %d entries, want 4", result.Entries)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, dir string, files []string)
+		want   string
+	}{
+		{
+			"detail changed",
+			func(t *testing.T, dir string, files []string) {
+				editFile(t, files[0], func(e *Entry) bool {
+					if e.Seq == 2 {
+						e.Detail = "entry 9"
+					}
+					return true
+				})
+			},
+			"isi entri 2 telah diubah",
+		},
+		{
+			"detail changed and hash recomputed",
+			func(t *testing.T, dir string, files []string) {
+				editFile(t, files[0], func(e *Entry) bool {
+					if e.Seq == 2 {
+						e.Detail = "entry 9"
+						e.Hash = e.computeHash()
+					}
+					return true
+				})
+			},
+			"rantai hash terputus pada entri 3",
+		},
+		{
+			"entry removed",
+			func(t *testing.T, dir string, files []string) {
+				editFile(t, files[1], func(e *Entry) bool { return e.Seq != 3 })
+			},
+			"nomor urut 4, seharusnya 3",
+		},
+		{
+			"rotated file removed",
+			func(t *testing.T, dir string, files []string) {
+				if err := os.Remove(files[1]); err != nil {
+					t.Fatal(err)
+				}
+			},
+			"nomor urut 5, seharusnya 3",
+		},
+		{
+			"entries reordered",
+			func(t *testing.T, dir string, files []string) {
+				data, err := os.ReadFile(files[2])
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+				lines[0], lines[1] = lines[1], lines[0]
+				if err := os.WriteFile(files[2], []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			"nomor urut 6, seharusnya 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeJournal(t, 6, 600)
+			files, err := journalFiles(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 3 || filepath.Base(files[2]) != currentFile {
+				t.Fatalf("journal files %v, want two rotated files and %s", files, currentFile)
+			}
+			tt.tamper(t, dir, files)
+
+			_, err = Verify(dir)
+			if err == nil {
+				t.Fatal("Verify succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify error %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
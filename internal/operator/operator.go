@@ -0,0 +1,145 @@
+package operator
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Errors returned by the operator functions
+var (
+	ErrInvalidCredentials = errors.New("username atau password salah")
+	ErrUsernameTaken      = errors.New("username operator sudah terdaftar")
+	ErrUnknownRole        = errors.New("peran operator tidak dikenal")
+	ErrPasswordTooShort   = errors.New("password operator minimal 8 karakter")
+	ErrLoginRequired      = errors.New("operator sudah ada, login sebagai supervisor untuk menambah operator")
+	ErrFirstSupervisor    = errors.New("operator pertama harus berperan supervisor")
+)
+
+// MinPasswordLength is the minimum length of an operator password
+const MinPasswordLength = 8
+
+// Roles returns the roles an operator can have
+func Roles() []string {
+	var roles []string
+	for _, role := range authz.StaffRoles() {
+		roles = append(roles, string(role))
+	}
+	return roles
+}
+
+// Operator is a member of the bank staff allowed into the admin console
+type Operator struct {
+	ID           int    `db:"id"`
+	Username     string `db:"username"`
+	PasswordHash string `db:"password_hash"`
+	Role         string `db:"role"`
+	CreatedAt    string `db:"created_at"`
+}
+
+// Principal returns the operator as seen by the authorization layer
+func (o *Operator) Principal() authz.Principal {
+	return authz.Principal{Name: o.Username, Role: authz.Role(o.Role)}
+}
+
+// Authorize returns a *authz.DeniedError when the role of the operator does not include the permission
+func (o *Operator) Authorize(perm authz.Permission) error {
+	return authz.Authorize(o.Principal(), perm)
+}
+
+// Count returns the number of operators
+func Count() (int, error) {
+	var count int
+	err := db.DB.Get(&count, "SELECT COUNT(*) FROM operators")
+	return count, err
+}
+
+// Create adds an operator with the role, storing only a bcrypt hash of the password
+// The creator has to be allowed to manage operators; only the first operator, who has to be a supervisor,
+// is created without one
+func Create(username, password, role string, creator *authz.Principal, source string) (*Operator, error) {
+	actor := username
+	if creator != nil {
+		actor = creator.Name
+		if err := authz.Authorize(*creator, authz.PermOperators); err != nil {
+			audit.RecordOrLog(actor, audit.ActionOperatorCreate, 0, audit.OutcomeFailure, source, username+": "+err.Error())
+			return nil, err
+		}
+	}
+	if !authz.IsStaffRole(authz.Role(role)) {
+		return nil, ErrUnknownRole
+	}
+	if len(password) < MinPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+
+	total, err := Count()
+	if err != nil {
+		return nil, err
+	}
+	if creator == nil {
+		if total > 0 {
+			audit.RecordOrLog(actor, audit.ActionOperatorCreate, 0, audit.OutcomeFailure, source, ErrLoginRequired.Error())
+			return nil, ErrLoginRequired
+		}
+		if authz.Role(role) != authz.RoleSupervisor {
+			return nil, ErrFirstSupervisor
+		}
+	}
+
+	var count int
+	if err := db.DB.Get(&count, "SELECT COUNT(*) FROM operators WHERE username = ?", username); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUsernameTaken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	op := &Operator{Username: username, PasswordHash: string(hash), Role: role}
+	result, err := db.DB.NamedExec(`INSERT INTO operators (username, password_hash, role) VALUES (:username, :password_hash, :role)`, op)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	op.ID = int(id)
+	audit.RecordOrLog(actor, audit.ActionOperatorCreate, 0, audit.OutcomeSuccess, source, username+", peran "+role)
+	return op, nil
+}
+
+// Login authenticates an operator by username and password
+// Unknown usernames and wrong passwords return the same error
+func Login(username, password, source string) (*Operator, error) {
+	op := &Operator{}
+	err := db.DB.Get(op, "SELECT * FROM operators WHERE username = ?", username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			audit.RecordOrLog(username, audit.ActionOperatorLogin, 0, audit.OutcomeFailure, source, "operator tidak ditemukan")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(op.PasswordHash), []byte(password)); err != nil {
+		audit.RecordOrLog(username, audit.ActionOperatorLogin, 0, audit.OutcomeFailure, source, "password salah")
+		return nil, ErrInvalidCredentials
+	}
+	audit.RecordOrLog(username, audit.ActionOperatorLogin, 0, audit.OutcomeSuccess, source, "peran "+op.Role)
+	return op, nil
+}
+
+// List returns every operator ordered by username
+func List() ([]Operator, error) {
+	var operators []Operator
+	err := db.DB.Select(&operators, "SELECT * FROM operators ORDER BY username")
+	return operators, err
+}
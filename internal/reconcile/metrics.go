@@ -0,0 +1,74 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteMetrics writes the result in the Prometheus text format
+func (r *Result) WriteMetrics(w io.Writer) error {
+	metrics := []struct {
+		name, help, kind string
+		samples          []string
+	}{
+		{"atm_reconcile_last_run_timestamp_seconds", "Waktu mulai rekonsiliasi terakhir.", "gauge",
+			[]string{strconv.FormatInt(r.Started.Unix(), 10)}},
+		{"atm_reconcile_duration_seconds", "Lama rekonsiliasi terakhir.", "gauge",
+			[]string{strconv.FormatFloat(r.Duration.Seconds(), 'f', 3, 64)}},
+		{"atm_reconcile_accounts_checked", "Jumlah akun yang diperiksa.", "gauge",
+			[]string{strconv.Itoa(r.Accounts)}},
+		{"atm_reconcile_mismatched_accounts", "Jumlah akun yang saldonya berbeda dari transaksinya, per mata uang.", "gauge", nil},
+		{"atm_reconcile_difference_amount", "Jumlah selisih absolut saldo dengan transaksi, per mata uang.", "gauge", nil},
+	}
+	differences := r.Differences()
+	// Without mismatches the series still exist, so alerts on them resolve
+	if len(differences) == 0 {
+		metrics[3].samples = []string{"0"}
+		metrics[4].samples = []string{"0"}
+	}
+	for _, t := range differences {
+		label := fmt.Sprintf(`{currency=%q} `, t.Currency)
+		metrics[3].samples = append(metrics[3].samples, label+strconv.Itoa(t.Accounts))
+		metrics[4].samples = append(metrics[4].samples, label+strconv.FormatFloat(t.Amount, 'f', 2, 64))
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, sample := range m.samples {
+			if sample[0] != '{' {
+				sample = " " + sample
+			}
+			if _, err := fmt.Fprintf(w, "%s%s\n", m.name, sample); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteMetricsFile writes the metrics for the textfile collector of the Prometheus node exporter
+// The file is replaced in one step, so the collector never reads half of it
+func (r *Result) WriteMetricsFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".reconcile-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := r.WriteMetrics(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
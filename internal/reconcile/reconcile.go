@@ -0,0 +1,353 @@
+package reconcile
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned when correcting a balance
+var (
+	ErrNoMismatch      = errors.New("saldo akun sudah sesuai dengan transaksinya")
+	ErrMismatchChanged = errors.New("selisih saldo sudah berubah sejak rekonsiliasi, jalankan rekonsiliasi ulang")
+)
+
+// Actor is the name the reconciliation job uses as maker of the corrections it proposes
+const Actor = "reconcile"
+
+// Reference marks the correcting entries in the transactions table, see CorrectionReference
+const Reference = "rekonsiliasi"
+
+// CorrectionReference is the reference of the correcting entry carried out by the approval request
+// The reference column is unique, so every correction gets one of its own
+func CorrectionReference(approvalID int) string {
+	return fmt.Sprintf("%s #%d", Reference, approvalID)
+}
+
+// tolerance is the smallest difference that counts as a mismatch; balances are kept in cents
+const tolerance = 0.005
+
+// Transaction is a transaction named in a mismatch
+type Transaction struct {
+	ID        int     `db:"id"`
+	Type      string  `db:"type"`
+	Amount    float64 `db:"amount"`
+	CreatedAt string  `db:"created_at"`
+}
+
+// Mismatch is an account whose balance differs from the sum of its transactions
+type Mismatch struct {
+	AccountID  int
+	Name       string
+	Currency   string
+	Balance    float64 // as stored in the accounts table
+	Computed   float64 // sum of the transactions of the account
+	Difference float64 // Balance minus Computed: what the transactions are missing
+	// First is the first transaction since the account last reconciled cleanly, nil when there is none,
+	// meaning that the balance changed without any transaction. Checked tells whether the account
+	// ever reconciled cleanly; when it did not, First is the first transaction of the account
+	First   *Transaction
+	Since   int // number of transactions since the account last reconciled cleanly
+	Checked bool
+	// Confirmed tells whether the previous run found the same difference, so the mismatch is not a
+	// posting caught halfway; only confirmed mismatches are worth a correction
+	Confirmed bool
+}
+
+// Result is the outcome of a reconciliation run
+type Result struct {
+	Started    time.Time
+	Duration   time.Duration
+	Accounts   int
+	Mismatches []Mismatch
+}
+
+// Run recomputes the balance of every account from its transactions and compares it with the stored
+// balance, both read from one snapshot of the database. A posting only shows up as a mismatch when it
+// did not update the balance and write its transaction in the same database transaction, so a mismatch
+// is only marked confirmed once two runs in a row found it. Accounts that agree get a checkpoint, which
+// later runs use to find the first transaction after which an account went wrong
+func Run() (*Result, error) {
+	result := &Result{Started: time.Now()}
+
+	tx, err := db.DB.BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var accounts []account
+	if err := tx.Select(&accounts, "SELECT id, name, currency, COALESCE(balance, 0) AS balance FROM accounts ORDER BY id"); err != nil {
+		return nil, err
+	}
+	var sums []typeTotal
+	err = tx.Select(&sums, `SELECT account_id, type, SUM(amount) AS amount, MAX(id) AS last_id FROM transactions
+		WHERE account_id IS NOT NULL GROUP BY account_id, type`)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []struct {
+		AccountID     int `db:"account_id"`
+		TransactionID int `db:"transaction_id"`
+	}
+	if err := tx.Select(&checkpoints, "SELECT account_id, transaction_id FROM reconciliation_checkpoints"); err != nil {
+		return nil, err
+	}
+	var previous []struct {
+		AccountID  int     `db:"account_id"`
+		Difference float64 `db:"difference"`
+	}
+	if err := tx.Select(&previous, "SELECT account_id, difference FROM reconciliation_mismatches"); err != nil {
+		return nil, err
+	}
+
+	checked := make(map[int]int)
+	for _, c := range checkpoints {
+		checked[c.AccountID] = c.TransactionID
+	}
+	seen := make(map[int]float64)
+	for _, p := range previous {
+		seen[p.AccountID] = p.Difference
+	}
+
+	result.Accounts = len(accounts)
+	mismatches, clean := compare(accounts, sums, checked, seen)
+	for i := range mismatches {
+		if err := firstSince(tx, &mismatches[i], checked[mismatches[i].AccountID]); err != nil {
+			return nil, err
+		}
+	}
+	result.Mismatches = mismatches
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := saveCheckpoints(clean); err != nil {
+		return nil, err
+	}
+	if err := saveMismatches(result.Mismatches); err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(result.Started)
+	return result, nil
+}
+
+// account is the stored balance of an account as Run reads it
+type account struct {
+	ID       int     `db:"id"`
+	Name     string  `db:"name"`
+	Currency string  `db:"currency"`
+	Balance  float64 `db:"balance"`
+}
+
+// typeTotal is the sum of the transactions of one type of an account, and the last of their IDs
+type typeTotal struct {
+	AccountID int     `db:"account_id"`
+	Type      string  `db:"type"`
+	Amount    float64 `db:"amount"`
+	LastID    int     `db:"last_id"`
+}
+
+// compare checks the stored balances against the transaction totals, given the last checkpoint of every
+// account (checked) and the differences found by the previous run (seen). It returns the mismatches and
+// the checkpoints of the accounts that agree; the first transaction of a mismatch is left to Run
+func compare(accounts []account, sums []typeTotal, checked map[int]int, seen map[int]float64) ([]Mismatch, []checkpoint) {
+	computed := make(map[int]float64)
+	lastIDs := make(map[int]int)
+	for _, s := range sums {
+		computed[s.AccountID] += transaction.SignedAmount(s.Type, s.Amount)
+		lastIDs[s.AccountID] = max(lastIDs[s.AccountID], s.LastID)
+	}
+
+	var mismatches []Mismatch
+	var clean []checkpoint
+	for _, a := range accounts {
+		sum := math.Round(computed[a.ID]*100) / 100
+		if math.Abs(a.Balance-sum) < tolerance {
+			if at, ok := checked[a.ID]; !ok || at != lastIDs[a.ID] {
+				clean = append(clean, checkpoint{a.ID, lastIDs[a.ID], a.Balance})
+			}
+			continue
+		}
+
+		m := Mismatch{AccountID: a.ID, Name: a.Name, Currency: a.Currency, Balance: a.Balance, Computed: sum,
+			Difference: math.Round((a.Balance-sum)*100) / 100}
+		_, m.Checked = checked[a.ID]
+		if difference, ok := seen[a.ID]; ok && math.Abs(difference-m.Difference) < tolerance {
+			m.Confirmed = true
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches, clean
+}
+
+// firstSince fills in the first transaction of the account after the one of its checkpoint
+func firstSince(q sqlx.Queryer, m *Mismatch, after int) error {
+	if err := sqlx.Get(q, &m.Since, "SELECT COUNT(*) FROM transactions WHERE account_id = ? AND id > ?", m.AccountID, after); err != nil {
+		return err
+	}
+	if m.Since == 0 {
+		return nil
+	}
+	m.First = &Transaction{}
+	return sqlx.Get(q, m.First, `SELECT id, type, amount, created_at FROM transactions
+		WHERE account_id = ? AND id > ? ORDER BY id LIMIT 1`, m.AccountID, after)
+}
+
+// checkpoint is the last transaction of an account at a run in which its balance agreed with its transactions
+type checkpoint struct {
+	AccountID     int
+	TransactionID int
+	Balance       float64
+}
+
+// saveCheckpoints records the accounts that reconciled cleanly
+func saveCheckpoints(checkpoints []checkpoint) error {
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, c := range checkpoints {
+		_, err := tx.Exec(`INSERT INTO reconciliation_checkpoints (account_id, transaction_id, balance) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE transaction_id = VALUES(transaction_id), balance = VALUES(balance), checked_at = CURRENT_TIMESTAMP`,
+			c.AccountID, c.TransactionID, c.Balance)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// saveMismatches replaces the mismatches remembered from the previous run with those of this run
+func saveMismatches(mismatches []Mismatch) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM reconciliation_mismatches"); err != nil {
+		return err
+	}
+	for _, m := range mismatches {
+		if _, err := tx.Exec("INSERT INTO reconciliation_mismatches (account_id, difference) VALUES (?, ?)", m.AccountID, m.Difference); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Correct writes the correcting entry of a mismatch, approved with the given approval request: an adjustment
+// transaction of the difference, so the transactions add up to the stored balance again. The balance itself
+// is left alone, it is what the customer was shown and spent from. The difference is checked again first,
+// and the correction is refused when it no longer matches the amount that was approved
+func Correct(approvalID, accountID int, amount float64, reason, actor, source string) error {
+	if strings.TrimSpace(reason) == "" {
+		return user.ErrReasonRequired
+	}
+	if math.Abs(amount) < tolerance {
+		return user.ErrInvalidAmount
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the account keeps its balance and transactions still while the difference is checked
+	var balance float64
+	err = tx.Get(&balance, "SELECT COALESCE(balance, 0) FROM accounts WHERE id = ? FOR UPDATE", accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var sums []typeTotal
+	if err := tx.Select(&sums, "SELECT account_id, type, SUM(amount) AS amount, MAX(id) AS last_id FROM transactions WHERE account_id = ? GROUP BY account_id, type", accountID); err != nil {
+		return err
+	}
+	kind, value, err := correction(balance, sums, amount)
+	if errors.Is(err, ErrNoMismatch) {
+		audit.RecordOrLog(actor, audit.ActionReconcile, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	if errors.Is(err, ErrMismatchChanged) {
+		audit.RecordOrLog(actor, audit.ActionReconcile, accountID, audit.OutcomeFailure, source, fmt.Sprintf("%+.2f: %v", amount, err))
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, ?, ?, ?, "+businessday.SQL+")",
+		accountID, kind, value, CorrectionReference(approvalID))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.RecordOrLog(actor, audit.ActionReconcile, accountID, audit.OutcomeSuccess, source, fmt.Sprintf("%+.2f: %s", amount, reason))
+	return nil
+}
+
+// correction returns the type and amount of the entry that makes the transactions of an account add up to
+// its balance, as long as the difference still is the approved amount
+func correction(balance float64, sums []typeTotal, amount float64) (string, float64, error) {
+	var sum float64
+	for _, s := range sums {
+		sum += transaction.SignedAmount(s.Type, s.Amount)
+	}
+	difference := balance - math.Round(sum*100)/100
+	if math.Abs(difference) < tolerance {
+		return "", 0, ErrNoMismatch
+	}
+	if math.Abs(difference-amount) >= tolerance {
+		return "", 0, ErrMismatchChanged
+	}
+	if amount < 0 {
+		return "adjustment_debit", -amount, nil
+	}
+	return "adjustment_credit", amount, nil
+}
+
+// Differences sums the absolute differences of the mismatches per currency, sorted by currency
+func (r *Result) Differences() []Total {
+	totals := make(map[string]*Total)
+	for _, m := range r.Mismatches {
+		t, ok := totals[m.Currency]
+		if !ok {
+			t = &Total{Currency: m.Currency}
+			totals[m.Currency] = t
+		}
+		t.Accounts++
+		t.Amount += math.Abs(m.Difference)
+	}
+	var list []Total
+	for _, t := range totals {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+	return list
+}
+
+// Total is the number of mismatched accounts in a currency and the sum of their absolute differences
+type Total struct {
+	Currency string
+	Accounts int
+	Amount   float64
+}
@@ -0,0 +1,182 @@
+package reconcile
+
+import (
+	"errors"
+	"testing"
+)
+
+// differences returns the differences found by a run, keyed by account, as saveMismatches keeps them
+func differences(mismatches []Mismatch) map[int]float64 {
+	seen := make(map[int]float64)
+	for _, m := range mismatches {
+		seen[m.AccountID] = m.Difference
+	}
+	return seen
+}
+
+func TestCompare(t *testing.T) {
+	accounts := []account{
+		{ID: 1, Name: "budi", Currency: "IDR", Balance: 150000},
+		{ID: 2, Name: "ani", Currency: "IDR", Balance: 90000},
+		{ID: 3, Name: "citra", Currency: "USD", Balance: 0},
+	}
+	sums := []typeTotal{
+		{AccountID: 1, Type: "deposit", Amount: 200000, LastID: 4},
+		{AccountID: 1, Type: "withdraw", Amount: 50000, LastID: 7},
+		{AccountID: 2, Type: "deposit", Amount: 100000, LastID: 5},
+	}
+
+	tests := []struct {
+		name       string
+		checked    map[int]int
+		seen       map[int]float64
+		mismatches []Mismatch
+		clean      []checkpoint
+	}{
+		{
+			"first run",
+			nil,
+			nil,
+			[]Mismatch{{AccountID: 2, Name: "ani", Currency: "IDR", Balance: 90000, Computed: 100000, Difference: -10000}},
+			[]checkpoint{{1, 7, 150000}, {3, 0, 0}},
+		},
+		{
+			"same difference in the previous run",
+			map[int]int{1: 7, 2: 3, 3: 0},
+			map[int]float64{2: -10000},
+			[]Mismatch{{AccountID: 2, Name: "ani", Currency: "IDR", Balance: 90000, Computed: 100000, Difference: -10000, Checked: true, Confirmed: true}},
+			nil,
+		},
+		{
+			"other difference in the previous run",
+			map[int]int{1: 4},
+			map[int]float64{2: -5000},
+			[]Mismatch{{AccountID: 2, Name: "ani", Currency: "IDR", Balance: 90000, Computed: 100000, Difference: -10000}},
+			[]checkpoint{{1, 7, 150000}, {3, 0, 0}},
+		},
+		{
+			"previous mismatch of an account that agrees now",
+			nil,
+			map[int]float64{1: 25000, 2: -10000},
+			[]Mismatch{{AccountID: 2, Name: "ani", Currency: "IDR", Balance: 90000, Computed: 100000, Difference: -10000, Confirmed: true}},
+			[]checkpoint{{1, 7, 150000}, {3, 0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches, clean := compare(accounts, sums, tt.checked, tt.seen)
+			if len(mismatches) != len(tt.mismatches) {
+				t.Fatalf("%d mismatches, want %d: %+v", len(mismatches), len(tt.mismatches), mismatches)
+			}
+			for i, m := range mismatches {
+				if m != tt.mismatches[i] {
+					t.Errorf("mismatch %+v, want %+v", m, tt.mismatches[i])
+				}
+			}
+			if len(clean) != len(tt.clean) {
+				t.Fatalf("%d checkpoints, want %d: %+v", len(clean), len(tt.clean), clean)
+			}
+			for i, c := range clean {
+				if c != tt.clean[i] {
+					t.Errorf("checkpoint %+v, want %+v", c, tt.clean[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMismatchConfirmedBySecondRun(t *testing.T) {
+	accounts := []account{{ID: 1, Currency: "IDR", Balance: 90000}}
+	sums := []typeTotal{{AccountID: 1, Type: "deposit", Amount: 100000, LastID: 1}}
+
+	first, _ := compare(accounts, sums, nil, nil)
+	if len(first) != 1 || first[0].Confirmed {
+		t.Fatalf("first run: %+v, want one unconfirmed mismatch", first)
+	}
+	second, _ := compare(accounts, sums, nil, differences(first))
+	if len(second) != 1 || !second[0].Confirmed {
+		t.Fatalf("second run: %+v, want one confirmed mismatch", second)
+	}
+
+	// A posting caught halfway changes the difference, and the mismatch starts over
+	sums = append(sums, typeTotal{AccountID: 1, Type: "withdraw", Amount: 5000, LastID: 2})
+	third, _ := compare(accounts, sums, nil, differences(second))
+	if len(third) != 1 || third[0].Confirmed {
+		t.Fatalf("third run: %+v, want one unconfirmed mismatch", third)
+	}
+}
+
+func TestCorrection(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance float64
+		sums    []typeTotal
+		amount  float64
+		kind    string
+		value   float64
+		err     error
+	}{
+		{"balance above the transactions", 110000, []typeTotal{{Type: "deposit", Amount: 100000}}, 10000, "adjustment_credit", 10000, nil},
+		{"balance below the transactions", 90000, []typeTotal{{Type: "deposit", Amount: 100000}}, -10000, "adjustment_debit", 10000, nil},
+		{"debits count against the balance", 40000, []typeTotal{{Type: "deposit", Amount: 100000}, {Type: "withdraw", Amount: 50000}}, -10000, "adjustment_debit", 10000, nil},
+		{"cents", 100000.25, []typeTotal{{Type: "deposit", Amount: 100000}}, 0.25, "adjustment_credit", 0.25, nil},
+		{"difference gone", 100000, []typeTotal{{Type: "deposit", Amount: 100000}}, 10000, "", 0, ErrNoMismatch},
+		{"difference changed", 105000, []typeTotal{{Type: "deposit", Amount: 100000}}, 10000, "", 0, ErrMismatchChanged},
+		{"difference changed sign", 90000, []typeTotal{{Type: "deposit", Amount: 100000}}, 10000, "", 0, ErrMismatchChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, value, err := correction(tt.balance, tt.sums, tt.amount)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("correction = %v, want %v", err, tt.err)
+			}
+			if kind != tt.kind || value != tt.value {
+				t.Errorf("correction = %s %.2f, want %s %.2f", kind, value, tt.kind, tt.value)
+			}
+		})
+	}
+}
+
+func TestTwoCorrections(t *testing.T) {
+	accounts := []account{
+		{ID: 1, Currency: "IDR", Balance: 110000},
+		{ID: 2, Currency: "IDR", Balance: 90000},
+	}
+	sums := []typeTotal{
+		{AccountID: 1, Type: "deposit", Amount: 100000, LastID: 1},
+		{AccountID: 2, Type: "deposit", Amount: 100000, LastID: 2},
+	}
+	mismatches, _ := compare(accounts, sums, nil, nil)
+	if len(mismatches) != 2 {
+		t.Fatalf("%d mismatches, want 2", len(mismatches))
+	}
+
+	// Each mismatch is approved with its own request and corrected with an entry of its own reference
+	references := make(map[string]bool)
+	for i, m := range mismatches {
+		approvalID := 40 + i
+		var account []typeTotal
+		for _, s := range sums {
+			if s.AccountID == m.AccountID {
+				account = append(account, s)
+			}
+		}
+		kind, value, err := correction(m.Balance, account, m.Difference)
+		if err != nil {
+			t.Fatalf("account %d: %v", m.AccountID, err)
+		}
+		reference := CorrectionReference(approvalID)
+		if references[reference] {
+			t.Errorf("account %d: reference %q is already used by another correction", m.AccountID, reference)
+		}
+		references[reference] = true
+		sums = append(sums, typeTotal{AccountID: m.AccountID, Type: kind, Amount: value, LastID: 10 + i})
+	}
+
+	if mismatches, _ := compare(accounts, sums, nil, nil); len(mismatches) != 0 {
+		t.Errorf("mismatches after the corrections: %+v", mismatches)
+	}
+	if got := CorrectionReference(41); got != "rekonsiliasi #41" {
+		t.Errorf("CorrectionReference(41) = %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	atmv1 "atm-simulation/pkg/atmpb/atm/v1"
+	"atm-simulation/pkg/currency"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without a session token
+var publicMethods = map[string]bool{
+	atmv1.BankingService_Register_FullMethodName: true,
+	atmv1.BankingService_Login_FullMethodName:    true,
+}
+
+// methodPermissions lists the session permission required by each protected method
+var methodPermissions = map[string]session.Permission{
+	atmv1.BankingService_GetAccount_FullMethodName:     session.PermProfile,
+	atmv1.BankingService_GetBalance_FullMethodName:     session.PermBalance,
+	atmv1.BankingService_Deposit_FullMethodName:        session.PermDeposit,
+	atmv1.BankingService_Withdraw_FullMethodName:       session.PermWithdraw,
+	atmv1.BankingService_Transfer_FullMethodName:       session.PermTransfer,
+	atmv1.BankingService_CancelTransfer_FullMethodName: session.PermTransfer,
+	atmv1.BankingService_StreamHistory_FullMethodName:  session.PermHistory,
+}
+
+// sessionKey is the context key of the authenticated session
+type sessionKey struct{}
+
+// sessionFrom returns the session stored in the context by the auth interceptor
+func sessionFrom(ctx context.Context) *session.Session {
+	sess, _ := ctx.Value(sessionKey{}).(*session.Session)
+	return sess
+}
+
+// UnaryInterceptors returns the interceptor chain for unary calls: logging, error mapping, auth
+func (s *Server) UnaryInterceptors() grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(logUnary, mapErrorsUnary, s.authUnary)
+}
+
+// StreamInterceptors returns the interceptor chain for streaming calls: logging, error mapping, auth
+func (s *Server) StreamInterceptors() grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(logStream, mapErrorsStream, s.authStream)
+}
+
+// authenticate resolves the session named by the bearer token in the request metadata
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "token sesi tidak ada")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "token sesi tidak valid")
+	}
+
+	sess, err := s.sessions.Get(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if perm, ok := methodPermissions[method]; ok {
+		if err := sess.Authorize(perm); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	sess.Touch()
+	return context.WithValue(ctx, sessionKey{}, sess), nil
+}
+
+// authUnary authenticates unary calls
+func (s *Server) authUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticatedStream carries the context holding the session into the stream handler
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (a authenticatedStream) Context() context.Context {
+	return a.ctx
+}
+
+// authStream authenticates streaming calls
+func (s *Server) authStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// toStatus maps a domain error onto a gRPC status error
+// Errors that already carry a status are returned unchanged
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, user.ErrAccountLocked), errors.Is(err, user.ErrAccountFrozen), errors.Is(err, user.ErrAccountDormant),
+		errors.Is(err, user.ErrAccountClosed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, user.ErrWrongPIN), errors.Is(err, user.ErrAccountNotFound):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, user.ErrNameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, transaction.ErrAccountNotFound), errors.Is(err, transaction.ErrTargetNotFound),
+		errors.Is(err, transaction.ErrTransferNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, transaction.ErrInvalidAmount), errors.Is(err, transaction.ErrSameAccount),
+		errors.Is(err, currency.ErrUnknownCurrency), user.IsPINPolicyError(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, transaction.ErrInsufficientBalance), errors.Is(err, transaction.ErrCurrencyMismatch),
+		errors.Is(err, transaction.ErrTargetUnavailable), errors.Is(err, transaction.ErrTransferNotPending),
+		errors.Is(err, transaction.ErrTransferExpired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		log.Println("grpc:", err)
+		return status.Error(codes.Internal, "terjadi kesalahan pada server")
+	}
+}
+
+// mapErrorsUnary converts domain errors of unary calls into gRPC status codes
+func mapErrorsUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(err)
+}
+
+// mapErrorsStream converts domain errors of streaming calls into gRPC status codes
+func mapErrorsStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(handler(srv, ss))
+}
+
+// clientAddr returns the address of the caller for the logs and the audit trail
+func clientAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// logUnary logs every unary call with its duration and status code
+func logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("grpc %s %s %s %s", clientAddr(ctx), info.FullMethod, status.Code(err), time.Since(start))
+	return resp, err
+}
+
+// logStream logs every streaming call with its duration and status code
+func logStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("grpc %s %s %s %s", clientAddr(ss.Context()), info.FullMethod, status.Code(err), time.Since(start))
+	return err
+}
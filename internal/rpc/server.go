@@ -0,0 +1,268 @@
+package rpc
+
+import (
+	"atm-simulation/internal/session"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	atmv1 "atm-simulation/pkg/atmpb/atm/v1"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// historyTypes maps the protobuf transaction types onto the values stored in the transactions table
+var historyTypes = map[atmv1.TransactionType]string{
+	atmv1.TransactionType_TRANSACTION_TYPE_UNSPECIFIED:       "all",
+	atmv1.TransactionType_TRANSACTION_TYPE_DEPOSIT:           "deposit",
+	atmv1.TransactionType_TRANSACTION_TYPE_WITHDRAW:          "withdraw",
+	atmv1.TransactionType_TRANSACTION_TYPE_TRANSFER_IN:       "transfer_in",
+	atmv1.TransactionType_TRANSACTION_TYPE_TRANSFER_OUT:      "transfer_out",
+	atmv1.TransactionType_TRANSACTION_TYPE_REVERSAL_IN:       "reversal_in",
+	atmv1.TransactionType_TRANSACTION_TYPE_REVERSAL_OUT:      "reversal_out",
+	atmv1.TransactionType_TRANSACTION_TYPE_INTERBANK_OUT:     "interbank_out",
+	atmv1.TransactionType_TRANSACTION_TYPE_INTERBANK_IN:      "interbank_in",
+	atmv1.TransactionType_TRANSACTION_TYPE_INTERBANK_REFUND:  "interbank_refund",
+	atmv1.TransactionType_TRANSACTION_TYPE_CAPTURE:           "capture",
+	atmv1.TransactionType_TRANSACTION_TYPE_HOLD:              "hold",
+	atmv1.TransactionType_TRANSACTION_TYPE_INTEREST:          "interest",
+	atmv1.TransactionType_TRANSACTION_TYPE_FEE:               "fee",
+	atmv1.TransactionType_TRANSACTION_TYPE_ADJUSTMENT_CREDIT: "adjustment_credit",
+	atmv1.TransactionType_TRANSACTION_TYPE_ADJUSTMENT_DEBIT:  "adjustment_debit",
+	atmv1.TransactionType_TRANSACTION_TYPE_PAYOUT:            "payout",
+}
+
+// Server implements the BankingService on top of the user and transaction packages
+type Server struct {
+	atmv1.UnimplementedBankingServiceServer
+
+	terminalID      string
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+	sessions        *session.Store
+}
+
+// NewServer creates a gRPC banking service whose sessions are reported as the given terminal
+func NewServer(terminalID string, idleTimeout, absoluteTimeout time.Duration) *Server {
+	return &Server{
+		terminalID:      terminalID,
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+		sessions:        session.NewStore(),
+	}
+}
+
+// SweepSessions ends idle sessions periodically until the stop channel is closed
+func (s *Server) SweepSessions(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sessions.Sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewGRPCServer creates a gRPC server with the interceptor chain and the service registered
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, s.UnaryInterceptors(), s.StreamInterceptors())
+	server := grpc.NewServer(opts...)
+	atmv1.RegisterBankingServiceServer(server, s)
+	return server
+}
+
+// terminal describes the caller of the request for the audit log
+func (s *Server) terminal(ctx context.Context) session.Terminal {
+	return session.Terminal{ID: s.terminalID, Source: clientAddr(ctx)}
+}
+
+// toAccount converts an account into its protobuf message
+func toAccount(account *user.Account) *atmv1.Account {
+	return &atmv1.Account{Id: int64(account.ID), Name: account.Name, CreatedAt: account.CreatedAt, Currency: account.Currency}
+}
+
+// balanceOf reads the current balance of the session's account
+func balanceOf(sess *session.Session) (*atmv1.Balance, error) {
+	balance, err := transaction.CheckBalance(sess.AccountID())
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.Balance{AccountId: int64(sess.AccountID()), Balance: balance.Ledger, AvailableBalance: balance.Available, Currency: sess.Currency()}, nil
+}
+
+// Register creates a new account
+func (s *Server) Register(ctx context.Context, req *atmv1.RegisterRequest) (*atmv1.RegisterResponse, error) {
+	if req.GetName() == "" || req.GetPin() == "" {
+		return nil, status.Error(codes.InvalidArgument, "nama dan PIN wajib diisi")
+	}
+	account, err := user.Register(req.GetName(), req.GetPin(), strings.ToUpper(req.GetCurrency()), s.terminal(ctx).String())
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.RegisterResponse{Account: toAccount(account)}, nil
+}
+
+// Login authenticates a customer and opens a session
+func (s *Server) Login(ctx context.Context, req *atmv1.LoginRequest) (*atmv1.LoginResponse, error) {
+	if req.GetName() == "" || req.GetPin() == "" {
+		return nil, status.Error(codes.InvalidArgument, "nama dan PIN wajib diisi")
+	}
+	terminal := s.terminal(ctx)
+	account, err := user.Login(req.GetName(), req.GetPin(), terminal.String())
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := session.Start(account, terminal, s.idleTimeout, s.absoluteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions.Add(sess)
+
+	return &atmv1.LoginResponse{
+		Token:     sess.ID,
+		ExpiresAt: sess.StartedAt.Add(sess.MaxDuration).UTC().Format(time.RFC3339),
+		Account:   toAccount(account),
+	}, nil
+}
+
+// Logout ends the session
+func (s *Server) Logout(ctx context.Context, _ *atmv1.LogoutRequest) (*atmv1.LogoutResponse, error) {
+	sess := sessionFrom(ctx)
+	s.sessions.Remove(sess.ID)
+	if err := sess.End(session.ReasonLogout); err != nil {
+		return nil, err
+	}
+	return &atmv1.LogoutResponse{}, nil
+}
+
+// GetAccount returns the profile of the session's account
+func (s *Server) GetAccount(ctx context.Context, _ *atmv1.GetAccountRequest) (*atmv1.GetAccountResponse, error) {
+	sess := sessionFrom(ctx)
+	if err := sess.Refresh(); err != nil {
+		return nil, err
+	}
+	return &atmv1.GetAccountResponse{Account: toAccount(sess.Account)}, nil
+}
+
+// GetBalance returns the balance of the session's account
+func (s *Server) GetBalance(ctx context.Context, _ *atmv1.GetBalanceRequest) (*atmv1.GetBalanceResponse, error) {
+	balance, err := balanceOf(sessionFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.GetBalanceResponse{Balance: balance}, nil
+}
+
+// Deposit adds money to the session's account
+func (s *Server) Deposit(ctx context.Context, req *atmv1.DepositRequest) (*atmv1.DepositResponse, error) {
+	sess := sessionFrom(ctx)
+	if err := transaction.Deposit(sess.AccountID(), req.GetAmount()); err != nil {
+		return nil, err
+	}
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.DepositResponse{Balance: balance}, nil
+}
+
+// Withdraw takes money from the session's account
+func (s *Server) Withdraw(ctx context.Context, req *atmv1.WithdrawRequest) (*atmv1.WithdrawResponse, error) {
+	sess := sessionFrom(ctx)
+	if err := transaction.Withdraw(sess.AccountID(), req.GetAmount()); err != nil {
+		return nil, err
+	}
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.WithdrawResponse{Balance: balance}, nil
+}
+
+// Transfer moves money from the session's account to another account
+func (s *Server) Transfer(ctx context.Context, req *atmv1.TransferRequest) (*atmv1.TransferResponse, error) {
+	sess := sessionFrom(ctx)
+	if req.GetTargetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "target_id wajib diisi")
+	}
+	// A held transfer is not a failure: the response names it so that it can be cancelled
+	var pending *transaction.PendingApprovalError
+	err := transaction.Transfer(sess.AccountID(), int(req.GetTargetId()), req.GetAmount())
+	if err != nil && !errors.As(err, &pending) {
+		return nil, err
+	}
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return nil, err
+	}
+	resp := &atmv1.TransferResponse{Balance: balance}
+	if pending != nil {
+		id := int64(pending.Transfer.ID)
+		resp.PendingTransferId = &id
+		resp.ExpiresAt = pending.Transfer.ExpiresAt
+	}
+	return resp, nil
+}
+
+// CancelTransfer withdraws a held transfer of the session's account
+func (s *Server) CancelTransfer(ctx context.Context, req *atmv1.CancelTransferRequest) (*atmv1.CancelTransferResponse, error) {
+	sess := sessionFrom(ctx)
+	if req.GetPendingTransferId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "pending_transfer_id wajib diisi")
+	}
+	if err := transaction.CancelTransfer(sess.AccountID(), int(req.GetPendingTransferId())); err != nil {
+		return nil, err
+	}
+	balance, err := balanceOf(sess)
+	if err != nil {
+		return nil, err
+	}
+	return &atmv1.CancelTransferResponse{Balance: balance}, nil
+}
+
+// StreamHistory streams the transactions of the session's account, newest first
+func (s *Server) StreamHistory(req *atmv1.StreamHistoryRequest, stream grpc.ServerStreamingServer[atmv1.StreamHistoryResponse]) error {
+	sess := sessionFrom(stream.Context())
+	transactionType, ok := historyTypes[req.GetType()]
+	if !ok {
+		return status.Error(codes.InvalidArgument, "tipe transaksi tidak dikenal")
+	}
+
+	transactions, err := transaction.ViewTransactionHistory(sess.AccountID(), transactionType)
+	if errors.Is(err, transaction.ErrNoHistory) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Reverse lookup of the protobuf enum for each stored type
+	types := make(map[string]atmv1.TransactionType, len(historyTypes))
+	for enum, name := range historyTypes {
+		types[name] = enum
+	}
+
+	for _, trans := range transactions {
+		item := &atmv1.Transaction{
+			Type:      types[trans["type"].(string)],
+			Amount:    trans["amount"].(float64),
+			CreatedAt: trans["created_at"].(string),
+		}
+		if targetID, ok := trans["target_id"].(*int); ok && targetID != nil {
+			id := int64(*targetID)
+			item.TargetId = &id
+		}
+		if err := stream.Send(&atmv1.StreamHistoryResponse{Transaction: item}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reasons for which a session can end
+const (
+	ReasonLogout          = "logout"
+	ReasonIdleTimeout     = "idle_timeout"
+	ReasonAbsoluteTimeout = "absolute_timeout"
+	ReasonShutdown        = "shutdown"
+)
+
+// Default timeouts of a customer session
+const (
+	DefaultIdleTimeout     = 60 * time.Second
+	DefaultAbsoluteTimeout = 5 * time.Minute
+)
+
+// Permission is an operation a session is allowed to perform, checked by the authorization layer
+type Permission = authz.Permission
+
+// Permissions granted to customer sessions
+const (
+	PermBalance   = authz.PermBalance
+	PermDeposit   = authz.PermDeposit
+	PermWithdraw  = authz.PermWithdraw
+	PermTransfer  = authz.PermTransfer
+	PermHistory   = authz.PermHistory
+	PermProfile   = authz.PermProfile
+	PermChangePIN = authz.PermChangePIN
+)
+
+// CustomerPermissions are the permissions of a customer logged in with their own card and PIN
+var CustomerPermissions = authz.Permissions(authz.RoleCustomer)
+
+// OfflinePermissions are the permissions of a customer logged in while the terminal runs in stand-in mode
+var OfflinePermissions = []Permission{PermBalance, PermWithdraw, PermProfile}
+
+// RemotePermissions are the permissions of a customer logged in through a remote ISO 8583 host,
+// which carries no history and no PIN change
+var RemotePermissions = []Permission{PermBalance, PermDeposit, PermWithdraw, PermTransfer, PermProfile}
+
+// Terminal describes where a session is hosted
+type Terminal struct {
+	ID     string // terminal ID, e.g. ATM-001
+	Source string // network address of the client, empty for a local terminal
+}
+
+// String returns the terminal as recorded in the audit log
+func (t Terminal) String() string {
+	if t.Source == "" {
+		return t.ID
+	}
+	return t.ID + "@" + t.Source
+}
+
+// Session represents the period during which a customer is logged in at a terminal
+type Session struct {
+	mu           sync.Mutex
+	ID           string
+	Principal    *user.Account // the authenticated customer
+	Account      *user.Account // the account the operations apply to
+	Terminal     Terminal
+	Permissions  []Permission // scope of the session within the permissions of the customer role
+	StartedAt    time.Time
+	EndedAt      time.Time
+	EndReason    string
+	IdleTimeout  time.Duration
+	MaxDuration  time.Duration
+	Offline      bool   // started in stand-in mode, not recorded in the sessions table
+	Remote       bool   // authenticated by a remote host, not recorded in the sessions table
+	PINBlock     string // PIN block built when the PIN was entered, sent to the remote host with every request
+	Language     string // language chosen by the customer at the start of the session, e.g. "id"
+	lastActivity time.Time
+}
+
+// newID generates a random session ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Start opens a new session for the authenticated account and records its start time
+func Start(account *user.Account, terminal Terminal, idleTimeout, maxDuration time.Duration) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account = withoutPIN(account)
+	s := &Session{
+		ID:           id,
+		Principal:    account,
+		Account:      account,
+		Terminal:     terminal,
+		Permissions:  CustomerPermissions,
+		StartedAt:    now,
+		IdleTimeout:  idleTimeout,
+		MaxDuration:  maxDuration,
+		lastActivity: now,
+	}
+
+	// Record the session start for reporting
+	_, err = db.DB.Exec("INSERT INTO sessions (id, account_id, terminal, started_at) VALUES (?, ?, ?, ?)",
+		s.ID, account.ID, terminal.String(), s.StartedAt.UTC().Format(time.DateTime))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// StartOffline opens a session authenticated against the stand-in snapshot while the database is down
+// The session is limited to OfflinePermissions and is not recorded
+func StartOffline(account *user.Account, terminal Terminal, idleTimeout, maxDuration time.Duration) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account = withoutPIN(account)
+	return &Session{
+		ID:           id,
+		Principal:    account,
+		Account:      account,
+		Terminal:     terminal,
+		Permissions:  OfflinePermissions,
+		StartedAt:    now,
+		IdleTimeout:  idleTimeout,
+		MaxDuration:  maxDuration,
+		Offline:      true,
+		lastActivity: now,
+	}, nil
+}
+
+// StartRemote opens a session for an account the remote host authenticated with the PIN block
+// The session is limited to RemotePermissions and is recorded by the host, not here
+func StartRemote(account *user.Account, pinBlock string, terminal Terminal, idleTimeout, maxDuration time.Duration) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account = withoutPIN(account)
+	return &Session{
+		ID:           id,
+		Principal:    account,
+		Account:      account,
+		Terminal:     terminal,
+		Permissions:  RemotePermissions,
+		StartedAt:    now,
+		IdleTimeout:  idleTimeout,
+		MaxDuration:  maxDuration,
+		Remote:       true,
+		PINBlock:     pinBlock,
+		lastActivity: now,
+	}, nil
+}
+
+// withoutPIN returns a copy of the account without its PIN, so a session never holds one
+func withoutPIN(account *user.Account) *user.Account {
+	stripped := *account
+	stripped.PIN = ""
+	return &stripped
+}
+
+// AccountID returns the ID of the selected account
+func (s *Session) AccountID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Account.ID
+}
+
+// Currency returns the currency of the selected account, the base currency when it is not known
+func (s *Session) Currency() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Account.Currency == "" {
+		return currency.Base
+	}
+	return s.Account.Currency
+}
+
+// Authorize returns a *authz.DeniedError when the session is not allowed to perform the operation
+func (s *Session) Authorize(p Permission) error {
+	s.mu.Lock()
+	principal := authz.Principal{Name: strconv.Itoa(s.Principal.ID), Role: authz.RoleCustomer, Scope: s.Permissions}
+	s.mu.Unlock()
+	return authz.Authorize(principal, p)
+}
+
+// Can reports whether the session is allowed to perform the operation
+func (s *Session) Can(p Permission) bool {
+	return s.Authorize(p) == nil
+}
+
+// Refresh reloads the principal and the selected account from storage,
+// so that changes such as a new status or balance are not served from a stale copy
+func (s *Session) Refresh() error {
+	s.mu.Lock()
+	principalID, accountID := s.Principal.ID, s.Account.ID
+	s.mu.Unlock()
+
+	principal, err := user.Get(principalID)
+	if err != nil {
+		return err
+	}
+	principal = withoutPIN(principal)
+	account := principal
+	if accountID != principalID {
+		account, err = user.Get(accountID)
+		if err != nil {
+			return err
+		}
+		account = withoutPIN(account)
+	}
+
+	s.mu.Lock()
+	s.Principal = principal
+	s.Account = account
+	s.mu.Unlock()
+	return nil
+}
+
+// Touch marks the session as active at the current time
+func (s *Session) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActivity = time.Now()
+}
+
+// IdleRemaining returns the time left before the session becomes idle
+func (s *Session) IdleRemaining() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.IdleTimeout <= 0 {
+		return s.remaining()
+	}
+	return s.IdleTimeout - time.Since(s.lastActivity)
+}
+
+// Remaining returns the time left before the absolute timeout of the session
+func (s *Session) Remaining() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remaining()
+}
+
+// remaining returns the time left before the absolute timeout, the caller must hold the lock
+func (s *Session) remaining() time.Duration {
+	if s.MaxDuration <= 0 {
+		// A session without an absolute timeout never expires on its own
+		return time.Duration(1<<63 - 1)
+	}
+	return s.MaxDuration - time.Since(s.StartedAt)
+}
+
+// ExpiryReason returns why the session has timed out, or an empty string while it is still valid
+func (s *Session) ExpiryReason() string {
+	if s.Remaining() <= 0 {
+		return ReasonAbsoluteTimeout
+	}
+	if s.IdleRemaining() <= 0 {
+		return ReasonIdleTimeout
+	}
+	return ""
+}
+
+// Ended reports whether the session has already been closed
+func (s *Session) Ended() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.EndedAt.IsZero()
+}
+
+// End closes the session and records its end time and reason
+// Ending a session that has already ended does nothing
+func (s *Session) End(reason string) error {
+	s.mu.Lock()
+	if !s.EndedAt.IsZero() {
+		s.mu.Unlock()
+		return nil
+	}
+	s.EndedAt = time.Now()
+	s.EndReason = reason
+	unrecorded := s.Offline || s.Remote
+	s.mu.Unlock()
+
+	if unrecorded {
+		return nil
+	}
+	_, err := db.DB.Exec("UPDATE sessions SET ended_at = ?, end_reason = ? WHERE id = ?",
+		s.EndedAt.UTC().Format(time.DateTime), reason, s.ID)
+	return err
+}
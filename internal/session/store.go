@@ -0,0 +1,72 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Store holds the sessions hosted by one process, keyed by session ID
+// It is safe for concurrent use by multiple front-ends
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewStore creates an empty session store
+func NewStore() *Store {
+	return &Store{sessions: make(map[string]*Session)}
+}
+
+// Add registers a session in the store
+func (st *Store) Add(s *Session) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.sessions[s.ID] = s
+}
+
+// Get returns the active session with the given ID
+// A session that has timed out is ended and removed
+func (st *Store) Get(id string) (*Session, error) {
+	st.mu.Lock()
+	s, ok := st.sessions[id]
+	st.mu.Unlock()
+	if !ok || s.Ended() {
+		st.Remove(id)
+		return nil, fmt.Errorf("sesi tidak ditemukan atau sudah berakhir")
+	}
+
+	if reason := s.ExpiryReason(); reason != "" {
+		st.Remove(id)
+		s.End(reason)
+		return nil, fmt.Errorf("sesi berakhir karena tidak ada aktivitas")
+	}
+	return s, nil
+}
+
+// Remove deletes a session from the store without ending it
+func (st *Store) Remove(id string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.sessions, id)
+}
+
+// Sweep ends and removes every session that has timed out
+// It returns the number of sessions that were ended
+func (st *Store) Sweep() int {
+	st.mu.Lock()
+	var expired []*Session
+	for id, s := range st.sessions {
+		if s.Ended() || s.ExpiryReason() != "" {
+			expired = append(expired, s)
+			delete(st.sessions, id)
+		}
+	}
+	st.mu.Unlock()
+
+	for _, s := range expired {
+		if reason := s.ExpiryReason(); reason != "" {
+			s.End(reason)
+		}
+	}
+	return len(expired)
+}
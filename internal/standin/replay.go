@@ -0,0 +1,161 @@
+package standin
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/businessday"
+	"atm-simulation/pkg/db"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+// Outcomes of replaying a queued withdrawal
+const (
+	// OutcomePosted means the withdrawal was posted like an online one
+	OutcomePosted = "posted"
+	// OutcomeOverdrawn means the balance no longer covered the withdrawal, e.g. because the
+	// account was debited elsewhere meanwhile; the cash is already out, so it is posted
+	// anyway and the account is left negative for follow-up
+	OutcomeOverdrawn = "overdrawn"
+	// OutcomeDuplicate means the reference had already been posted by an earlier replay
+	OutcomeDuplicate = "duplicate"
+	// OutcomeRejected means the account no longer exists; the entry needs manual handling
+	OutcomeRejected = "rejected"
+)
+
+// Result is the outcome of replaying one queued withdrawal
+type Result struct {
+	Entry
+	Outcome    string    `json:"outcome"`
+	Detail     string    `json:"detail,omitempty"`
+	ReplayedAt time.Time `json:"replayed_at"`
+}
+
+// Replay posts the queued withdrawals to the ledger in the order they were approved
+// It stops at the first database error and keeps the remaining entries queued;
+// every processed entry is appended to replayed.jsonl
+func (s *Store) Replay() ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Result
+	done := 0
+	var replayErr error
+	for _, e := range s.pending {
+		outcome, detail, err := post(e)
+		if err != nil {
+			replayErr = err
+			break
+		}
+		result := Result{Entry: e, Outcome: outcome, Detail: detail, ReplayedAt: time.Now()}
+		if err := appendLine(filepath.Join(s.Dir, replayedFile), result); err != nil {
+			replayErr = err
+			break
+		}
+		if outcome == OutcomeOverdrawn || outcome == OutcomeRejected {
+			audit.RecordOrLog(s.Terminal, audit.ActionStandInReplay, e.AccountID, audit.OutcomeFailure, s.Terminal,
+				fmt.Sprintf("%s %s: %s", e.Reference, outcome, detail))
+		}
+		results = append(results, result)
+		done++
+	}
+
+	s.pending = s.pending[done:]
+	if err := s.saveQueue(); err != nil {
+		return results, err
+	}
+	return results, replayErr
+}
+
+// saveQueue rewrites the queue file with the pending entries, the caller must hold the lock
+func (s *Store) saveQueue() error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range s.pending {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return writeFile(filepath.Join(s.Dir, queueFile), buf.Bytes())
+}
+
+// post writes one queued withdrawal to the ledger in a single database transaction
+// The reference makes posting idempotent when a replay is interrupted and run again
+func post(e Entry) (string, string, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.Get(&count, "SELECT COUNT(*) FROM transactions WHERE reference = ?", e.Reference); err != nil {
+		return "", "", err
+	}
+	if count > 0 {
+		return OutcomeDuplicate, "", nil
+	}
+
+	var balance float64
+	err = tx.Get(&balance, "SELECT balance FROM accounts WHERE id = ? FOR UPDATE", e.AccountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return OutcomeRejected, "akun tidak ditemukan", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	outcome, detail := OutcomePosted, ""
+	if balance < e.Amount {
+		outcome = OutcomeOverdrawn
+		detail = fmt.Sprintf("saldo %.2f tidak cukup untuk %.2f", balance, e.Amount)
+	}
+
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", e.Amount, e.AccountID); err != nil {
+		return "", "", err
+	}
+	// The withdrawal is booked on the business date it was approved on; entries queued without one take the current date
+	var businessDate *string
+	if e.BusinessDate != "" {
+		businessDate = &e.BusinessDate
+	}
+	// created_at keeps the time of the offline approval, in local time like the CURRENT_TIMESTAMP of every other row
+	_, err = tx.Exec("INSERT INTO transactions (account_id, type, amount, reference, created_at, business_date) VALUES (?, 'withdraw', ?, ?, ?, COALESCE(?, "+businessday.SQL+"))",
+		e.AccountID, e.Amount, e.Reference, e.CreatedAt.Local().Format(time.DateTime), businessDate)
+	if err != nil {
+		return "", "", err
+	}
+	return outcome, detail, tx.Commit()
+}
+
+// Watch replays the queue and refreshes the snapshot whenever the database is reachable,
+// checking every interval until the stop channel is closed
+func (s *Store) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		if len(s.Pending()) == 0 || !db.Online(2*time.Second) {
+			continue
+		}
+
+		results, err := s.Replay()
+		log.Printf("Stand-in: %d transaksi offline dikirim ulang", len(results))
+		if err != nil {
+			log.Println("Stand-in: pengiriman ulang terhenti:", err)
+			continue
+		}
+		if err := s.Snapshot(); err != nil {
+			log.Println("Stand-in: gagal memperbarui snapshot:", err)
+		}
+	}
+}
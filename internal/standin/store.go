@@ -0,0 +1,377 @@
+package standin
+
+import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Errors returned while the terminal runs in stand-in mode
+var (
+	ErrNotCached    = i18n.NewError("error.not_cached", "akun tidak tersedia dalam mode offline")
+	ErrWrongPIN     = i18n.NewError("error.wrong_pin", "PIN salah")
+	ErrOfflineLimit = i18n.NewError("error.offline_limit", "jumlah melebihi batas penarikan offline")
+)
+
+// LimitError is returned when a withdrawal exceeds what is left of the offline limit
+// It matches ErrOfflineLimit
+type LimitError struct {
+	Remaining float64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (sisa %.2f)", ErrOfflineLimit, e.Remaining)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrOfflineLimit
+}
+
+// MessageKey returns the catalog key of the translation, see i18n.Keyed
+func (e *LimitError) MessageKey() (string, []interface{}) {
+	return "error.offline_limit_left", []interface{}{e.Remaining}
+}
+
+// Files kept in the stand-in directory
+const (
+	snapshotFile = "snapshot.json"
+	dayFile      = "business_date.json"
+	queueFile    = "queue.jsonl"
+	replayedFile = "replayed.jsonl"
+)
+
+// CachedAccount is the copy of an account used to authorize withdrawals while offline
+// Only the salted bcrypt hash of the PIN is kept, as it is stored in the database
+type CachedAccount struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	PINHash string    `json:"pin_hash"`
+	Balance float64   `json:"balance"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// Entry is a withdrawal approved offline and waiting to be posted to the ledger
+type Entry struct {
+	Reference string    `json:"reference"`
+	Terminal  string    `json:"terminal"`
+	AccountID int       `json:"account_id"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	// BusinessDate is the business date the withdrawal was approved on, empty when the snapshot had none
+	BusinessDate string `json:"business_date,omitempty"`
+}
+
+// Store holds the balance snapshot and the queue of offline withdrawals of one terminal
+// Both are kept on disk so they survive a restart of the terminal
+type Store struct {
+	Dir      string
+	Terminal string
+	// Limit is the most each account may withdraw in total while offline
+	Limit float64
+
+	mu       sync.Mutex
+	accounts map[int]*CachedAccount
+	day      *businessday.Day // business date open when the snapshot was taken
+	pending  []Entry
+}
+
+// Open loads the snapshot and the pending queue from the directory, creating it if needed
+func Open(dir, terminal string, limit float64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Store{Dir: dir, Terminal: terminal, Limit: limit, accounts: make(map[int]*CachedAccount)}
+
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var accounts []*CachedAccount
+		if err := json.Unmarshal(data, &accounts); err != nil {
+			return nil, fmt.Errorf("snapshot stand-in rusak: %w", err)
+		}
+		for _, account := range accounts {
+			s.accounts[account.ID] = account
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, dayFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.day); err != nil {
+			return nil, fmt.Errorf("tanggal bisnis stand-in rusak: %w", err)
+		}
+	}
+
+	s.pending, err = readEntries(filepath.Join(dir, queueFile))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readEntries reads a JSON-lines file of queue entries; a missing file is an empty queue
+func readEntries(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s baris %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// writeFile replaces a file atomically so a crash never leaves it half written
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// appendLine appends one JSON line to a file and flushes it to disk
+func appendLine(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// saveSnapshot writes the cached accounts to disk, the caller must hold the lock
+func (s *Store) saveSnapshot() error {
+	accounts := make([]*CachedAccount, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(s.Dir, snapshotFile), data); err != nil {
+		return err
+	}
+	data, err = json.Marshal(s.day)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(s.Dir, dayFile), data)
+}
+
+// Snapshot caches every active account from the database
+// It is taken at start-up and after a replay, while the database is reachable
+func (s *Store) Snapshot() error {
+	// The offline limit is an amount in the base currency, so foreign currency accounts are not cached
+	// Funds on hold are left out of the cached balance
+	var accounts []user.Account
+	if err := db.DB.Select(&accounts, "SELECT id, name, pin, "+transaction.AvailableSQL+" AS balance FROM accounts WHERE locked_at IS NULL AND status = 'active' AND currency = ?", currency.Base); err != nil {
+		return err
+	}
+
+	// Withdrawals approved offline are booked on the business date open now, or the next one after its cut-off
+	day, err := businessday.Current()
+	if errors.Is(err, businessday.ErrNoBusinessDate) {
+		day, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// A PIN stored before PINs were hashed is hashed for the snapshot, it never reaches the disk as it is
+	for i, account := range accounts {
+		if account.PIN != "" && !user.PINHashed(account.PIN) {
+			hash, err := user.HashPIN(account.PIN)
+			if err != nil {
+				return err
+			}
+			accounts[i].PIN = hash
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.day = day
+	s.accounts = make(map[int]*CachedAccount, len(accounts))
+	for _, account := range accounts {
+		s.accounts[account.ID] = &CachedAccount{
+			ID:      account.ID,
+			Name:    account.Name,
+			PINHash: account.PIN,
+			Balance: account.Balance,
+			TakenAt: now,
+		}
+	}
+	return s.saveSnapshot()
+}
+
+// Update refreshes the cached balance of one account after an online operation
+func (s *Store) Update(accountID int, balance float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return nil
+	}
+	account.Balance = balance
+	account.TakenAt = time.Now()
+	return s.saveSnapshot()
+}
+
+// Login authenticates a customer against the snapshot
+// The returned account carries the cached balance and no PIN
+func (s *Store) Login(name, pin string) (*user.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if account.Name != name {
+			continue
+		}
+		if !user.PINHashed(account.PINHash) || !user.PINMatches(account.PINHash, pin) {
+			return nil, ErrWrongPIN
+		}
+		return &user.Account{ID: account.ID, Name: account.Name, Balance: account.Balance - s.queued(account.ID)}, nil
+	}
+	return nil, ErrNotCached
+}
+
+// queued returns the total of the pending withdrawals of an account, the caller must hold the lock
+func (s *Store) queued(accountID int) float64 {
+	total := 0.0
+	for _, e := range s.pending {
+		if e.AccountID == accountID {
+			total += e.Amount
+		}
+	}
+	return total
+}
+
+// Balance returns the cached balance less the withdrawals approved offline
+func (s *Store) Balance(accountID int) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return 0, ErrNotCached
+	}
+	return account.Balance - s.queued(accountID), nil
+}
+
+// Withdraw approves a withdrawal against the snapshot and queues it durably for replay
+// The total withdrawn offline per account may not exceed the limit nor the cached balance
+func (s *Store) Withdraw(accountID int, amount float64) (Entry, error) {
+	if amount <= 0 {
+		return Entry{}, transaction.ErrInvalidAmount
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return Entry{}, ErrNotCached
+	}
+	queued := s.queued(accountID)
+	if queued+amount > s.Limit {
+		return Entry{}, &LimitError{Remaining: max(s.Limit-queued, 0)}
+	}
+	if account.Balance-queued < amount {
+		return Entry{}, transaction.ErrInsufficientBalance
+	}
+
+	ref := make([]byte, 8)
+	if _, err := rand.Read(ref); err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{
+		Reference: "SI-" + s.Terminal + "-" + hex.EncodeToString(ref),
+		Terminal:  s.Terminal,
+		AccountID: accountID,
+		Amount:    amount,
+		CreatedAt: time.Now(),
+	}
+	if s.day != nil {
+		entry.BusinessDate = s.day.DateAt(entry.CreatedAt)
+	}
+	// The entry must be on disk before the cash is dispensed
+	if err := appendLine(filepath.Join(s.Dir, queueFile), entry); err != nil {
+		return Entry{}, err
+	}
+	s.pending = append(s.pending, entry)
+	return entry, nil
+}
+
+// Pending returns the withdrawals waiting to be replayed
+func (s *Store) Pending() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.pending...)
+}
+
+// SnapshotTime returns when the oldest cached balance was taken, or the zero time without a snapshot
+func (s *Store) SnapshotTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var oldest time.Time
+	for _, account := range s.accounts {
+		if oldest.IsZero() || account.TakenAt.Before(oldest) {
+			oldest = account.TakenAt
+		}
+	}
+	return oldest
+}
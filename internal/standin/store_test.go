@@ -0,0 +1,100 @@
+package standin
+
+import (
+	"atm-simulation/internal/transaction"
+	"errors"
+	"testing"
+)
+
+// openStore opens a store in a fresh directory with one cached account
+func openStore(t *testing.T, limit, balance float64) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir(), "ATM-001", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.accounts[12] = &CachedAccount{ID: 12, Name: "budi", Balance: balance}
+	return s
+}
+
+func TestWithdrawLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     float64
+		balance   float64
+		earlier   []float64 // withdrawals approved offline before
+		accountID int
+		amount    float64
+		want      error
+		remaining float64 // left of the limit reported with ErrOfflineLimit
+	}{
+		{"within the limit", 1000000, 5000000, nil, 12, 500000, nil, 0},
+		{"exactly the limit", 1000000, 5000000, nil, 12, 1000000, nil, 0},
+		{"above the limit", 1000000, 5000000, nil, 12, 1500000, ErrOfflineLimit, 1000000},
+		{"limit used up by earlier withdrawals", 1000000, 5000000, []float64{600000, 300000}, 12, 200000, ErrOfflineLimit, 100000},
+		{"rest of the limit", 1000000, 5000000, []float64{600000, 300000}, 12, 100000, nil, 0},
+		{"above the cached balance", 1000000, 400000, nil, 12, 500000, transaction.ErrInsufficientBalance, 0},
+		{"balance used up by earlier withdrawals", 1000000, 700000, []float64{500000}, 12, 300000, transaction.ErrInsufficientBalance, 0},
+		{"account not in the snapshot", 1000000, 5000000, nil, 13, 100000, ErrNotCached, 0},
+		{"zero amount", 1000000, 5000000, nil, 12, 0, transaction.ErrInvalidAmount, 0},
+		{"negative amount", 1000000, 5000000, nil, 12, -100000, transaction.ErrInvalidAmount, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openStore(t, tt.limit, tt.balance)
+			for _, amount := range tt.earlier {
+				if _, err := s.Withdraw(12, amount); err != nil {
+					t.Fatalf("earlier withdrawal of %.2f: %v", amount, err)
+				}
+			}
+
+			_, err := s.Withdraw(tt.accountID, tt.amount)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Withdraw(%.2f) = %v, want %v", tt.amount, err, tt.want)
+			}
+			var limit *LimitError
+			if errors.As(err, &limit) && limit.Remaining != tt.remaining {
+				t.Errorf("%.2f left of the limit, want %.2f", limit.Remaining, tt.remaining)
+			}
+
+			queued := len(tt.earlier)
+			if err == nil {
+				queued++
+			}
+			if got := len(s.Pending()); got != queued {
+				t.Errorf("%d withdrawals queued, want %d", got, queued)
+			}
+		})
+	}
+}
+
+func TestWithdrawQueuedDurably(t *testing.T) {
+	s := openStore(t, 1000000, 5000000)
+	first, err := s.Withdraw(12, 300000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Withdraw(12, 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Reference == second.Reference {
+		t.Errorf("both withdrawals have the reference %s", first.Reference)
+	}
+	if balance, _ := s.Balance(12); balance != 4500000 {
+		t.Errorf("balance %.2f, want 4500000", balance)
+	}
+
+	// After a restart the queue still counts against the limit
+	reopened, err := Open(s.Dir, "ATM-001", 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.accounts[12] = &CachedAccount{ID: 12, Name: "budi", Balance: 5000000}
+	if got := len(reopened.Pending()); got != 2 {
+		t.Fatalf("%d withdrawals queued after a restart, want 2", got)
+	}
+	if _, err := reopened.Withdraw(12, 600000); !errors.Is(err, ErrOfflineLimit) {
+		t.Errorf("Withdraw after a restart = %v, want ErrOfflineLimit", err)
+	}
+}
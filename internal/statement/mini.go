@@ -0,0 +1,85 @@
+package statement
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
+	"atm-simulation/pkg/db"
+	"fmt"
+	"time"
+)
+
+// Length of a mini-statement, which has to fit on a receipt
+const (
+	DefaultMiniLength = 10
+	MaxMiniLength     = 30
+)
+
+// ErrInvalidLength is returned for a mini-statement shorter than one or longer than MaxMiniLength transactions
+var ErrInvalidLength = fmt.Errorf("jumlah transaksi mini statement harus 1 sampai %d", MaxMiniLength)
+
+// Mini is a mini-statement: the last transactions of an account of every type
+type Mini struct {
+	Account     *user.Account
+	Balance     float64 // ledger balance when the mini-statement was made
+	Lines       []Line  // newest first, each with the balance after it
+	GeneratedAt time.Time
+}
+
+// GenerateMini builds the mini-statement of the last n transactions of the account
+// The running balance is worked back from the current balance
+func GenerateMini(accountID, n int) (*Mini, error) {
+	if n < 1 || n > MaxMiniLength {
+		return nil, ErrInvalidLength
+	}
+	account, err := user.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mini{Account: account, Balance: account.Balance, GeneratedAt: time.Now()}
+	err = db.DB.Select(&m.Lines, `SELECT id, type, amount, target_id, reference, created_at FROM transactions
+		WHERE account_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`, accountID, n)
+	if err != nil {
+		return nil, err
+	}
+	balance := account.Balance
+	for i := range m.Lines {
+		m.Lines[i].Balance = balance
+		balance -= m.Lines[i].Signed()
+	}
+	return m, nil
+}
+
+// Receipt renders the mini-statement in the compact layout of a receipt: one line per transaction
+// with the date, a short type code, the signed amount and the balance after it
+func (m *Mini) Receipt(p *i18n.Printer) []string {
+	decimals := 2
+	if c, err := currency.Lookup(m.Account.Currency); err == nil {
+		decimals = c.Decimals
+	}
+	number := func(x float64) string {
+		return p.Number(x, decimals)
+	}
+
+	lines := []string{
+		p.DateTime(m.GeneratedAt),
+		p.T("receipt.account_id", m.Account.ID),
+		p.T("mini.title"),
+	}
+	for _, l := range m.Lines {
+		date := l.CreatedAt
+		if t, err := time.Parse(time.DateTime, l.CreatedAt); err == nil {
+			date = t.Format(p.T("format.day_month"))
+		}
+		amount := number(l.Signed())
+		if l.Signed() > 0 {
+			amount = "+" + amount
+		}
+		lines = append(lines, fmt.Sprintf("%-5s %-8s %11s %11s", date, p.T("mini.type_"+l.Type), amount, number(l.Balance)))
+	}
+	if len(m.Lines) == 0 {
+		lines = append(lines, p.T("mini.empty"))
+	}
+	return append(lines, p.T("receipt.balance", currency.Format(m.Balance, m.Account.Currency)))
+}
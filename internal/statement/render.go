@@ -0,0 +1,264 @@
+package statement
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/pkg/currency"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Formats a statement can be rendered in
+const (
+	FormatPDF = "pdf"
+	FormatCSV = "csv"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatPDF, FormatCSV}
+
+// ErrUnknownFormat is returned for a format other than those in Formats
+var ErrUnknownFormat = errors.New("format rekening koran tidak dikenal")
+
+// Write renders the statement in the format, with the labels of the printer's language
+func Write(w io.Writer, s *Statement, format string, p *i18n.Printer) error {
+	switch format {
+	case FormatPDF:
+		return WritePDF(w, s, p)
+	case FormatCSV:
+		return WriteCSV(w, s, p)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// FileName is the name of the statement file in the format, e.g. 3_2025-04-01_2025-04-30.pdf
+func FileName(s *Statement, format string) string {
+	return fileName(s.Account.ID, s.From, s.To, format)
+}
+
+// fileName names the statement file of an account before the statement is generated
+func fileName(accountID int, from, to time.Time, format string) string {
+	return fmt.Sprintf("%d_%s_%s.%s", accountID, from.Format(time.DateOnly), to.Add(-time.Nanosecond).Format(time.DateOnly), format)
+}
+
+// WriteFile renders the statement into a file of the directory and returns its path
+// The file is written under a temporary name first, so a failed run leaves no partial statement
+func WriteFile(dir string, s *Statement, format string, p *i18n.Printer) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName(s, format))
+	f, err := os.CreateTemp(dir, ".statement-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if err := Write(f, s, format, p); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(f.Name(), path)
+}
+
+// lastDay is the last day included in the period of the statement
+func lastDay(s *Statement) time.Time {
+	return s.To.Add(-time.Nanosecond)
+}
+
+// typeLabel is the name of a transaction type in the printer's language
+func typeLabel(p *i18n.Printer, transactionType string) string {
+	return p.T("history.type_" + transactionType)
+}
+
+// description names the transaction and its counterparty or reference
+func description(p *i18n.Printer, l Line) string {
+	text := typeLabel(p, l.Type)
+	switch {
+	case l.TargetID != nil:
+		text += " - " + p.T("statement.counterparty", *l.TargetID)
+	case l.Reference != nil && *l.Reference != "":
+		text += " - " + *l.Reference
+	}
+	return text
+}
+
+// plain formats an amount for machines: dot decimal separator, no grouping, no symbol
+func plain(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// WriteCSV renders the statement as CSV: the opening balance, one row per transaction with the
+// running balance, the closing balance and, after an empty row, the totals per type
+func WriteCSV(w io.Writer, s *Statement, p *i18n.Printer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{p.T("statement.col_date"), p.T("statement.col_type"), p.T("statement.col_description"),
+			p.T("statement.col_debit"), p.T("statement.col_credit"), p.T("statement.col_balance")},
+		{s.From.Format(time.DateTime), "", p.T("statement.opening"), "", "", plain(s.Opening)},
+	}
+	for _, l := range s.Lines {
+		debit, credit := "", ""
+		if l.Signed() < 0 {
+			debit = plain(l.Amount)
+		} else {
+			credit = plain(l.Amount)
+		}
+		rows = append(rows, []string{l.CreatedAt, l.Type, description(p, l), debit, credit, plain(l.Balance)})
+	}
+	rows = append(rows,
+		[]string{lastDay(s).Format(time.DateTime), "", p.T("statement.closing"), plain(s.Debits), plain(s.Credits), plain(s.Closing)},
+		nil,
+		[]string{p.T("statement.col_type"), p.T("statement.col_description"), p.T("statement.col_count"), p.T("statement.col_total")},
+	)
+	for _, t := range s.Totals {
+		rows = append(rows, []string{t.Type, typeLabel(p, t.Type), strconv.Itoa(t.Count), plain(t.Amount)})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Column widths of the transaction table of the PDF, in millimetres; they add up to the page width
+// of A4 minus the margins
+var pdfColumns = []float64{34, 70, 28, 28, 30}
+
+// WritePDF renders the statement as an A4 PDF document
+func WritePDF(w io.Writer, s *Statement, p *i18n.Printer) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 15, 10)
+	pdf.SetAutoPageBreak(true, 20)
+	// The core fonts use cp1252, which covers the currency symbols of the bank
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	money := func(amount float64) string {
+		return tr(currency.Format(amount, s.Account.Currency))
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, tr(p.T("statement.page", pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		labels := []string{"statement.col_date", "statement.col_description", "statement.col_debit", "statement.col_credit", "statement.col_balance"}
+		for i, key := range labels {
+			align := "R"
+			if i < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(pdfColumns[i], 7, tr(p.T(key)), "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	_, pageHeight := pdf.GetPageSize()
+	row := func(date, text, debit, credit, balance string) {
+		// Break the page before the bottom margin ourselves, to repeat the table header on every page
+		if pdf.GetY()+6 > pageHeight-20 {
+			pdf.AddPage()
+			header()
+		}
+		pdf.CellFormat(pdfColumns[0], 6, date, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(pdfColumns[1], 6, tr(text), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(pdfColumns[2], 6, debit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(pdfColumns[3], 6, credit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(pdfColumns[4], 6, balance, "1", 1, "R", false, 0, "")
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(p.T("statement.title")), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range []string{
+		p.T("statement.account", s.Account.ID, s.Account.Name),
+		p.T("statement.currency", s.Account.Currency),
+		p.T("statement.period", p.Date(s.From), p.Date(lastDay(s))),
+		p.T("statement.generated", p.DateTime(s.GeneratedAt)),
+	} {
+		pdf.CellFormat(0, 6, tr(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	header()
+	row(p.Date(s.From), p.T("statement.opening"), "", "", money(s.Opening))
+	for _, l := range s.Lines {
+		debit, credit := "", ""
+		if l.Signed() < 0 {
+			debit = money(l.Amount)
+		} else {
+			credit = money(l.Amount)
+		}
+		row(p.Timestamp(l.CreatedAt), description(p, l), debit, credit, money(l.Balance))
+	}
+	if len(s.Lines) == 0 {
+		row("", p.T("statement.no_transactions"), "", "", "")
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	row(p.Date(lastDay(s)), p.T("statement.closing"), money(s.Debits), money(s.Credits), money(s.Closing))
+
+	if len(s.Totals) > 0 {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 8, tr(p.T("statement.summary")), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(104, 7, tr(p.T("statement.col_type")), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(28, 7, tr(p.T("statement.col_count")), "1", 0, "R", true, 0, "")
+		pdf.CellFormat(58, 7, tr(p.T("statement.col_total")), "1", 1, "R", true, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, t := range s.Totals {
+			pdf.CellFormat(104, 6, tr(typeLabel(p, t.Type)), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(28, 6, strconv.Itoa(t.Count), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(58, 6, money(t.Amount), "1", 1, "R", false, 0, "")
+		}
+	}
+	return pdf.Output(w)
+}
+
+// Batch writes the statement of every account open during the period into the directory, in each format
+// Statements already in the directory are kept unless overwrite is set, so an interrupted batch can be
+// run again. It returns the files written and the errors of the accounts that failed
+func Batch(dir string, from, to time.Time, formats []string, p *i18n.Printer, overwrite bool) ([]string, error) {
+	ids, err := Accounts(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	var errs []error
+	for _, id := range ids {
+		var s *Statement
+		for _, format := range formats {
+			if !overwrite {
+				if _, err := os.Stat(filepath.Join(dir, fileName(id, from, to, format))); err == nil {
+					continue
+				}
+			}
+			if s == nil {
+				if s, err = Generate(id, from, to); err != nil {
+					errs = append(errs, fmt.Errorf("akun %d: %w", id, err))
+					break
+				}
+			}
+			path, err := WriteFile(dir, s, format, p)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("akun %d: %w", id, err))
+				continue
+			}
+			written = append(written, path)
+		}
+	}
+	return written, errors.Join(errs...)
+}
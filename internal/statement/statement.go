@@ -0,0 +1,127 @@
+package statement
+
+import (
+	"atm-simulation/internal/transaction"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidPeriod is returned when the end of a period is not after its start
+var ErrInvalidPeriod = errors.New("periode rekening koran tidak valid")
+
+// Line is one transaction of a statement with the balance after it
+type Line struct {
+	ID        int     `db:"id"`
+	Type      string  `db:"type"`
+	Amount    float64 `db:"amount"` // as stored, always positive
+	TargetID  *int    `db:"target_id"`
+	Reference *string `db:"reference"`
+	CreatedAt string  `db:"created_at"`
+	Balance   float64 `db:"-"`
+}
+
+// Signed returns the change the transaction made to the balance, negative for debits
+func (l Line) Signed() float64 {
+	return transaction.SignedAmount(l.Type, l.Amount)
+}
+
+// Total sums the transactions of one type
+type Total struct {
+	Type   string
+	Count  int
+	Amount float64
+}
+
+// Statement is the account statement of one account for a period
+type Statement struct {
+	Account     *user.Account
+	From        time.Time // first moment of the period
+	To          time.Time // first moment after the period
+	Opening     float64
+	Closing     float64
+	Credits     float64
+	Debits      float64 // positive sum of the debits
+	Lines       []Line
+	Totals      []Total // per transaction type, by type
+	GeneratedAt time.Time
+}
+
+// Month returns the period of a calendar month
+func Month(year int, month time.Month) (from, to time.Time) {
+	from = time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// PreviousMonth returns the period of the calendar month before the one of t
+func PreviousMonth(t time.Time) (from, to time.Time) {
+	previous := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	return Month(previous.Year(), previous.Month())
+}
+
+// Generate builds the statement of the account for the period from (inclusive) to (exclusive)
+// The opening balance is worked back from the current balance, so the closing balance of the
+// latest period always matches the account
+func Generate(accountID int, from, to time.Time) (*Statement, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidPeriod
+	}
+	account, err := user.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var since []Line
+	err = db.DB.Select(&since, `SELECT id, type, amount, target_id, reference, created_at FROM transactions
+		WHERE account_id = ? AND created_at >= ? ORDER BY created_at, id`, accountID, from.Format(time.DateTime))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Statement{Account: account, From: from, To: to, Opening: account.Balance, GeneratedAt: time.Now()}
+	for _, l := range since {
+		s.Opening -= l.Signed()
+	}
+
+	end := to.Format(time.DateTime)
+	totals := make(map[string]*Total)
+	balance := s.Opening
+	for _, l := range since {
+		if l.CreatedAt >= end {
+			break
+		}
+		balance += l.Signed()
+		l.Balance = balance
+		s.Lines = append(s.Lines, l)
+
+		if l.Signed() < 0 {
+			s.Debits += l.Amount
+		} else {
+			s.Credits += l.Amount
+		}
+		t, ok := totals[l.Type]
+		if !ok {
+			t = &Total{Type: l.Type}
+			totals[l.Type] = t
+		}
+		t.Count++
+		t.Amount += l.Amount
+	}
+	s.Closing = balance
+
+	for _, t := range totals {
+		s.Totals = append(s.Totals, *t)
+	}
+	sort.Slice(s.Totals, func(i, j int) bool { return s.Totals[i].Type < s.Totals[j].Type })
+	return s, nil
+}
+
+// Accounts returns the IDs of the accounts that were open during some part of the period
+func Accounts(from, to time.Time) ([]int, error) {
+	var ids []int
+	err := db.DB.Select(&ids, `SELECT id FROM accounts WHERE created_at < ? AND (closed_at IS NULL OR closed_at >= ?) ORDER BY id`,
+		to.Format(time.DateTime), from.Format(time.DateTime))
+	return ids, err
+}
@@ -0,0 +1,148 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrCannotDispense is returned when the notes left in the cassettes cannot make up the amount
+var ErrCannotDispense = errors.New("uang tunai di ATM tidak cukup untuk jumlah ini")
+
+// Cassette holds the notes of one denomination
+type Cassette struct {
+	Denomination int
+	Count        int
+}
+
+// Cassettes is the cash inventory of one terminal
+type Cassettes struct {
+	mu        sync.Mutex
+	cassettes []Cassette
+}
+
+// NewCassettes creates an inventory, largest denomination first
+func NewCassettes(cassettes ...Cassette) *Cassettes {
+	sorted := append([]Cassette(nil), cassettes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Denomination > sorted[j].Denomination })
+	return &Cassettes{cassettes: sorted}
+}
+
+// ParseCassettes parses an inventory such as "100000x200,50000x100" (denomination x note count)
+func ParseCassettes(value string) (*Cassettes, error) {
+	var cassettes []Cassette
+	for _, part := range strings.Split(value, ",") {
+		denomination, count, ok := strings.Cut(strings.TrimSpace(part), "x")
+		if !ok {
+			return nil, fmt.Errorf("kaset %q tidak valid, gunakan format nominalxjumlah", part)
+		}
+		d, err := strconv.Atoi(denomination)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("nominal kaset %q tidak valid", denomination)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("jumlah lembar kaset %q tidak valid", count)
+		}
+		cassettes = append(cassettes, Cassette{Denomination: d, Count: n})
+	}
+	return NewCassettes(cassettes...), nil
+}
+
+// plan finds how many notes of each cassette make up the amount, preferring large notes
+// It backtracks so that e.g. 60000 is paid with three 20000 notes when no 10000 notes exist
+func plan(cassettes []Cassette, amount int) ([]int, bool) {
+	if amount == 0 {
+		return make([]int, len(cassettes)), true
+	}
+	if len(cassettes) == 0 {
+		return nil, false
+	}
+	first := cassettes[0]
+	for n := min(first.Count, amount/first.Denomination); n >= 0; n-- {
+		if rest, ok := plan(cassettes[1:], amount-n*first.Denomination); ok {
+			return append([]int{n}, rest...), true
+		}
+	}
+	return nil, false
+}
+
+// CanDispense reports whether the amount can be paid with the notes left
+func (c *Cassettes) CanDispense(amount float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := plan(c.cassettes, int(math.Round(amount)))
+	return ok
+}
+
+// Dispense takes the notes for the amount out of the cassettes and returns the count per denomination
+func (c *Cassettes) Dispense(amount float64) (map[int]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts, ok := plan(c.cassettes, int(math.Round(amount)))
+	if !ok {
+		return nil, ErrCannotDispense
+	}
+	notes := make(map[int]int)
+	for i, n := range counts {
+		if n > 0 {
+			c.cassettes[i].Count -= n
+			notes[c.cassettes[i].Denomination] = n
+		}
+	}
+	return notes, nil
+}
+
+// Replenish loads notes into the cassettes, adding a cassette for a denomination the terminal did not hold
+func (c *Cassettes) Replenish(notes ...Cassette) error {
+	for _, n := range notes {
+		if n.Denomination <= 0 || n.Count <= 0 {
+			return fmt.Errorf("isi kaset %dx%d tidak valid", n.Denomination, n.Count)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, n := range notes {
+		found := false
+		for i := range c.cassettes {
+			if c.cassettes[i].Denomination == n.Denomination {
+				c.cassettes[i].Count += n.Count
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.cassettes = append(c.cassettes, n)
+		}
+	}
+	sort.Slice(c.cassettes, func(i, j int) bool { return c.cassettes[i].Denomination > c.cassettes[j].Denomination })
+	return nil
+}
+
+// Total returns the value of the notes left
+func (c *Cassettes) Total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for _, cassette := range c.cassettes {
+		total += cassette.Denomination * cassette.Count
+	}
+	return float64(total)
+}
+
+// String describes the notes left, e.g. "100000x198,50000x100"
+func (c *Cassettes) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parts := make([]string, len(c.cassettes))
+	for i, cassette := range c.cassettes {
+		parts[i] = fmt.Sprintf("%dx%d", cassette.Denomination, cassette.Count)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,65 @@
+package terminal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		cassettes []Cassette
+		amount    int
+		want      []int
+		ok        bool
+	}{
+		{"large notes first", []Cassette{{100000, 10}, {50000, 10}}, 250000, []int{2, 1}, true},
+		{"large notes run out", []Cassette{{100000, 1}, {50000, 10}}, 250000, []int{1, 3}, true},
+		{"backtracks to smaller notes", []Cassette{{50000, 10}, {20000, 10}}, 60000, []int{0, 3}, true},
+		{"backtracks past a large note", []Cassette{{50000, 10}, {20000, 10}}, 110000, []int{1, 3}, true},
+		{"not enough notes", []Cassette{{100000, 1}, {50000, 1}}, 200000, nil, false},
+		{"no fitting denomination", []Cassette{{50000, 10}, {20000, 10}}, 30000, nil, false},
+		{"empty cassette", []Cassette{{100000, 0}, {50000, 4}}, 200000, []int{0, 4}, true},
+		{"zero amount", []Cassette{{100000, 5}}, 0, []int{0}, true},
+		{"no cassettes", nil, 50000, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := plan(tt.cassettes, tt.amount)
+			if ok != tt.ok {
+				t.Fatalf("plan(%d) ok = %v, want %v", tt.amount, ok, tt.ok)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("plan(%d) = %v, want %v", tt.amount, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("plan(%d) = %v, want %v", tt.amount, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispense(t *testing.T) {
+	cash := NewCassettes(Cassette{20000, 5}, Cassette{50000, 2})
+
+	notes, err := cash.Dispense(110000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes[50000] != 1 || notes[20000] != 3 {
+		t.Errorf("Dispense(110000) = %v, want one 50000 and three 20000 notes", notes)
+	}
+	if got := cash.Total(); got != 90000 {
+		t.Errorf("%.0f left, want 90000", got)
+	}
+
+	// A refused amount leaves the cassettes alone
+	if _, err := cash.Dispense(100000); !errors.Is(err, ErrCannotDispense) {
+		t.Fatalf("Dispense(100000) = %v, want ErrCannotDispense", err)
+	}
+	if got := cash.Total(); got != 90000 {
+		t.Errorf("%.0f left after a refused amount, want 90000", got)
+	}
+}
@@ -0,0 +1,116 @@
+package terminal
+
+import (
+	"atm-simulation/internal/host"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Customer is an account the simulation withdraws from, identified by the card holding its ID and PIN block
+type Customer struct {
+	Card host.Card
+}
+
+// ParseCustomer parses a customer given as "accountID:PIN"
+func ParseCustomer(value string) (Customer, error) {
+	id, pin, ok := strings.Cut(value, ":")
+	if !ok {
+		return Customer{}, fmt.Errorf("nasabah %q tidak valid, gunakan format id:PIN", value)
+	}
+	accountID, err := strconv.Atoi(id)
+	if err != nil {
+		return Customer{}, fmt.Errorf("ID akun %q tidak valid", id)
+	}
+	card, err := host.NewCard(accountID, pin)
+	if err != nil {
+		return Customer{}, fmt.Errorf("nasabah %d: %w", accountID, err)
+	}
+	return Customer{Card: card}, nil
+}
+
+// Options controls the traffic generated by Simulate
+type Options struct {
+	// Interval is the pause between two customer operations
+	Interval time.Duration
+	// OutageRate is the probability that the terminal breaks down before an operation
+	OutageRate float64
+	// OutageDuration is how long a breakdown lasts
+	OutageDuration time.Duration
+	// MaxWithdrawal is the largest random withdrawal; amounts are multiples of 50000
+	MaxWithdrawal int
+}
+
+// Stats counts the outcome of the operations of one terminal
+type Stats struct {
+	Terminal    string
+	Inquiries   int
+	Withdrawals int
+	Approved    int
+	Declined    int
+	CashShort   int
+	Unavailable int
+	Outages     int
+	Failovers   int
+	CashLeft    float64
+}
+
+// Simulate runs random balance inquiries and withdrawals on the terminal until the
+// context is cancelled or the cash runs out
+func (t *Terminal) Simulate(ctx context.Context, customers []Customer, opts Options) *Stats {
+	stats := &Stats{Terminal: t.ID}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for t.Cash.Total() > 0 {
+		select {
+		case <-ctx.Done():
+			return t.finish(stats)
+		case <-time.After(opts.Interval):
+		}
+
+		if random.Float64() < opts.OutageRate {
+			stats.Outages++
+			t.SetDown(true)
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.OutageDuration):
+			}
+			t.SetDown(false)
+			continue
+		}
+
+		customer := customers[random.Intn(len(customers))]
+		var err error
+		if random.Intn(4) == 0 {
+			stats.Inquiries++
+			_, err = t.CheckBalance(customer.Card)
+		} else {
+			stats.Withdrawals++
+			amount := float64(50000 * (1 + random.Intn(max(opts.MaxWithdrawal/50000, 1))))
+			err = t.Withdraw(customer.Card, amount)
+		}
+
+		switch {
+		case err == nil:
+			stats.Approved++
+		case errors.Is(err, ErrCannotDispense):
+			stats.CashShort++
+		case errors.Is(err, host.ErrHostUnavailable):
+			stats.Unavailable++
+		default:
+			stats.Declined++
+		}
+	}
+	return t.finish(stats)
+}
+
+// finish fills the counters read from the terminal once the simulation stops
+func (t *Terminal) finish(stats *Stats) *Stats {
+	stats.Failovers = t.Client.Failovers()
+	stats.CashLeft = t.Cash.Total()
+	return stats
+}
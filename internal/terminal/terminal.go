@@ -0,0 +1,129 @@
+package terminal
+
+import (
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/host"
+	"atm-simulation/internal/journal"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ErrOutOfService is returned while the terminal is down
+var ErrOutOfService = errors.New("terminal sedang tidak beroperasi")
+
+// Terminal is one simulated ATM: it holds its own cash, talks to the host through its
+// own client (and therefore its own STAN sequence) and writes its own journal
+type Terminal struct {
+	ID      string
+	Client  *host.Client
+	Cash    *Cassettes
+	Journal *journal.Journal
+
+	mu   sync.Mutex
+	down bool
+	// busy serializes customer operations: an ATM serves one customer at a time
+	busy sync.Mutex
+}
+
+// New creates a terminal that is in service
+func New(id string, client *host.Client, cash *Cassettes, ej *journal.Journal) *Terminal {
+	return &Terminal{ID: id, Client: client, Cash: cash, Journal: ej}
+}
+
+// log writes an event to the terminal journal
+func (t *Terminal) log(accountID int, event, detail string) {
+	if t.Journal == nil {
+		return
+	}
+	if err := t.Journal.Write("", accountID, event, detail); err != nil {
+		log.Printf("terminal %s: gagal menulis jurnal: %v", t.ID, err)
+	}
+}
+
+// SetDown takes the terminal out of service, or puts it back
+func (t *Terminal) SetDown(down bool) {
+	t.mu.Lock()
+	changed := t.down != down
+	t.down = down
+	t.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if down {
+		t.log(0, journal.EventTerminalDown, "gangguan terminal")
+	} else {
+		t.log(0, journal.EventTerminalUp, "terminal kembali beroperasi")
+	}
+}
+
+// Down reports whether the terminal is out of service
+func (t *Terminal) Down() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.down
+}
+
+// Replenish loads notes into the terminal on behalf of the principal, who needs the cash replenishment permission
+func (t *Terminal) Replenish(p authz.Principal, notes ...Cassette) error {
+	if err := authz.Authorize(p, authz.PermCashReplenish); err != nil {
+		return err
+	}
+
+	// Wait for the current customer to finish before the safe is opened
+	t.busy.Lock()
+	defer t.busy.Unlock()
+
+	if err := t.Cash.Replenish(notes...); err != nil {
+		return err
+	}
+	t.log(0, journal.EventReplenish, fmt.Sprintf("oleh %s, isi kaset %s", p.Name, t.Cash))
+	return nil
+}
+
+// CheckBalance asks the host for the balance of the account of the card
+func (t *Terminal) CheckBalance(card host.Card) (float64, error) {
+	t.busy.Lock()
+	defer t.busy.Unlock()
+
+	if t.Down() {
+		return 0, ErrOutOfService
+	}
+	accountID := card.AccountID
+	balance, err := t.Client.CheckBalance(card)
+	if err != nil {
+		t.log(accountID, journal.EventError, "cek saldo: "+err.Error())
+		return 0, err
+	}
+	return balance, nil
+}
+
+// Withdraw checks that the cash is available, has the host debit the account and dispenses the notes
+func (t *Terminal) Withdraw(card host.Card, amount float64) error {
+	t.busy.Lock()
+	defer t.busy.Unlock()
+
+	if t.Down() {
+		return ErrOutOfService
+	}
+	// Refuse before contacting the host so the account is not debited for cash that cannot be paid
+	accountID := card.AccountID
+	if !t.Cash.CanDispense(amount) {
+		t.log(accountID, journal.EventDispenseFail, fmt.Sprintf("jumlah=%.2f: %v", amount, ErrCannotDispense))
+		return ErrCannotDispense
+	}
+	if err := t.Client.Withdraw(card, amount); err != nil {
+		t.log(accountID, journal.EventDispenseFail, fmt.Sprintf("jumlah=%.2f: %v", amount, err))
+		return err
+	}
+
+	notes, err := t.Cash.Dispense(amount)
+	if err != nil {
+		t.log(accountID, journal.EventDispenseFail, fmt.Sprintf("jumlah=%.2f: %v", amount, err))
+		return err
+	}
+	t.log(accountID, journal.EventDispense, fmt.Sprintf("jumlah=%.2f lembar=%v", amount, notes))
+	return nil
+}
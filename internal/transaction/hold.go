@@ -0,0 +1,309 @@
+package transaction
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned by funds holds
+var (
+	ErrHoldNotFound       = i18n.NewError("error.hold_not_found", "dana yang ditahan tidak ditemukan")
+	ErrHoldNotActive      = i18n.NewError("error.hold_not_active", "dana sudah tidak ditahan")
+	ErrHoldExpired        = i18n.NewError("error.hold_expired", "masa penahanan dana sudah berakhir")
+	ErrCaptureExceedsHold = i18n.NewError("error.capture_exceeds_hold", "jumlah melebihi dana yang ditahan")
+)
+
+// Statuses of a hold
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// DefaultHoldTTL is how long funds stay on hold when nobody captures or releases them
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+// HoldTTL is how long a new hold lasts before it expires and its funds are released
+var HoldTTL = DefaultHoldTTL
+
+// maxReferenceLength is the length of the reference column of the holds and standing_orders tables
+const maxReferenceLength = 100
+
+// Hold is an authorization that reserves part of the balance of an account until it is captured,
+// released or expires; it lowers the available balance but leaves the ledger balance alone
+type Hold struct {
+	ID        int     `db:"id"`
+	AccountID int     `db:"account_id"`
+	Amount    float64 `db:"amount"`   // authorized amount, in the currency of the account
+	Captured  float64 `db:"captured"` // amount debited when the hold was captured
+	Reference string  `db:"reference"`
+	Status    string  `db:"status"`
+	CreatedAt string  `db:"created_at"`
+	ExpiresAt string  `db:"expires_at"`
+	ClosedAt  *string `db:"closed_at"`
+}
+
+// Balance is the balance of an account as the ledger holds it and as the customer may still spend it
+type Balance struct {
+	Ledger    float64
+	Available float64 // ledger minus the active holds and the transfers waiting for approval
+}
+
+// heldSQL sums the amounts held on an account by active holds and by transfers waiting for approval
+const heldSQL = `COALESCE((SELECT SUM(h.amount) FROM holds h
+	WHERE h.account_id = accounts.id AND h.status = 'active' AND h.expires_at > NOW()), 0)
+	+ COALESCE((SELECT SUM(p.amount) FROM pending_transfers p
+	WHERE p.account_id = accounts.id AND p.status = 'pending_approval' AND p.expires_at > NOW()), 0)`
+
+// AvailableSQL is the available balance of a row of the accounts table, for queries selecting from it
+const AvailableSQL = "accounts.balance - " + heldSQL
+
+// CheckBalance returns the ledger and the available balance of the account
+func CheckBalance(accountID int) (Balance, error) {
+	var balance Balance
+	err := db.DB.QueryRowx("SELECT balance, "+AvailableSQL+" FROM accounts WHERE id = ?", accountID).
+		Scan(&balance.Ledger, &balance.Available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Balance{}, ErrAccountNotFound
+	}
+	return balance, err
+}
+
+// AvailableBalance returns the balance of the account minus the amounts on hold
+func AvailableBalance(accountID int) (float64, error) {
+	return available(db.DB, accountID, false)
+}
+
+// available reads the available balance of the account, locking the account row when asked to
+func available(q sqlx.Queryer, accountID int, lock bool) (float64, error) {
+	query := "SELECT " + AvailableSQL + " FROM accounts WHERE id = ?"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var balance float64
+	err := sqlx.Get(q, &balance, query, accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAccountNotFound
+	}
+	return balance, err
+}
+
+// authorizeHold checks that the principal may manage holds and audits a denial
+func authorizeHold(p authz.Principal, action string, accountID int, source string) error {
+	if err := authz.Authorize(p, authz.PermHold); err != nil {
+		audit.RecordOrLog(p.Name, action, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	return nil
+}
+
+// PlaceHold reserves the amount on the account on behalf of an operator, e.g. for a card purchase
+// that is settled later. The account must be allowed to send money and have the amount available
+func PlaceHold(accountID int, amount float64, reference string, p authz.Principal, source string) (*Hold, error) {
+	if err := authorizeHold(p, audit.ActionHoldPlace, accountID, source); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	reference = strings.TrimSpace(reference)
+	if len(reference) > maxReferenceLength {
+		reference = reference[:maxReferenceLength]
+	}
+	if err := checkStatus(accountID, user.OpSend); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	balance, err := available(tx, accountID, true)
+	if err != nil {
+		return nil, err
+	}
+	if balance < amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	result, err := tx.Exec(`INSERT INTO holds (account_id, amount, reference, status, expires_at)
+		VALUES (?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? SECOND))`,
+		accountID, amount, reference, HoldActive, int(HoldTTL.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	audit.RecordOrLog(p.Name, audit.ActionHoldPlace, accountID, audit.OutcomeSuccess, source, strings.TrimSpace(fmt.Sprintf("hold #%d sebesar %.2f %s", id, amount, reference)))
+	return GetHold(int(id))
+}
+
+// CaptureHold debits the account for a hold and releases whatever is left of it
+// An amount of 0 captures the whole hold; more than the hold is refused
+func CaptureHold(id int, amount float64, p authz.Principal, source string) (*Hold, error) {
+	if amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h, err := lockHold(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeHold(p, audit.ActionHoldCapture, h.AccountID, source); err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	// The hold itself still counts against the available balance until its status changes below
+	balance, err := available(tx, h.AccountID, true)
+	if err != nil {
+		return nil, err
+	}
+	if balance+h.Amount < amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, h.AccountID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO transactions (account_id, type, amount, business_date) VALUES (?, 'capture', ?, `+businessday.SQL+`)`, h.AccountID, amount); err != nil {
+		return nil, err
+	}
+	if err := closeHold(tx, h.ID, HoldCaptured, amount); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	audit.RecordOrLog(p.Name, audit.ActionHoldCapture, h.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("hold #%d: %.2f dari %.2f", h.ID, amount, h.Amount))
+	return GetHold(h.ID)
+}
+
+// ReleaseHold gives the amount of a hold back to the available balance without debiting anything
+func ReleaseHold(id int, p authz.Principal, source string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	h, err := lockHold(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorizeHold(p, audit.ActionHoldRelease, h.AccountID, source); err != nil {
+		return err
+	}
+	if err := closeHold(tx, h.ID, HoldReleased, 0); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.RecordOrLog(p.Name, audit.ActionHoldRelease, h.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("hold #%d sebesar %.2f", h.ID, h.Amount))
+	return nil
+}
+
+// ExpireHolds marks the active holds that passed their expiry as expired
+// Their amounts stop counting against the available balance as soon as they expire; this only
+// records it. It returns the number of holds expired
+func ExpireHolds() (int, error) {
+	result, err := db.DB.Exec(`UPDATE holds SET status = ?, closed_at = CURRENT_TIMESTAMP
+		WHERE status = ? AND expires_at <= NOW()`, HoldExpired, HoldActive)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// GetHold retrieves a hold by its ID
+func GetHold(id int) (*Hold, error) {
+	h := &Hold{}
+	err := db.DB.Get(h, "SELECT * FROM holds WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	return h, err
+}
+
+// Holds returns the active holds, oldest first
+// An account ID of 0 returns those of every account
+func Holds(accountID int) ([]Hold, error) {
+	query := "SELECT * FROM holds WHERE status = ? AND expires_at > NOW()"
+	args := []interface{}{HoldActive}
+	if accountID != 0 {
+		query += " AND account_id = ?"
+		args = append(args, accountID)
+	}
+	var holds []Hold
+	err := db.DB.Select(&holds, query+" ORDER BY id", args...)
+	return holds, err
+}
+
+// lockHold loads an active hold and locks it until the transaction ends
+// A hold found past its expiry is marked expired
+func lockHold(tx *sqlx.Tx, id int) (*Hold, error) {
+	h := &Hold{}
+	err := tx.Get(h, "SELECT * FROM holds WHERE id = ? FOR UPDATE", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.Status != HoldActive {
+		return nil, ErrHoldNotActive
+	}
+
+	var expired bool
+	if err := tx.Get(&expired, "SELECT expires_at <= NOW() FROM holds WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	if expired {
+		if err := closeHold(tx, id, HoldExpired, 0); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrHoldExpired
+	}
+	return h, nil
+}
+
+// closeHold records how a hold ended and the amount captured from it
+func closeHold(tx *sqlx.Tx, id int, status string, captured float64) error {
+	_, err := tx.Exec(`UPDATE holds SET status = ?, captured = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?`, status, captured, id)
+	return err
+}
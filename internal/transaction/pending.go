@@ -0,0 +1,358 @@
+package transaction
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned by the approval of large transfers
+var (
+	ErrPendingApproval    = i18n.NewError("error.pending_approval", "transfer melebihi batas dan menunggu persetujuan supervisor")
+	ErrTransferNotFound   = i18n.NewError("error.transfer_not_found", "transfer tertunda tidak ditemukan")
+	ErrTransferNotPending = i18n.NewError("error.transfer_not_pending", "transfer sudah tidak menunggu persetujuan")
+	ErrTransferExpired    = i18n.NewError("error.transfer_expired", "transfer sudah kedaluwarsa")
+	ErrNoClearing         = i18n.NewError("error.no_clearing", "kliring antarbank tidak tersedia untuk memproses transfer ini")
+)
+
+// Statuses of a transfer that needs approval
+const (
+	TransferPendingApproval = "pending_approval"
+	TransferApproved        = "approved"
+	TransferRejected        = "rejected"
+	TransferExpired         = "expired"
+	TransferCancelled       = "cancelled"
+)
+
+// Defaults of the approval of large transfers
+const (
+	DefaultApprovalThreshold = 50000000
+	DefaultApprovalTTL       = 24 * time.Hour
+)
+
+// ApprovalThreshold is the amount, in the currency of the sender, above which a transfer waits for
+// the approval of a supervisor; 0 lets every transfer through at once
+var ApprovalThreshold float64 = DefaultApprovalThreshold
+
+// ApprovalTTL is how long a transfer waits for approval before it expires and its funds are released
+var ApprovalTTL = DefaultApprovalTTL
+
+// PendingTransfer is a large transfer whose amount is held on the sender's account until a supervisor decides
+type PendingTransfer struct {
+	ID        int     `db:"id"`
+	AccountID int     `db:"account_id"`
+	TargetID  int     `db:"target_id"`
+	DestBank  *string `db:"dest_bank"` // bank of the receiver of an interbank transfer, nil within the bank
+	Reference *string `db:"reference"` // unique reference of the transfer, e.g. of a standing order
+	Amount    float64 `db:"amount"`    // debited from the sender, in the sender's currency
+	Credit    float64 `db:"credit"`    // credited to the receiver, in the receiver's currency
+	Status    string  `db:"status"`
+	DecidedBy *string `db:"decided_by"` // supervisor, or the sender's account ID for a cancellation
+	Note      string  `db:"note"`       // reason of a rejection
+	CreatedAt string  `db:"created_at"`
+	ExpiresAt string  `db:"expires_at"`
+	DecidedAt *string `db:"decided_at"`
+}
+
+// PendingApprovalError is returned by Transfer and TransferFX when the transfer was held for approval
+// instead of posted; it matches ErrPendingApproval
+type PendingApprovalError struct {
+	Transfer *PendingTransfer
+}
+
+func (e *PendingApprovalError) Error() string {
+	return fmt.Sprintf("transfer #%d menunggu persetujuan supervisor", e.Transfer.ID)
+}
+
+func (e *PendingApprovalError) Is(target error) bool {
+	return target == ErrPendingApproval
+}
+
+// NeedsApproval reports whether a transfer debiting the amount has to wait for a supervisor
+func NeedsApproval(debit float64) bool {
+	return ApprovalThreshold > 0 && debit > ApprovalThreshold
+}
+
+// PostInterbank hands an approved interbank transfer to the clearing network within the transaction of the approval
+// The clearing package sets it, since it depends on this package
+var PostInterbank func(tx *sqlx.Tx, p *PendingTransfer) error
+
+// HoldInterbank holds an interbank transfer for the approval of a supervisor, like a large transfer within the bank,
+// and returns a *PendingApprovalError
+func HoldInterbank(accountID int, destBank string, destAccountID int, amount float64) error {
+	return holdTransfer(accountID, destAccountID, &destBank, amount, amount, "")
+}
+
+// holdTransfer records a transfer that waits for approval and holds its amount on the sender's account
+// The reference, if any, is kept with the transfer and posted with it once approved; a transfer held
+// before with the same reference is returned again instead of being held twice
+func holdTransfer(accountID, targetID int, destBank *string, debit, credit float64, reference string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	balance, err := available(tx, accountID, true)
+	if err != nil {
+		return err
+	}
+	if balance < debit {
+		return ErrInsufficientBalance
+	}
+
+	result, err := tx.Exec(`INSERT INTO pending_transfers (account_id, target_id, dest_bank, reference, amount, credit, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? SECOND))`,
+		accountID, targetID, destBank, nullableReference(reference), debit, credit, TransferPendingApproval, int(ApprovalTTL.Seconds()))
+	if err := duplicateReference(err); errors.Is(err, ErrDuplicateReference) {
+		return heldBefore(reference)
+	} else if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	p, err := GetPendingTransfer(int(id))
+	if err != nil {
+		return err
+	}
+	return &PendingApprovalError{Transfer: p}
+}
+
+// heldBefore reports the transfer already held with the reference: a *PendingApprovalError while it still
+// waits for approval, ErrDuplicateReference once it was decided
+func heldBefore(reference string) error {
+	p := &PendingTransfer{}
+	if err := db.DB.Get(p, "SELECT * FROM pending_transfers WHERE reference = ?", reference); err != nil {
+		return err
+	}
+	if p.Status == TransferPendingApproval {
+		return &PendingApprovalError{Transfer: p}
+	}
+	return ErrDuplicateReference
+}
+
+// GetPendingTransfer retrieves a transfer that needed approval by its ID
+func GetPendingTransfer(id int) (*PendingTransfer, error) {
+	p := &PendingTransfer{}
+	err := db.DB.Get(p, "SELECT * FROM pending_transfers WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	return p, err
+}
+
+// PendingTransfers returns the transfers waiting for approval, oldest first
+// An account ID of 0 returns those of every account, for the supervisor queue
+func PendingTransfers(accountID int) ([]PendingTransfer, error) {
+	query := "SELECT * FROM pending_transfers WHERE status = ? AND expires_at > NOW()"
+	args := []interface{}{TransferPendingApproval}
+	if accountID != 0 {
+		query += " AND account_id = ?"
+		args = append(args, accountID)
+	}
+	var transfers []PendingTransfer
+	err := db.DB.Select(&transfers, query+" ORDER BY id", args...)
+	return transfers, err
+}
+
+// ApproveTransfer posts a held transfer on behalf of a supervisor
+// An interbank transfer is handed to the clearing network through PostInterbank
+func ApproveTransfer(id int, checker authz.Principal, source string) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p, err := lockPending(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := authz.AuthorizeApproval(checker, strconv.Itoa(p.AccountID)); err != nil {
+		audit.RecordOrLog(checker.Name, audit.ActionApprove, p.AccountID, audit.OutcomeFailure, source, fmt.Sprintf("transfer #%d: %v", p.ID, err))
+		return err
+	}
+
+	post := postTransfer
+	if p.DestBank != nil {
+		post = PostInterbank
+	}
+	if post == nil {
+		err = ErrNoClearing
+	} else {
+		err = post(tx, p)
+	}
+	if err != nil {
+		audit.RecordOrLog(checker.Name, audit.ActionApprove, p.AccountID, audit.OutcomeFailure, source, fmt.Sprintf("transfer #%d: %v", p.ID, err))
+		return err
+	}
+	if err := decideTransfer(tx, p.ID, TransferApproved, checker.Name, ""); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.RecordOrLog(checker.Name, audit.ActionApprove, p.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("transfer #%d ke %s sebesar %.2f", p.ID, p.Target(), p.Amount))
+	return nil
+}
+
+// Target names the receiver of the transfer, prefixed with the bank code for an interbank transfer
+func (p *PendingTransfer) Target() string {
+	if p.DestBank != nil {
+		return fmt.Sprintf("%s/%d", *p.DestBank, p.TargetID)
+	}
+	return strconv.Itoa(p.TargetID)
+}
+
+// postTransfer moves the amount of an approved transfer within the bank
+// The checks of the sender and the receiver are repeated, since their status may have changed
+func postTransfer(tx *sqlx.Tx, p *PendingTransfer) error {
+	if _, _, err := currencies(p.AccountID, p.TargetID); err != nil {
+		return err
+	}
+
+	// The held amount still counts against the available balance until the status changes, so it is added back;
+	// other holds and transfers waiting for approval stay reserved
+	balance, err := available(tx, p.AccountID, true)
+	if err != nil {
+		return err
+	}
+	if balance+p.Amount < p.Amount {
+		return ErrInsufficientBalance
+	}
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", p.Amount, p.AccountID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", p.Credit, p.TargetID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, reference, business_date) VALUES (?, 'transfer_out', ?, ?, ?, `+businessday.SQL+`)`, p.AccountID, p.Amount, p.TargetID, p.Reference)
+	if err != nil {
+		return duplicateReference(err)
+	}
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, business_date) VALUES (?, 'transfer_in', ?, ?, `+businessday.SQL+`)`, p.TargetID, p.Credit, p.AccountID)
+	return err
+}
+
+// RejectTransfer turns a held transfer down on behalf of a supervisor and releases its amount
+func RejectTransfer(id int, checker authz.Principal, note, source string) error {
+	if strings.TrimSpace(note) == "" {
+		return user.ErrReasonRequired
+	}
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p, err := lockPending(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := authz.AuthorizeApproval(checker, strconv.Itoa(p.AccountID)); err != nil {
+		audit.RecordOrLog(checker.Name, audit.ActionReject, p.AccountID, audit.OutcomeFailure, source, fmt.Sprintf("transfer #%d: %v", p.ID, err))
+		return err
+	}
+	if err := decideTransfer(tx, p.ID, TransferRejected, checker.Name, note); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.RecordOrLog(checker.Name, audit.ActionReject, p.AccountID, audit.OutcomeSuccess, source, fmt.Sprintf("transfer #%d: %s", p.ID, note))
+	return nil
+}
+
+// CancelTransfer lets the sender withdraw a held transfer before it is decided, which releases its amount
+func CancelTransfer(accountID, id int) error {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p, err := lockPending(tx, id)
+	if err != nil {
+		return err
+	}
+	// Transfers of other accounts are reported as missing rather than forbidden
+	if p.AccountID != accountID {
+		return ErrTransferNotFound
+	}
+	if err := decideTransfer(tx, p.ID, TransferCancelled, strconv.Itoa(accountID), ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExpireTransfers marks the held transfers whose approval window has passed as expired
+// Their amounts stop counting against the available balance as soon as they expire; this only
+// records it. It returns the number of transfers expired
+func ExpireTransfers() (int, error) {
+	result, err := db.DB.Exec(`UPDATE pending_transfers SET status = ?, decided_at = CURRENT_TIMESTAMP
+		WHERE status = ? AND expires_at <= NOW()`, TransferExpired, TransferPendingApproval)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// lockPending loads a transfer that still waits for approval and locks it until the transaction ends
+// A transfer found past its expiry is marked expired
+func lockPending(tx *sqlx.Tx, id int) (*PendingTransfer, error) {
+	p := &PendingTransfer{}
+	err := tx.Get(p, "SELECT * FROM pending_transfers WHERE id = ? FOR UPDATE", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != TransferPendingApproval {
+		return nil, ErrTransferNotPending
+	}
+
+	var expired bool
+	if err := tx.Get(&expired, "SELECT expires_at <= NOW() FROM pending_transfers WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	if expired {
+		if err := decideTransfer(tx, id, TransferExpired, "", ""); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrTransferExpired
+	}
+	return p, nil
+}
+
+// decideTransfer records the outcome of a held transfer; an empty decider is stored as NULL
+func decideTransfer(tx *sqlx.Tx, id int, status, decidedBy, note string) error {
+	var by *string
+	if decidedBy != "" {
+		by = &decidedBy
+	}
+	_, err := tx.Exec(`UPDATE pending_transfers SET status = ?, decided_by = ?, note = ?, decided_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		status, by, note, id)
+	return err
+}
@@ -0,0 +1,177 @@
+package transaction
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned by standing orders
+var (
+	ErrStandingOrderNotFound = i18n.NewError("error.standing_order_not_found", "standing order tidak ditemukan")
+	ErrStandingOrderDay      = i18n.NewError("error.standing_order_day", "tanggal standing order harus 1 sampai 31")
+)
+
+// Statuses of a standing order
+const (
+	StandingOrderActive    = "active"
+	StandingOrderCancelled = "cancelled"
+)
+
+// Results of the last execution of a standing order
+const (
+	StandingOrderExecuted = "executed"
+	StandingOrderPending  = "pending_approval" // above the approval threshold, waiting for a supervisor
+	StandingOrderFailed   = "failed"
+)
+
+// StandingOrder is a transfer repeated every month on the same day
+type StandingOrder struct {
+	ID          int     `db:"id"`
+	AccountID   int     `db:"account_id"`
+	TargetID    int     `db:"target_id"`
+	Amount      float64 `db:"amount"`
+	Day         int     `db:"day_of_month"` // in shorter months the order runs on the last day
+	Reference   string  `db:"reference"`
+	Status      string  `db:"status"`
+	LastRunDate *string `db:"last_run_date"` // business date of the last execution
+	LastResult  string  `db:"last_result"`
+	CreatedAt   string  `db:"created_at"`
+}
+
+// StandingOrderRun counts what happened to the standing orders due on a business date
+type StandingOrderRun struct {
+	Executed int
+	Pending  int
+	Failed   int
+}
+
+// CreateStandingOrder sets up a monthly transfer between two accounts in the same currency
+func CreateStandingOrder(accountID, targetID int, amount float64, day int, reference string) (*StandingOrder, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if day < 1 || day > 31 {
+		return nil, ErrStandingOrderDay
+	}
+	if accountID == targetID {
+		return nil, ErrSameAccount
+	}
+	source, target, err := currencies(accountID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if source != target {
+		return nil, ErrCurrencyMismatch
+	}
+	reference = strings.TrimSpace(reference)
+	if len(reference) > maxReferenceLength {
+		reference = reference[:maxReferenceLength]
+	}
+
+	result, err := db.DB.Exec(`INSERT INTO standing_orders (account_id, target_id, amount, day_of_month, reference, status)
+		VALUES (?, ?, ?, ?, ?, ?)`, accountID, targetID, amount, day, reference, StandingOrderActive)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetStandingOrder(int(id))
+}
+
+// GetStandingOrder retrieves a standing order by its ID
+func GetStandingOrder(id int) (*StandingOrder, error) {
+	o := &StandingOrder{}
+	err := db.DB.Get(o, "SELECT * FROM standing_orders WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStandingOrderNotFound
+	}
+	return o, err
+}
+
+// StandingOrders returns the active standing orders by ID
+// An account ID of 0 returns those of every account
+func StandingOrders(accountID int) ([]StandingOrder, error) {
+	query := "SELECT * FROM standing_orders WHERE status = ?"
+	args := []interface{}{StandingOrderActive}
+	if accountID != 0 {
+		query += " AND account_id = ?"
+		args = append(args, accountID)
+	}
+	var orders []StandingOrder
+	err := db.DB.Select(&orders, query+" ORDER BY id", args...)
+	return orders, err
+}
+
+// CancelStandingOrder stops a standing order from running again
+func CancelStandingOrder(id int) error {
+	result, err := db.DB.Exec("UPDATE standing_orders SET status = ? WHERE id = ? AND status = ?", StandingOrderCancelled, id, StandingOrderActive)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStandingOrderNotFound
+	}
+	return nil
+}
+
+// standingOrderReference is the reference of the transfer of a standing order on a date
+func standingOrderReference(id int, date time.Time) string {
+	return fmt.Sprintf("so#%d/%s", id, date.Format(time.DateOnly))
+}
+
+// RunStandingOrders carries out the standing orders due on the business date that did not run on it yet
+// An order that fails, e.g. for lack of funds, is recorded and tried again next month
+func RunStandingOrders(date time.Time) (StandingOrderRun, error) {
+	var run StandingOrderRun
+	day := date.Day()
+	// In a month shorter than the day of an order, the order runs on the last day of the month
+	if date.AddDate(0, 0, 1).Day() == 1 {
+		day = 31
+	}
+	var orders []StandingOrder
+	err := db.DB.Select(&orders, `SELECT * FROM standing_orders WHERE status = ? AND day_of_month BETWEEN ? AND ?
+		AND (last_run_date IS NULL OR last_run_date < ?) ORDER BY id`,
+		StandingOrderActive, date.Day(), day, date.Format(time.DateOnly))
+	if err != nil {
+		return run, err
+	}
+
+	for _, o := range orders {
+		result := StandingOrderExecuted
+		var pending *PendingApprovalError
+		// The reference is unique to the order and the date, so an order whose transfer was posted or held
+		// before the run stopped is not paid twice when the run is repeated
+		err := TransferWithReference(o.AccountID, o.TargetID, o.Amount, standingOrderReference(o.ID, date))
+		switch {
+		case errors.Is(err, ErrDuplicateReference):
+			run.Executed++
+		case errors.As(err, &pending):
+			result = StandingOrderPending
+			run.Pending++
+		case err != nil:
+			result = fmt.Sprintf("%s: %v", StandingOrderFailed, err)
+			run.Failed++
+		default:
+			run.Executed++
+		}
+		if len(result) > 255 {
+			result = result[:255]
+		}
+		_, err = db.DB.Exec("UPDATE standing_orders SET last_run_date = ?, last_result = ? WHERE id = ?", date.Format(time.DateOnly), result, o.ID)
+		if err != nil {
+			return run, err
+		}
+	}
+	return run, nil
+}
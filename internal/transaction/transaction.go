@@ -1,12 +1,59 @@
 package transaction
 
 import (
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/internal/user"
+	"atm-simulation/pkg/currency"
 	"atm-simulation/pkg/db"
-	"fmt"
+	"database/sql"
+	"errors"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+// Errors returned by the transaction functions
+var (
+	ErrAccountNotFound     = i18n.NewError("error.account_not_found", "user id tidak terdaftar")
+	ErrTargetNotFound      = i18n.NewError("error.target_not_found", "user id tujuan tidak terdaftar")
+	ErrInsufficientBalance = i18n.NewError("error.insufficient_balance", "saldo tidak mencukupi")
+	ErrInvalidAmount       = i18n.NewError("error.invalid_amount", "jumlah uang tidak valid")
+	ErrSameAccount         = i18n.NewError("error.same_account", "tidak dapat mentransfer ke akun sendiri")
+	ErrNoHistory           = i18n.NewError("error.no_history", "tidak ada riwayat transaksi untuk kategori ini")
+	ErrCurrencyMismatch    = i18n.NewError("error.currency_mismatch", "mata uang akun tujuan berbeda, transfer memerlukan konversi kurs")
+	ErrQuoteMismatch       = i18n.NewError("error.quote_mismatch", "kurs tidak sesuai dengan mata uang kedua akun")
+	ErrTargetUnavailable   = i18n.NewError("error.target_unavailable", "akun tujuan tidak dapat menerima dana")
+	ErrOriginalNotFound    = i18n.NewError("error.original_not_found", "transaksi asal tidak ditemukan")
+	ErrAlreadyReversed     = i18n.NewError("error.already_reversed", "transaksi sudah dibatalkan")
+	ErrDuplicateReference  = i18n.NewError("error.duplicate_reference", "referensi transaksi sudah dipakai")
+)
+
+// Types lists the transaction types, as in the type column of the transactions table
+var Types = []string{"deposit", "withdraw", "transfer_in", "transfer_out", "reversal_in", "reversal_out", "interbank_out",
+	"interbank_in", "interbank_refund", "adjustment_credit", "adjustment_debit", "payout", "capture", "interest", "fee"}
+
+// debitTypes are the transaction types that take money out of the account; the others bring money in
+var debitTypes = map[string]bool{
+	"withdraw":         true,
+	"transfer_out":     true,
+	"reversal_out":     true,
+	"interbank_out":    true,
+	"adjustment_debit": true,
+	"payout":           true,
+	"capture":          true,
+	"fee":              true,
+}
+
+// SignedAmount returns the change a transaction made to the balance: the amount is stored
+// positive, so debits are negated
+func SignedAmount(transactionType string, amount float64) float64 {
+	if debitTypes[transactionType] {
+		return -amount
+	}
+	return amount
+}
+
 // Checks if the account exists by querying the database
 func checkAccountExists(accountID int) (bool, error) {
 	var count int
@@ -18,6 +65,23 @@ func checkAccountExists(accountID int) (bool, error) {
 	return count > 0, nil
 }
 
+// checkStatus returns an error when the status of the account does not allow the operation
+func checkStatus(accountID int, op user.Operation) error {
+	var status string
+	err := db.DB.Get(&status, "SELECT status FROM accounts WHERE id = ?", accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return user.CheckStatus(status, op)
+}
+
+// activeHoldsSQL selects the active holds of an account in the shape of the transactions table
+const activeHoldsSQL = `SELECT 'hold' AS type, amount, NULL AS target_id, created_at FROM holds
+	WHERE account_id = ? AND status = 'active' AND expires_at > NOW()`
+
 // Retrieves the transaction history based on account ID and transaction type
 func ViewTransactionHistory(accountID int, transactionType string) ([]map[string]interface{}, error) {
 	// Check if the account exists
@@ -27,15 +91,20 @@ func ViewTransactionHistory(accountID int, transactionType string) ([]map[string
 	}
 	if !exists {
 		// If the account does not exist, return an error
-		return nil, fmt.Errorf("user id tidak terdaftar")
+		return nil, ErrAccountNotFound
 	}
 
 	// Prepare the query based on the transaction type
+	// Active holds are listed as transactions of type "hold", with the amount still on hold
 	var rows *sqlx.Rows // Use sqlx.Rows instead of db.Rows
-	if transactionType == "all" {
-		// If 'all', retrieve all transactions
-		rows, err = db.DB.Queryx("SELECT type, amount, target_id, created_at FROM transactions WHERE account_id = ? ORDER BY created_at DESC", accountID)
-	} else {
+	switch transactionType {
+	case "all":
+		// If 'all', retrieve all transactions and the active holds
+		rows, err = db.DB.Queryx("SELECT type, amount, target_id, created_at FROM transactions WHERE account_id = ? UNION ALL "+
+			activeHoldsSQL+" ORDER BY created_at DESC", accountID, accountID)
+	case "hold":
+		rows, err = db.DB.Queryx(activeHoldsSQL+" ORDER BY created_at DESC", accountID)
+	default:
 		// If a specific transaction type is given, filter by that type
 		rows, err = db.DB.Queryx("SELECT type, amount, target_id, created_at FROM transactions WHERE account_id = ? AND type = ? ORDER BY created_at DESC", accountID, transactionType)
 	}
@@ -71,7 +140,7 @@ func ViewTransactionHistory(accountID int, transactionType string) ([]map[string
 
 	// If no transactions are found
 	if len(transactions) == 0 {
-		return nil, fmt.Errorf("tidak ada riwayat transaksi untuk kategori ini")
+		return nil, ErrNoHistory
 	}
 
 	return transactions, nil
@@ -79,107 +148,394 @@ func ViewTransactionHistory(accountID int, transactionType string) ([]map[string
 
 // Deposits money into the specified account
 func Deposit(accountID int, amount float64) error {
-	// Check if the account exists
-	exists, err := checkAccountExists(accountID)
-	if err != nil {
+	return DepositWithReference(accountID, amount, "")
+}
+
+// DepositWithReference deposits money and records the reference on the transaction, so the deposit
+// can be found and reversed later. A reference that is already used is refused with ErrDuplicateReference
+func DepositWithReference(accountID int, amount float64, reference string) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	// Check if the account exists and may receive money
+	if err := checkStatus(accountID, user.OpReceive); err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("user id tidak terdaftar")
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
 	// Update the account balance
-	_, err = db.DB.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, accountID)
+	_, err = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, accountID)
 	if err != nil {
 		return err
 	}
 
 	// Insert the deposit transaction into the transactions table
-	_, err = db.DB.Exec(`INSERT INTO transactions (account_id, type, amount) VALUES (?, 'deposit', ?)`, accountID, amount)
-	return err
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, 'deposit', ?, ?, `+businessday.SQL+`)`,
+		accountID, amount, nullableReference(reference))
+	if err != nil {
+		return duplicateReference(err)
+	}
+	if err := checkNotReversed(tx, reference); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // Withdraws money from the specified account
 func Withdraw(accountID int, amount float64) error {
-	// Check if the account exists
-	exists, err := checkAccountExists(accountID)
-	if err != nil {
+	return WithdrawWithReference(accountID, amount, "")
+}
+
+// WithdrawWithReference withdraws money and records the reference on the transaction, e.g. the
+// terminal and trace number of an ISO 8583 request, so the withdrawal can be found and reversed later
+// A reference that is already used is refused with ErrDuplicateReference
+func WithdrawWithReference(accountID int, amount float64, reference string) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	// Check if the account exists and may send money
+	if err := checkStatus(accountID, user.OpSend); err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("user id tidak terdaftar")
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	// Check if the account has enough balance
-	var balance float64
-	err = db.DB.Get(&balance, "SELECT balance FROM accounts WHERE id = ?", accountID)
+	// Check if the account has enough balance, leaving out amounts on hold
+	// The account row stays locked until the withdrawal is committed
+	balance, err := available(tx, accountID, true)
 	if err != nil {
 		return err
 	}
 	if balance < amount {
-		return fmt.Errorf("saldo tidak mencukupi")
+		return ErrInsufficientBalance
 	}
 
 	// Update the account balance by subtracting the withdrawal amount
-	_, err = db.DB.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, accountID)
+	_, err = tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, accountID)
 	if err != nil {
 		return err
 	}
 
 	// Insert the withdrawal transaction into the transactions table
-	_, err = db.DB.Exec(`INSERT INTO transactions (account_id, type, amount) VALUES (?, 'withdraw', ?)`, accountID, amount)
-	return err
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, 'withdraw', ?, ?, `+businessday.SQL+`)`,
+		accountID, amount, nullableReference(reference))
+	if err != nil {
+		return duplicateReference(err)
+	}
+	if err := checkNotReversed(tx, reference); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Transfers money between two accounts (sender and receiver)
+// Transfers money from one account to another account in the same currency
+// Transfers above ApprovalThreshold wait for a supervisor, see PendingApprovalError
 func Transfer(accountID, targetID int, amount float64) error {
-	// Check if the sender account exists
-	exists, err := checkAccountExists(accountID)
+	return TransferWithReference(accountID, targetID, amount, "")
+}
+
+// TransferWithReference transfers money and records the reference on the sender's transaction,
+// so the transfer can be found and reversed later or is not posted twice
+// A reference that is already used is refused with ErrDuplicateReference
+func TransferWithReference(accountID, targetID int, amount float64, reference string) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if accountID == targetID {
+		return ErrSameAccount
+	}
+
+	source, target, err := currencies(accountID, targetID)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("user id tidak terdaftar")
+	if source != target {
+		return ErrCurrencyMismatch
+	}
+	return move(accountID, targetID, amount, amount, reference)
+}
+
+// TransferFX transfers money between accounts in different currencies at the quoted rate
+// The sender is debited the quoted amount and the receiver credited the converted amount
+func TransferFX(accountID, targetID int, quote currency.Quote) error {
+	if quote.Amount <= 0 || quote.Converted <= 0 {
+		return ErrInvalidAmount
+	}
+	if accountID == targetID {
+		return ErrSameAccount
 	}
 
-	// Check if the target account exists
-	exists, err = checkAccountExists(targetID)
+	source, target, err := currencies(accountID, targetID)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("user id tujuan tidak terdaftar")
+	if source != quote.From || target != quote.To {
+		return ErrQuoteMismatch
+	}
+	return move(accountID, targetID, quote.Amount, quote.Converted, "")
+}
+
+// currencies returns the currencies of the sender and the receiver of a transfer,
+// after checking that the sender may send and the receiver may receive money
+func currencies(accountID, targetID int) (string, string, error) {
+	var source, target user.Account
+	err := db.DB.Get(&source, "SELECT currency, status FROM accounts WHERE id = ?", accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrAccountNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if err := user.CheckStatus(source.Status, user.OpSend); err != nil {
+		return "", "", err
+	}
+	err = db.DB.Get(&target, "SELECT currency, status FROM accounts WHERE id = ?", targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrTargetNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if user.CheckStatus(target.Status, user.OpReceive) != nil {
+		return "", "", ErrTargetUnavailable
+	}
+	return source.Currency, target.Currency, nil
+}
+
+// move debits the sender and credits the receiver, each in their own currency
+// A transfer above ApprovalThreshold is held instead and a *PendingApprovalError returned
+func move(accountID, targetID int, debit, credit float64, reference string) error {
+	// Large transfers are held until a supervisor approves them
+	if NeedsApproval(debit) {
+		return holdTransfer(accountID, targetID, nil, debit, credit, reference)
 	}
 
-	// Check if the sender has enough balance to transfer
-	var balance float64
-	err = db.DB.Get(&balance, "SELECT balance FROM accounts WHERE id = ?", accountID)
+	tx, err := db.DB.Beginx()
 	if err != nil {
 		return err
 	}
-	if balance < amount {
-		return fmt.Errorf("saldo tidak mencukupi")
+	defer tx.Rollback()
+
+	// Lock both accounts, always in the same order, so opposite transfers cannot deadlock
+	if err := lockAccounts(tx, accountID, targetID); err != nil {
+		return err
+	}
+
+	// Check if the sender has enough balance to transfer, leaving out amounts on hold
+	balance, err := available(tx, accountID, true)
+	if err != nil {
+		return err
+	}
+	if balance < debit {
+		return ErrInsufficientBalance
 	}
 
 	// Withdraw the amount from the sender's account
-	_, err = db.DB.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, accountID)
+	_, err = tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", debit, accountID)
 	if err != nil {
 		return err
 	}
 
 	// Deposit the amount into the target account
-	_, err = db.DB.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, targetID)
+	_, err = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", credit, targetID)
 	if err != nil {
 		return err
 	}
 
 	// Insert the transaction for the sender
-	_, err = db.DB.Exec(`INSERT INTO transactions (account_id, type, amount, target_id) VALUES (?, 'transfer_out', ?, ?)`, accountID, amount, targetID)
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, reference, business_date) VALUES (?, 'transfer_out', ?, ?, ?, `+businessday.SQL+`)`,
+		accountID, debit, targetID, nullableReference(reference))
 	if err != nil {
+		return duplicateReference(err)
+	}
+	if err := checkNotReversed(tx, reference); err != nil {
 		return err
 	}
 
 	// Insert the transaction for the receiver
-	_, err = db.DB.Exec(`INSERT INTO transactions (account_id, type, amount, target_id) VALUES (?, 'transfer_in', ?, ?)`, targetID, amount, accountID)
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, business_date) VALUES (?, 'transfer_in', ?, ?, `+businessday.SQL+`)`, targetID, credit, accountID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// nullableReference stores an empty reference as NULL, as the reference column is unique
+func nullableReference(reference string) interface{} {
+	if reference == "" {
+		return nil
+	}
+	return reference
+}
+
+// duplicateReference turns the duplicate key error of the unique reference column into ErrDuplicateReference
+func duplicateReference(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrDuplicateReference
+	}
 	return err
 }
+
+// lockAccounts locks the rows of the accounts in ID order until the database transaction ends
+func lockAccounts(tx *sqlx.Tx, ids ...int) error {
+	query, args, err := sqlx.In("SELECT id FROM accounts WHERE id IN (?) ORDER BY id FOR UPDATE", ids)
+	if err != nil {
+		return err
+	}
+	var locked []int
+	return tx.Select(&locked, query, args...)
+}
+
+// checkNotReversed refuses a posting with ErrAlreadyReversed when its reversal advice arrived first, see Reverse
+// It runs once the posting is inserted: a reversal arriving meanwhile then waits for the posting and reverses it
+func checkNotReversed(tx *sqlx.Tx, reference string) error {
+	if reference == "" {
+		return nil
+	}
+	var count int
+	if err := tx.Get(&count, "SELECT COUNT(*) FROM reversal_advices WHERE reference = ? FOR SHARE", reference); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAlreadyReversed
+	}
+	return nil
+}
+
+// ReversalReference is the reference of the reversal of the transaction with the given reference
+func ReversalReference(reference string) string {
+	return "R:" + reference
+}
+
+// Reverse undoes an approved withdrawal, deposit or transfer, found by the reference it was posted with,
+// whose response never reached the terminal. A deposit is taken back only while the amount is available
+// and a transfer is moved back from the target. The reversal
+// is recorded with ReversalReference, so a repeated reversal returns ErrAlreadyReversed instead
+// of crediting the account twice, also when it reaches another host
+// A reversal that arrives before its original is kept in reversal_advices, and the original is
+// refused with ErrAlreadyReversed when it arrives late
+func Reverse(reference string) error {
+	if reference == "" {
+		return ErrOriginalNotFound
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the original transaction makes a concurrent reversal of it wait for this one
+	var original struct {
+		AccountID int           `db:"account_id"`
+		Type      string        `db:"type"`
+		Amount    float64       `db:"amount"`
+		TargetID  sql.NullInt64 `db:"target_id"`
+	}
+	err = tx.Get(&original, "SELECT account_id, type, amount, target_id FROM transactions WHERE reference = ? FOR UPDATE", reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := tx.Exec("INSERT IGNORE INTO reversal_advices (reference) VALUES (?)", reference); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+	if original.Type != "withdraw" && original.Type != "deposit" && original.Type != "transfer_out" {
+		return ErrOriginalNotFound
+	}
+	var reversed int
+	if err := tx.Get(&reversed, "SELECT COUNT(*) FROM transactions WHERE reference = ?", ReversalReference(reference)); err != nil {
+		return err
+	}
+	if reversed > 0 {
+		return ErrAlreadyReversed
+	}
+
+	accountID, amount := original.AccountID, original.Amount
+	if original.Type == "withdraw" {
+		// Put the withdrawn amount back into the account
+		_, err = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, accountID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, 'reversal_in', ?, ?, `+businessday.SQL+`)`,
+			accountID, amount, ReversalReference(reference))
+		if err != nil {
+			return duplicateReference(err)
+		}
+		return tx.Commit()
+	}
+	if original.Type == "deposit" {
+		// Take the deposited amount back out of the account, if it was not spent meanwhile
+		balance, err := available(tx, accountID, true)
+		if err != nil {
+			return err
+		}
+		if balance < amount {
+			return ErrInsufficientBalance
+		}
+		_, err = tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, accountID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, reference, business_date) VALUES (?, 'reversal_out', ?, ?, `+businessday.SQL+`)`,
+			accountID, amount, ReversalReference(reference))
+		if err != nil {
+			return duplicateReference(err)
+		}
+		return tx.Commit()
+	}
+
+	targetID := int(original.TargetID.Int64)
+	if err := lockAccounts(tx, accountID, targetID); err != nil {
+		return err
+	}
+
+	// The target must still have the transferred amount available
+	balance, err := available(tx, targetID, true)
+	if errors.Is(err, ErrAccountNotFound) {
+		return ErrTargetNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return ErrInsufficientBalance
+	}
+
+	// Move the amount from the target back to the sender
+	_, err = tx.Exec("UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, targetID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, accountID)
+	if err != nil {
+		return err
+	}
+
+	// Insert the reversal for the target and for the sender
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, business_date) VALUES (?, 'reversal_out', ?, ?, `+businessday.SQL+`)`, targetID, amount, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, target_id, reference, business_date) VALUES (?, 'reversal_in', ?, ?, ?, `+businessday.SQL+`)`,
+		accountID, amount, targetID, ReversalReference(reference))
+	if err != nil {
+		return duplicateReference(err)
+	}
+	return tx.Commit()
+}
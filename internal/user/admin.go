@@ -0,0 +1,272 @@
+package user
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/internal/businessday"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/pkg/db"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by the account management functions of the admin console
+var (
+	ErrReasonRequired  = i18n.NewError("error.reason_required", "alasan wajib diisi")
+	ErrAccountActive   = i18n.NewError("error.account_active", "akun sudah aktif")
+	ErrAccountUnlocked = i18n.NewError("error.account_unlocked", "akun tidak terkunci")
+	ErrInvalidAmount   = i18n.NewError("error.invalid_adjustment", "jumlah koreksi tidak valid")
+	ErrBalanceNegative = i18n.NewError("error.balance_negative", "koreksi membuat saldo menjadi negatif")
+)
+
+// searchLimit is the maximum number of accounts returned by Search
+const searchLimit = 50
+
+// Search finds accounts by ID or by a part of the name, on behalf of an operator
+func Search(query, actor, source string) ([]Account, error) {
+	query = strings.TrimSpace(query)
+	var accounts []Account
+	var err error
+	if id, convErr := strconv.Atoi(query); convErr == nil {
+		err = db.DB.Select(&accounts, "SELECT * FROM accounts WHERE id = ?", id)
+	} else {
+		err = db.DB.Select(&accounts, "SELECT * FROM accounts WHERE name LIKE ? ORDER BY name LIMIT ?", "%"+query+"%", searchLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	audit.RecordOrLog(actor, audit.ActionAccountSearch, 0, audit.OutcomeSuccess, source, fmt.Sprintf("%q, %d akun", query, len(accounts)))
+	return accounts, nil
+}
+
+// View retrieves the full profile of an account for an operator and records the access
+func View(accountID int, actor, source string) (*Account, error) {
+	account, err := Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+	audit.RecordOrLog(actor, audit.ActionAccountView, accountID, audit.OutcomeSuccess, source, "")
+	return account, nil
+}
+
+// authorize checks that the principal may perform the action on the account and audits a denial
+func authorize(p authz.Principal, perm authz.Permission, action string, accountID int, source string) error {
+	if err := authz.Authorize(p, perm); err != nil {
+		audit.RecordOrLog(p.Name, action, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Close closes the account for good and pays out the remaining balance
+// It returns the amount paid out to the customer
+func Close(accountID int, reason string, p authz.Principal, source string) (float64, error) {
+	if err := authorize(p, authz.PermClose, audit.ActionClose, accountID, source); err != nil {
+		return 0, err
+	}
+	actor := p.Name
+	if strings.TrimSpace(reason) == "" {
+		return 0, ErrReasonRequired
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var account Account
+	err = tx.Get(&account, "SELECT id, balance, status FROM accounts WHERE id = ? FOR UPDATE", accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAccountNotFound
+		}
+		return 0, err
+	}
+	if account.Status == StatusClosed {
+		audit.RecordOrLog(actor, audit.ActionClose, accountID, audit.OutcomeFailure, source, ErrAccountClosed.Error())
+		return 0, ErrAccountClosed
+	}
+
+	// A negative balance has to be settled by an adjustment before the account can be closed
+	if account.Balance < 0 {
+		audit.RecordOrLog(actor, audit.ActionClose, accountID, audit.OutcomeFailure, source, ErrBalanceNegative.Error())
+		return 0, ErrBalanceNegative
+	}
+	if account.Balance > 0 {
+		_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, business_date) VALUES (?, 'payout', ?, `+businessday.SQL+`)`, accountID, account.Balance)
+		if err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.Exec("UPDATE accounts SET balance = 0, status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?", StatusClosed, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordStatusChange(tx, accountID, account.Status, StatusClosed, reason, actor, source); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	audit.RecordOrLog(actor, audit.ActionClose, accountID, audit.OutcomeSuccess, source, fmt.Sprintf("%s, dibayarkan %.2f", reason, account.Balance))
+	return account.Balance, nil
+}
+
+// ResetPIN gives the account a random PIN that satisfies the PIN policy and unlocks it
+// The new PIN is returned so that it can be handed to the customer
+func ResetPIN(accountID int, p authz.Principal, source string) (string, error) {
+	if err := authorize(p, authz.PermResetPIN, audit.ActionPINReset, accountID, source); err != nil {
+		return "", err
+	}
+	actor := p.Name
+	account, err := Get(accountID)
+	if err != nil {
+		return "", err
+	}
+	if account.Status == StatusClosed {
+		audit.RecordOrLog(actor, audit.ActionPINReset, accountID, audit.OutcomeFailure, source, ErrAccountClosed.Error())
+		return "", ErrAccountClosed
+	}
+
+	var pin string
+	for {
+		pin, err = randomPIN(Policy.Length)
+		if err != nil {
+			return "", err
+		}
+		if Policy.Check(pin) != nil {
+			continue
+		}
+		reused, err := usedRecently(accountID, account.PIN, pin, Policy.History)
+		if err != nil {
+			return "", err
+		}
+		if !reused {
+			break
+		}
+	}
+
+	// Only the hash is stored, the PIN itself is returned once to be handed to the customer
+	hash, err := HashPIN(pin)
+	if err != nil {
+		return "", err
+	}
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE accounts SET pin = ?, failed_attempts = 0, locked_at = NULL WHERE id = ?", hash, accountID)
+	if err == nil {
+		err = recordPIN(tx, accountID, hash)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		audit.RecordOrLog(actor, audit.ActionPINReset, accountID, audit.OutcomeFailure, source, err.Error())
+		return "", err
+	}
+	audit.RecordOrLog(actor, audit.ActionPINReset, accountID, audit.OutcomeSuccess, source, "")
+	return pin, nil
+}
+
+// randomPIN returns a PIN of random digits
+func randomPIN(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
+
+// Unlock lifts the lockout after too many wrong PINs
+func Unlock(accountID int, p authz.Principal, source string) error {
+	if err := authorize(p, authz.PermUnlock, audit.ActionUnlock, accountID, source); err != nil {
+		return err
+	}
+	actor := p.Name
+	account, err := Get(accountID)
+	if err != nil {
+		return err
+	}
+	if account.LockedAt == nil {
+		audit.RecordOrLog(actor, audit.ActionUnlock, accountID, audit.OutcomeFailure, source, ErrAccountUnlocked.Error())
+		return ErrAccountUnlocked
+	}
+
+	_, err = db.DB.Exec("UPDATE accounts SET failed_attempts = 0, locked_at = NULL WHERE id = ?", accountID)
+	if err != nil {
+		audit.RecordOrLog(actor, audit.ActionUnlock, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	audit.RecordOrLog(actor, audit.ActionUnlock, accountID, audit.OutcomeSuccess, source, "")
+	return nil
+}
+
+// AdjustBalance corrects the balance of the account by the amount, which is negative for a debit
+// Every adjustment needs a reason, which is kept in the audit log
+func AdjustBalance(accountID int, amount float64, reason string, p authz.Principal, source string) error {
+	if err := authorize(p, authz.PermAdjust, audit.ActionAdjustBalance, accountID, source); err != nil {
+		return err
+	}
+	actor := p.Name
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+	if amount == 0 {
+		return ErrInvalidAmount
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var account Account
+	err = tx.Get(&account, "SELECT id, balance, status FROM accounts WHERE id = ? FOR UPDATE", accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		return err
+	}
+	if account.Status == StatusClosed {
+		audit.RecordOrLog(actor, audit.ActionAdjustBalance, accountID, audit.OutcomeFailure, source, ErrAccountClosed.Error())
+		return ErrAccountClosed
+	}
+	if account.Balance+amount < 0 {
+		audit.RecordOrLog(actor, audit.ActionAdjustBalance, accountID, audit.OutcomeFailure, source, ErrBalanceNegative.Error())
+		return ErrBalanceNegative
+	}
+
+	kind, value := "adjustment_credit", amount
+	if amount < 0 {
+		kind, value = "adjustment_debit", -amount
+	}
+	_, err = tx.Exec("UPDATE accounts SET balance = balance + ? WHERE id = ?", amount, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO transactions (account_id, type, amount, business_date) VALUES (?, ?, ?, `+businessday.SQL+`)`, accountID, kind, value)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.RecordOrLog(actor, audit.ActionAdjustBalance, accountID, audit.OutcomeSuccess, source, fmt.Sprintf("%+.2f: %s", amount, reason))
+	return nil
+}
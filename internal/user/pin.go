@@ -0,0 +1,212 @@
+package user
+
+import (
+	"atm-simulation/internal/i18n"
+	"atm-simulation/pkg/db"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Errors returned when a new PIN does not satisfy the PIN policy
+var (
+	ErrPINLength     = i18n.NewError("error.pin_length", "panjang PIN tidak sesuai ketentuan")
+	ErrPINNotNumeric = i18n.NewError("error.pin_not_numeric", "PIN hanya boleh berisi angka")
+	ErrPINSequence   = i18n.NewError("error.pin_sequence", "PIN tidak boleh berupa angka berurutan")
+	ErrPINRepeated   = i18n.NewError("error.pin_repeated", "PIN tidak boleh berupa angka yang berulang")
+	ErrPINDate       = i18n.NewError("error.pin_date", "PIN tidak boleh berupa tanggal, seperti tanggal lahir")
+	ErrPINReused     = i18n.NewError("error.pin_reused", "PIN sudah pernah digunakan, pilih PIN lain")
+)
+
+// IsPINPolicyError reports whether the error means that a new PIN was refused by the PIN policy
+func IsPINPolicyError(err error) bool {
+	for _, policyErr := range []error{ErrPINLength, ErrPINNotNumeric, ErrPINSequence, ErrPINRepeated, ErrPINDate, ErrPINReused} {
+		if errors.Is(err, policyErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinPINLength and MaxPINLength bound the PIN length a policy may ask for (ISO 9564)
+const (
+	MinPINLength = 4
+	MaxPINLength = 12
+)
+
+// PINPolicy describes which PINs are accepted when an account is opened or its PIN is changed
+type PINPolicy struct {
+	Length      int  // exact number of digits
+	History     int  // number of most recent PINs, the current one included, that may not be reused
+	RejectDates bool // reject PINs that read as a date, the usual way a birthdate ends up as PIN
+}
+
+// DefaultPINPolicy is the policy used unless the operator configures another one
+var DefaultPINPolicy = PINPolicy{Length: 6, History: 3, RejectDates: true}
+
+// Policy is the PIN policy enforced by Register and ChangePIN
+var Policy = DefaultPINPolicy
+
+// Validate checks that the policy itself can be enforced
+func (p PINPolicy) Validate() error {
+	if p.Length < MinPINLength || p.Length > MaxPINLength {
+		return fmt.Errorf("panjang PIN harus antara %d dan %d angka", MinPINLength, MaxPINLength)
+	}
+	if p.History < 0 {
+		return fmt.Errorf("jumlah riwayat PIN tidak boleh negatif")
+	}
+	return nil
+}
+
+// Check returns the first rule of the policy that the PIN breaks
+// Reuse of earlier PINs is checked by ChangePIN, which knows the account
+func (p PINPolicy) Check(pin string) error {
+	for _, ch := range pin {
+		if ch < '0' || ch > '9' {
+			return ErrPINNotNumeric
+		}
+	}
+	if len(pin) != p.Length {
+		return ErrPINLength
+	}
+	if isSequence(pin) {
+		return ErrPINSequence
+	}
+	if isRepeated(pin) {
+		return ErrPINRepeated
+	}
+	if p.RejectDates && isDate(pin) {
+		return ErrPINDate
+	}
+	return nil
+}
+
+// isSequence reports whether the digits go up or down by one, like 123456 or 987654
+func isSequence(pin string) bool {
+	up, down := true, true
+	for i := 1; i < len(pin); i++ {
+		step := int(pin[i]) - int(pin[i-1])
+		up = up && step == 1
+		down = down && step == -1
+	}
+	return up || down
+}
+
+// isRepeated reports whether the PIN repeats a shorter block, like 111111, 121212 or 123123
+func isRepeated(pin string) bool {
+	for size := 1; size <= len(pin)/2; size++ {
+		if len(pin)%size == 0 && strings.Repeat(pin[:size], len(pin)/size) == pin {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayouts are the ways a date is commonly written as digits, by PIN length
+var dateLayouts = map[int][]string{
+	4: {"0201", "0102", "2006"},
+	6: {"020106", "010206", "060102"},
+	8: {"02012006", "01022006", "20060102"},
+}
+
+// isDate reports whether the PIN reads as a calendar date in one of the common layouts
+func isDate(pin string) bool {
+	for _, layout := range dateLayouts[len(pin)] {
+		t, err := time.Parse(layout, pin)
+		if err != nil {
+			continue
+		}
+		// A four-digit year only counts when it could be a year of birth
+		if layout == "2006" && (t.Year() < 1900 || t.Year() > time.Now().Year()) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// HashPIN returns the salted bcrypt hash a PIN is stored as, in the accounts table and in the PIN history
+func HashPIN(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// PINHashed reports whether a stored PIN is a bcrypt hash; PINs stored before they were hashed are not
+func PINHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// PINMatches reports whether the PIN matches the stored one
+func PINMatches(stored, pin string) bool {
+	if !PINHashed(stored) {
+		return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(pin)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(pin)) == nil
+}
+
+// recordPIN adds the hash of a PIN to the history of the account
+func recordPIN(tx *sqlx.Tx, accountID int, hash string) error {
+	_, err := tx.Exec("INSERT INTO pin_history (account_id, pin) VALUES (?, ?)", accountID, hash)
+	return err
+}
+
+// HashStoredPINs replaces the PINs stored before PINs were hashed, in the accounts table and in the
+// PIN history, with their hashes, and returns how many it replaced
+func HashStoredPINs() (int, error) {
+	replaced := 0
+	for _, table := range []string{"accounts", "pin_history"} {
+		var rows []struct {
+			ID  int    `db:"id"`
+			PIN string `db:"pin"`
+		}
+		if err := db.DB.Select(&rows, "SELECT id, pin FROM "+table+" WHERE pin IS NOT NULL AND pin NOT LIKE '$2%'"); err != nil {
+			return replaced, err
+		}
+		for _, r := range rows {
+			if r.PIN == "" {
+				continue
+			}
+			hash, err := HashPIN(r.PIN)
+			if err != nil {
+				return replaced, err
+			}
+			// The PIN is only replaced when nobody changed it meanwhile
+			result, err := db.DB.Exec("UPDATE "+table+" SET pin = ? WHERE id = ? AND pin = ?", hash, r.ID, r.PIN)
+			if err != nil {
+				return replaced, err
+			}
+			if n, _ := result.RowsAffected(); n > 0 {
+				replaced++
+			}
+		}
+	}
+	return replaced, nil
+}
+
+// usedRecently reports whether the PIN is the current PIN of the account
+// or one of the PINs it had before, as far back as the policy looks
+func usedRecently(accountID int, current, pin string, history int) (bool, error) {
+	if history <= 0 {
+		return false, nil
+	}
+	if PINMatches(current, pin) {
+		return true, nil
+	}
+	var previous []string
+	err := db.DB.Select(&previous, "SELECT pin FROM pin_history WHERE account_id = ? ORDER BY id DESC LIMIT ?", accountID, history)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range previous {
+		if PINMatches(p, pin) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
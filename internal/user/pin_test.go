@@ -0,0 +1,98 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPINPolicyCheck(t *testing.T) {
+	noDates := DefaultPINPolicy
+	noDates.RejectDates = false
+	four := PINPolicy{Length: 4, RejectDates: true}
+	eight := PINPolicy{Length: 8, RejectDates: true}
+
+	tests := []struct {
+		name   string
+		policy PINPolicy
+		pin    string
+		want   error
+	}{
+		{"accepted", DefaultPINPolicy, "739261", nil},
+		{"too short", DefaultPINPolicy, "73926", ErrPINLength},
+		{"too long", DefaultPINPolicy, "7392610", ErrPINLength},
+		{"letter", DefaultPINPolicy, "73a261", ErrPINNotNumeric},
+		{"not numeric checked before length", DefaultPINPolicy, "abc", ErrPINNotNumeric},
+		{"ascending", DefaultPINPolicy, "345678", ErrPINSequence},
+		{"descending", DefaultPINPolicy, "987654", ErrPINSequence},
+		{"same digit", DefaultPINPolicy, "111111", ErrPINRepeated},
+		{"repeated pair", DefaultPINPolicy, "121212", ErrPINRepeated},
+		{"repeated triple", DefaultPINPolicy, "123123", ErrPINRepeated},
+		{"day month year", DefaultPINPolicy, "150390", ErrPINDate},
+		{"month day year", DefaultPINPolicy, "031590", ErrPINDate},
+		{"year month day", DefaultPINPolicy, "900315", ErrPINDate},
+		{"impossible date", DefaultPINPolicy, "310290", nil},
+		{"date allowed by the policy", noDates, "150390", nil},
+		{"four digits day month", four, "2512", ErrPINDate},
+		{"four digits year of birth", four, "1987", ErrPINDate},
+		{"four digits year in the future", four, "2999", nil},
+		{"four digits year before 1900", four, "1357", nil},
+		{"eight digits year month day", eight, "19900315", ErrPINDate},
+		{"eight digits ascending", eight, "12345678", ErrPINSequence},
+		{"eight digits accepted", eight, "73926158", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Check(tt.pin); !errors.Is(err, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.pin, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPINPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PINPolicy
+		valid  bool
+	}{
+		{"default", DefaultPINPolicy, true},
+		{"shortest", PINPolicy{Length: MinPINLength}, true},
+		{"longest", PINPolicy{Length: MaxPINLength}, true},
+		{"too short", PINPolicy{Length: MinPINLength - 1}, false},
+		{"too long", PINPolicy{Length: MaxPINLength + 1}, false},
+		{"negative history", PINPolicy{Length: 6, History: -1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestPINMatches(t *testing.T) {
+	hash, err := HashPIN("739261")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		stored string
+		pin    string
+		want   bool
+	}{
+		{"hashed", hash, "739261", true},
+		{"hashed wrong PIN", hash, "739262", false},
+		{"plain text from before hashing", "739261", "739261", true},
+		{"plain text wrong PIN", "739261", "739262", false},
+		{"empty stored PIN", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PINMatches(tt.stored, tt.pin); got != tt.want {
+				t.Errorf("PINMatches(%q) = %v, want %v", tt.pin, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package user
+
+import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/authz"
+	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Statuses of an account
+const (
+	StatusActive  = "active"
+	StatusFrozen  = "frozen"
+	StatusDormant = "dormant"
+	StatusClosed  = "closed"
+)
+
+// Operation is a use of an account whose availability depends on the account status
+type Operation string
+
+// Operations restricted by the account status
+const (
+	OpLogin   Operation = "login"   // the customer identifies at a terminal or through an API
+	OpReceive Operation = "receive" // money is credited: deposits and incoming transfers
+	OpSend    Operation = "send"    // money is debited: withdrawals and outgoing transfers
+)
+
+// statusRules lists the operations each status allows
+// A frozen account still receives money but cannot send it, a dormant account has to be
+// reactivated by the bank before the customer can use it again, and a closed account does nothing
+var statusRules = map[string][]Operation{
+	StatusActive:  {OpLogin, OpReceive, OpSend},
+	StatusFrozen:  {OpLogin, OpReceive},
+	StatusDormant: {OpReceive},
+	StatusClosed:  {},
+}
+
+// DefaultDormancyMonths is the number of months without customer activity after which an account becomes dormant
+const DefaultDormancyMonths = 12
+
+// StatusChange is a recorded transition of an account from one status to another
+type StatusChange struct {
+	ID         int    `db:"id"`
+	AccountID  int    `db:"account_id"`
+	FromStatus string `db:"from_status"`
+	ToStatus   string `db:"to_status"`
+	Reason     string `db:"reason"`
+	Actor      string `db:"actor"`
+	Source     string `db:"source"`
+	CreatedAt  string `db:"created_at"`
+}
+
+// ValidStatus reports whether the status is one of the account statuses
+func ValidStatus(status string) bool {
+	_, ok := statusRules[status]
+	return ok
+}
+
+// CheckStatus returns the error of the account status when it does not allow the operation
+func CheckStatus(status string, op Operation) error {
+	for _, allowed := range statusRules[status] {
+		if allowed == op {
+			return nil
+		}
+	}
+	return statusError(status)
+}
+
+// statusError returns the error that explains why an account in the status is refused
+func statusError(status string) error {
+	switch status {
+	case StatusFrozen:
+		return ErrAccountFrozen
+	case StatusDormant:
+		return ErrAccountDormant
+	case StatusClosed:
+		return ErrAccountClosed
+	}
+	return fmt.Errorf("status akun %q tidak dikenal", status)
+}
+
+// Freeze blocks the account from sending money, on behalf of an operator
+func Freeze(accountID int, reason string, p authz.Principal, source string) error {
+	if err := authorize(p, authz.PermFreeze, audit.ActionFreeze, accountID, source); err != nil {
+		return err
+	}
+	return setStatus(accountID, StatusActive, StatusFrozen, audit.ActionFreeze, reason, p.Name, source)
+}
+
+// Unfreeze gives a frozen account back to the customer
+func Unfreeze(accountID int, reason string, p authz.Principal, source string) error {
+	if err := authorize(p, authz.PermFreeze, audit.ActionUnfreeze, accountID, source); err != nil {
+		return err
+	}
+	return setStatus(accountID, StatusFrozen, StatusActive, audit.ActionUnfreeze, reason, p.Name, source)
+}
+
+// Reactivate gives a dormant account back to the customer, once the bank has checked who they are
+func Reactivate(accountID int, reason string, p authz.Principal, source string) error {
+	if err := authorize(p, authz.PermReactivate, audit.ActionReactivate, accountID, source); err != nil {
+		return err
+	}
+	return setStatus(accountID, StatusDormant, StatusActive, audit.ActionReactivate, reason, p.Name, source)
+}
+
+// setStatus moves the account from one status to another and records the transition
+func setStatus(accountID int, from, to, action, reason, actor, source string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.Get(&status, "SELECT status FROM accounts WHERE id = ? FOR UPDATE", accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		return err
+	}
+	if status != from {
+		err := ErrAccountActive
+		if status != StatusActive {
+			err = statusError(status)
+		}
+		audit.RecordOrLog(actor, action, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE accounts SET status = ? WHERE id = ?", to, accountID)
+	if err == nil {
+		err = recordStatusChange(tx, accountID, from, to, reason, actor, source)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		audit.RecordOrLog(actor, action, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	audit.RecordOrLog(actor, action, accountID, audit.OutcomeSuccess, source, reason)
+	return nil
+}
+
+// recordStatusChange stores a status transition of the account in its status history
+func recordStatusChange(tx *sqlx.Tx, accountID int, from, to, reason, actor, source string) error {
+	_, err := tx.Exec(`INSERT INTO account_status_history (account_id, from_status, to_status, reason, actor, source)
+		VALUES (?, ?, ?, ?, ?, ?)`, accountID, from, to, reason, actor, source)
+	return err
+}
+
+// StatusHistory returns the status transitions of the account, oldest first
+func StatusHistory(accountID int) ([]StatusChange, error) {
+	var changes []StatusChange
+	err := db.DB.Select(&changes, "SELECT * FROM account_status_history WHERE account_id = ? ORDER BY id", accountID)
+	return changes, err
+}
+
+// MarkDormant makes every active account without customer activity for the given number of months dormant
+// Activity is a login, a deposit, a withdrawal or an outgoing transfer; an account without any
+// counts from the day it was opened. It returns the IDs of the accounts that became dormant
+func MarkDormant(months int, actor, source string) ([]int, error) {
+	if months <= 0 {
+		return nil, fmt.Errorf("jumlah bulan harus lebih dari 0")
+	}
+
+	var ids []int
+	err := db.DB.Select(&ids, `SELECT a.id FROM accounts a
+		WHERE a.status = ? AND GREATEST(
+			COALESCE(a.created_at, '1970-01-01'),
+			COALESCE((SELECT MAX(t.created_at) FROM transactions t
+				WHERE t.account_id = a.id AND t.type IN ('deposit', 'withdraw', 'transfer_out', 'interbank_out')), '1970-01-01'),
+			COALESCE((SELECT MAX(s.started_at) FROM sessions s WHERE s.account_id = a.id), '1970-01-01')
+		) < DATE_SUB(NOW(), INTERVAL ? MONTH)
+		ORDER BY a.id`, StatusActive, months)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("tidak ada aktivitas selama %d bulan", months)
+	var marked []int
+	for _, id := range ids {
+		// The status may have been changed by an operator since the accounts were selected
+		if err := setStatus(id, StatusActive, StatusDormant, audit.ActionDormant, reason, actor, source); err != nil {
+			log.Printf("Akun %d tidak dapat dijadikan dormant: %v", id, err)
+			continue
+		}
+		marked = append(marked, id)
+	}
+	return marked, nil
+}
@@ -1,37 +1,121 @@
 package user
 
 import (
+	"atm-simulation/internal/audit"
+	"atm-simulation/internal/i18n"
+	"atm-simulation/pkg/currency"
 	"atm-simulation/pkg/db"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
 )
 
+// Errors returned by the user functions
+var (
+	ErrNameTaken       = i18n.NewError("error.name_taken", "nama pengguna sudah terdaftar, silakan pilih nama lain")
+	ErrAccountNotFound = i18n.NewError("error.account_not_found", "akun tidak ditemukan")
+	ErrAccountLocked   = i18n.NewError("error.account_locked", "akun terkunci, silakan hubungi bank")
+	ErrWrongPIN        = i18n.NewError("error.wrong_pin", "PIN salah")
+	ErrAccountFrozen   = i18n.NewError("error.account_frozen", "akun dibekukan, silakan hubungi bank")
+	ErrAccountDormant  = i18n.NewError("error.account_dormant", "akun tidak aktif karena lama tidak digunakan, silakan hubungi bank")
+	ErrAccountClosed   = i18n.NewError("error.account_closed", "akun sudah ditutup")
+)
+
+// BankCode is the code of the bank whose accounts are opened and served by this process
+var BankCode = "001"
+
+// MaxFailedAttempts is the number of consecutive wrong PINs after which an account is locked
+const MaxFailedAttempts = 3
+
+// WrongPINError is returned for a wrong PIN together with the number of attempts left
+// It matches ErrWrongPIN, and ErrAccountLocked as well once no attempts are left
+type WrongPINError struct {
+	Attempts  int
+	Remaining int
+}
+
+func (e *WrongPINError) Error() string {
+	if e.Remaining > 0 {
+		return fmt.Sprintf("%v, sisa percobaan %d kali", ErrWrongPIN, e.Remaining)
+	}
+	return fmt.Sprintf("%v %d kali, %v", ErrWrongPIN, e.Attempts, ErrAccountLocked)
+}
+
+func (e *WrongPINError) Unwrap() []error {
+	if e.Remaining > 0 {
+		return []error{ErrWrongPIN}
+	}
+	return []error{ErrWrongPIN, ErrAccountLocked}
+}
+
+// MessageKey returns the catalog key of the translation, see i18n.Keyed
+func (e *WrongPINError) MessageKey() (string, []interface{}) {
+	if e.Remaining > 0 {
+		return "error.wrong_pin_attempts_left", []interface{}{e.Remaining}
+	}
+	return "error.wrong_pin_locked", []interface{}{e.Attempts}
+}
+
 // Account represents a user's account in the system
 type Account struct {
-	ID        int     `db:"id"`
-	Name      string  `db:"name"`
-	PIN       string  `db:"pin"`
-	Balance   float64 `db:"balance"`
-	CreatedAt string  `db:"created_at"`
+	ID             int     `db:"id"`
+	Name           string  `db:"name"`
+	PIN            string  `db:"pin"`
+	Balance        float64 `db:"balance"`
+	CreatedAt      string  `db:"created_at"`
+	FailedAttempts int     `db:"failed_attempts"`
+	LockedAt       *string `db:"locked_at"`
+	BankCode       string  `db:"bank_code"`
+	Currency       string  `db:"currency"`
+	Status         string  `db:"status"`
+	ClosedAt       *string `db:"closed_at"`
 }
 
 // Register creates a new account
 // Checks if the username is already taken, and if so, returns an error
-func Register(name, pin string) (*Account, error) {
+// The source identifies the terminal or address the request came from
+func Register(name, pin, currencyCode, source string) (*Account, error) {
+	// Accounts are opened in the base currency unless another one is asked for
+	if currencyCode == "" {
+		currencyCode = currency.Base
+	}
+	c, err := currency.Lookup(currencyCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := Policy.Check(pin); err != nil {
+		audit.RecordOrLog(name, audit.ActionRegister, 0, audit.OutcomeFailure, source, err.Error())
+		return nil, err
+	}
+
 	// Check if the username already exists in the database
 	var existingAccount Account
-	err := db.DB.Get(&existingAccount, "SELECT * FROM accounts WHERE name = ?", name)
+	err = db.DB.Get(&existingAccount, "SELECT * FROM accounts WHERE name = ?", name)
 	if err == nil {
 		// If the username already exists, return an error
-		return nil, fmt.Errorf("nama pengguna sudah terdaftar, silakan pilih nama lain")
+		audit.RecordOrLog(name, audit.ActionRegister, existingAccount.ID, audit.OutcomeFailure, source, "nama sudah terdaftar")
+		return nil, ErrNameTaken
 	}
 
 	// If the username is not taken, create a new account
-	account := &Account{Name: name, PIN: pin, Balance: 0.0}
-	result, err := db.DB.NamedExec(`INSERT INTO accounts (name, pin, balance) VALUES (:name, :pin, :balance)`, account)
+	// The first PIN goes into the PIN history together with the account, both only as a hash
+	hash, err := HashPIN(pin)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.DB.Beginx()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	account := &Account{Name: name, PIN: hash, Balance: 0.0, BankCode: BankCode, Currency: c.Code}
+	result, err := tx.NamedExec(`INSERT INTO accounts (name, pin, balance, bank_code, currency) VALUES (:name, :pin, :balance, :bank_code, :currency)`, account)
+	if err != nil {
+		audit.RecordOrLog(name, audit.ActionRegister, 0, audit.OutcomeFailure, source, err.Error())
+		return nil, err
+	}
 
 	// Retrieve the ID of the newly created account
 	lastID, err := result.LastInsertId()
@@ -41,28 +125,142 @@ func Register(name, pin string) (*Account, error) {
 
 	// Set the ID of the new account into the account object
 	account.ID = int(lastID)
+	if err := recordPIN(tx, account.ID, hash); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	audit.RecordOrLog(name, audit.ActionRegister, account.ID, audit.OutcomeSuccess, source, "")
 	return account, nil
 }
 
 // Login authenticates the user by checking their name and PIN
 // If the account is found, it returns the account details, otherwise an error
-func Login(name, pin string) (*Account, error) {
+// After MaxFailedAttempts wrong PINs in a row the account is locked
+func Login(name, pin, source string) (*Account, error) {
 	account := &Account{}
-	// Use sqlx Get to fetch account details by name and PIN
-	err := db.DB.Get(account, "SELECT * FROM accounts WHERE name = ? AND pin = ?", name, pin)
+	// Use sqlx Get to fetch account details by name
+	err := db.DB.Get(account, "SELECT * FROM accounts WHERE name = ?", name)
 
 	// Handle errors if the account is not found
 	if err != nil {
 		// If no account is found, return an error
-		if err.Error() == "sql: no rows in result set" {
-			return nil, fmt.Errorf("akun tidak ditemukan")
+		if errors.Is(err, sql.ErrNoRows) {
+			audit.RecordOrLog(name, audit.ActionLogin, 0, audit.OutcomeFailure, source, "akun tidak ditemukan")
+			return nil, ErrAccountNotFound
 		}
-		// Log any other errors
-		log.Fatal(err)
+		return nil, err
+	}
+
+	if err := checkPIN(account, pin, source); err != nil {
+		return nil, err
 	}
 	return account, nil
 }
 
+// VerifyPIN authenticates a card-present request for the account by its ID,
+// counting wrong PINs towards the lockout just like Login does
+func VerifyPIN(accountID int, pin, source string) (*Account, error) {
+	account, err := Get(accountID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			audit.RecordOrLog(strconv.Itoa(accountID), audit.ActionLogin, 0, audit.OutcomeFailure, source, "akun tidak ditemukan")
+		}
+		return nil, err
+	}
+	if err := checkPIN(account, pin, source); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// checkPIN compares the PIN with the stored one and records the outcome
+func checkPIN(account *Account, pin, source string) error {
+	// A locked account cannot log in until it is unlocked by an operator
+	if account.LockedAt != nil {
+		audit.RecordOrLog(account.Name, audit.ActionLogin, account.ID, audit.OutcomeFailure, source, "akun terkunci")
+		return ErrAccountLocked
+	}
+	if err := CheckStatus(account.Status, OpLogin); err != nil {
+		audit.RecordOrLog(account.Name, audit.ActionLogin, account.ID, audit.OutcomeFailure, source, "status akun "+account.Status)
+		return err
+	}
+
+	if !PINMatches(account.PIN, pin) {
+		return registerFailedAttempt(account, source)
+	}
+
+	// A PIN stored before PINs were hashed is hashed now that it is known to be right
+	if !PINHashed(account.PIN) {
+		hash, err := HashPIN(pin)
+		if err != nil {
+			return err
+		}
+		if _, err := db.DB.Exec("UPDATE accounts SET pin = ? WHERE id = ? AND pin = ?", hash, account.ID, account.PIN); err != nil {
+			return err
+		}
+		account.PIN = hash
+	}
+
+	// Reset the failed attempt counter after a successful login
+	if account.FailedAttempts > 0 {
+		_, err := db.DB.Exec("UPDATE accounts SET failed_attempts = 0 WHERE id = ?", account.ID)
+		if err != nil {
+			return err
+		}
+		account.FailedAttempts = 0
+	}
+
+	audit.RecordOrLog(account.Name, audit.ActionLogin, account.ID, audit.OutcomeSuccess, source, "")
+	return nil
+}
+
+// registerFailedAttempt counts a wrong PIN and locks the account once the limit is reached
+func registerFailedAttempt(account *Account, source string) error {
+	attempts := account.FailedAttempts + 1
+	audit.RecordOrLog(account.Name, audit.ActionLogin, account.ID, audit.OutcomeFailure, source, "PIN salah, percobaan ke-"+strconv.Itoa(attempts))
+
+	if attempts < MaxFailedAttempts {
+		_, err := db.DB.Exec("UPDATE accounts SET failed_attempts = ? WHERE id = ?", attempts, account.ID)
+		if err != nil {
+			return err
+		}
+		return &WrongPINError{Attempts: attempts, Remaining: MaxFailedAttempts - attempts}
+	}
+
+	// Lock the account once the maximum number of attempts is reached
+	_, err := db.DB.Exec("UPDATE accounts SET failed_attempts = ?, locked_at = CURRENT_TIMESTAMP WHERE id = ?", attempts, account.ID)
+	if err != nil {
+		return err
+	}
+	audit.RecordOrLog(account.Name, audit.ActionLockout, account.ID, audit.OutcomeSuccess, source, fmt.Sprintf("%d kali PIN salah", attempts))
+	return &WrongPINError{Attempts: attempts}
+}
+
+// Get retrieves the account with the given ID
+func Get(accountID int) (*Account, error) {
+	account := &Account{}
+	err := db.DB.Get(account, "SELECT * FROM accounts WHERE id = ?", accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// LookupID returns the ID of the account with the given name, as a card reader would read it
+func LookupID(name string) (int, error) {
+	var accountID int
+	err := db.DB.Get(&accountID, "SELECT id FROM accounts WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAccountNotFound
+	}
+	return accountID, err
+}
+
 // CheckBalance retrieves the balance of the given account by its ID
 func CheckBalance(accountID int) (float64, error) {
 	var balance float64
@@ -74,10 +272,61 @@ func CheckBalance(accountID int) (float64, error) {
 	return balance, nil
 }
 
+// MatchPIN reports whether the PIN is the current PIN of the account
+// A mismatch is not counted towards the lockout, the caller already holds an authenticated session
+func MatchPIN(accountID int, pin string) (bool, error) {
+	var stored string
+	if err := db.DB.Get(&stored, "SELECT pin FROM accounts WHERE id = ?", accountID); err != nil {
+		return false, err
+	}
+	return PINMatches(stored, pin), nil
+}
+
 // ChangePIN updates the PIN of the user account
-// It receives the account ID and the new PIN as parameters
-func ChangePIN(accountID int, newPIN string) error {
-	// Update the PIN for the user in the database
-	_, err := db.DB.Exec("UPDATE accounts SET pin = ? WHERE id = ?", newPIN, accountID)
-	return err
+// It receives the account ID, the new PIN and the source terminal as parameters
+// The new PIN has to satisfy the PIN policy and may not be one of the recent PINs of the account
+func ChangePIN(accountID int, newPIN, source string) error {
+	var account Account
+	err := db.DB.Get(&account, "SELECT name, pin FROM accounts WHERE id = ?", accountID)
+	if err != nil {
+		return err
+	}
+
+	if err := Policy.Check(newPIN); err != nil {
+		audit.RecordOrLog(account.Name, audit.ActionPINChange, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	reused, err := usedRecently(accountID, account.PIN, newPIN, Policy.History)
+	if err != nil {
+		return err
+	}
+	if reused {
+		audit.RecordOrLog(account.Name, audit.ActionPINChange, accountID, audit.OutcomeFailure, source, ErrPINReused.Error())
+		return ErrPINReused
+	}
+
+	// Update the PIN for the user in the database and remember it in the PIN history
+	hash, err := HashPIN(newPIN)
+	if err != nil {
+		return err
+	}
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE accounts SET pin = ? WHERE id = ?", hash, accountID)
+	if err == nil {
+		err = recordPIN(tx, accountID, hash)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		audit.RecordOrLog(account.Name, audit.ActionPINChange, accountID, audit.OutcomeFailure, source, err.Error())
+		return err
+	}
+	audit.RecordOrLog(account.Name, audit.ActionPINChange, accountID, audit.OutcomeSuccess, source, "")
+	return nil
 }
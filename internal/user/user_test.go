@@ -0,0 +1,35 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWrongPINError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *WrongPINError
+		locked bool
+		key    string
+		text   string
+	}{
+		{"attempts left", &WrongPINError{Attempts: 1, Remaining: 2}, false, "error.wrong_pin_attempts_left", "PIN salah, sisa percobaan 2 kali"},
+		{"locked", &WrongPINError{Attempts: 3}, true, "error.wrong_pin_locked", "PIN salah 3 kali, akun terkunci, silakan hubungi bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, ErrWrongPIN) {
+				t.Error("does not match ErrWrongPIN")
+			}
+			if errors.Is(tt.err, ErrAccountLocked) != tt.locked {
+				t.Errorf("matches ErrAccountLocked %v, want %v", !tt.locked, tt.locked)
+			}
+			if key, _ := tt.err.MessageKey(); key != tt.key {
+				t.Errorf("key %q, want %q", key, tt.key)
+			}
+			if tt.err.Error() != tt.text {
+				t.Errorf("text %q, want %q", tt.err.Error(), tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,1452 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: atm/v1/atm.proto
+
+package atmv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TransactionType int32
+
+const (
+	TransactionType_TRANSACTION_TYPE_UNSPECIFIED      TransactionType = 0
+	TransactionType_TRANSACTION_TYPE_DEPOSIT          TransactionType = 1
+	TransactionType_TRANSACTION_TYPE_WITHDRAW         TransactionType = 2
+	TransactionType_TRANSACTION_TYPE_TRANSFER_IN      TransactionType = 3
+	TransactionType_TRANSACTION_TYPE_TRANSFER_OUT     TransactionType = 4
+	TransactionType_TRANSACTION_TYPE_REVERSAL_IN      TransactionType = 5
+	TransactionType_TRANSACTION_TYPE_REVERSAL_OUT     TransactionType = 6
+	TransactionType_TRANSACTION_TYPE_INTERBANK_OUT    TransactionType = 7
+	TransactionType_TRANSACTION_TYPE_INTERBANK_IN     TransactionType = 8
+	TransactionType_TRANSACTION_TYPE_INTERBANK_REFUND TransactionType = 9
+	// Debit of captured funds that were on hold
+	TransactionType_TRANSACTION_TYPE_CAPTURE TransactionType = 10
+	// Funds still on hold, not yet debited
+	TransactionType_TRANSACTION_TYPE_HOLD TransactionType = 11
+	// Interest accrued over a month, credited at month end
+	TransactionType_TRANSACTION_TYPE_INTEREST TransactionType = 12
+	// Monthly account fee
+	TransactionType_TRANSACTION_TYPE_FEE TransactionType = 13
+	// Balance correction approved by a supervisor, or a reconciliation correction
+	TransactionType_TRANSACTION_TYPE_ADJUSTMENT_CREDIT TransactionType = 14
+	TransactionType_TRANSACTION_TYPE_ADJUSTMENT_DEBIT  TransactionType = 15
+	// Remaining balance paid out when the account was closed
+	TransactionType_TRANSACTION_TYPE_PAYOUT TransactionType = 16
+)
+
+// Enum value maps for TransactionType.
+var (
+	TransactionType_name = map[int32]string{
+		0:  "TRANSACTION_TYPE_UNSPECIFIED",
+		1:  "TRANSACTION_TYPE_DEPOSIT",
+		2:  "TRANSACTION_TYPE_WITHDRAW",
+		3:  "TRANSACTION_TYPE_TRANSFER_IN",
+		4:  "TRANSACTION_TYPE_TRANSFER_OUT",
+		5:  "TRANSACTION_TYPE_REVERSAL_IN",
+		6:  "TRANSACTION_TYPE_REVERSAL_OUT",
+		7:  "TRANSACTION_TYPE_INTERBANK_OUT",
+		8:  "TRANSACTION_TYPE_INTERBANK_IN",
+		9:  "TRANSACTION_TYPE_INTERBANK_REFUND",
+		10: "TRANSACTION_TYPE_CAPTURE",
+		11: "TRANSACTION_TYPE_HOLD",
+		12: "TRANSACTION_TYPE_INTEREST",
+		13: "TRANSACTION_TYPE_FEE",
+		14: "TRANSACTION_TYPE_ADJUSTMENT_CREDIT",
+		15: "TRANSACTION_TYPE_ADJUSTMENT_DEBIT",
+		16: "TRANSACTION_TYPE_PAYOUT",
+	}
+	TransactionType_value = map[string]int32{
+		"TRANSACTION_TYPE_UNSPECIFIED":       0,
+		"TRANSACTION_TYPE_DEPOSIT":           1,
+		"TRANSACTION_TYPE_WITHDRAW":          2,
+		"TRANSACTION_TYPE_TRANSFER_IN":       3,
+		"TRANSACTION_TYPE_TRANSFER_OUT":      4,
+		"TRANSACTION_TYPE_REVERSAL_IN":       5,
+		"TRANSACTION_TYPE_REVERSAL_OUT":      6,
+		"TRANSACTION_TYPE_INTERBANK_OUT":     7,
+		"TRANSACTION_TYPE_INTERBANK_IN":      8,
+		"TRANSACTION_TYPE_INTERBANK_REFUND":  9,
+		"TRANSACTION_TYPE_CAPTURE":           10,
+		"TRANSACTION_TYPE_HOLD":              11,
+		"TRANSACTION_TYPE_INTEREST":          12,
+		"TRANSACTION_TYPE_FEE":               13,
+		"TRANSACTION_TYPE_ADJUSTMENT_CREDIT": 14,
+		"TRANSACTION_TYPE_ADJUSTMENT_DEBIT":  15,
+		"TRANSACTION_TYPE_PAYOUT":            16,
+	}
+)
+
+func (x TransactionType) Enum() *TransactionType {
+	p := new(TransactionType)
+	*p = x
+	return p
+}
+
+func (x TransactionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TransactionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_atm_v1_atm_proto_enumTypes[0].Descriptor()
+}
+
+func (TransactionType) Type() protoreflect.EnumType {
+	return &file_atm_v1_atm_proto_enumTypes[0]
+}
+
+func (x TransactionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TransactionType.Descriptor instead.
+func (TransactionType) EnumDescriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{0}
+}
+
+type Account struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CreatedAt string                 `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// ISO 4217 code of the account currency
+	Currency      string `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_atm_v1_atm_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Account) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Account) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Account) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Account) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type Balance struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AccountId int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Balance   float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	Currency  string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	// Balance minus the active holds and the transfers waiting for approval
+	AvailableBalance float64 `protobuf:"fixed64,4,opt,name=available_balance,json=availableBalance,proto3" json:"available_balance,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Balance) Reset() {
+	*x = Balance{}
+	mi := &file_atm_v1_atm_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Balance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Balance) ProtoMessage() {}
+
+func (x *Balance) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Balance.ProtoReflect.Descriptor instead.
+func (*Balance) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Balance) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *Balance) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Balance) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Balance) GetAvailableBalance() float64 {
+	if x != nil {
+		return x.AvailableBalance
+	}
+	return 0
+}
+
+type RegisterRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pin   string                 `protobuf:"bytes,2,opt,name=pin,proto3" json:"pin,omitempty"`
+	// ISO 4217 code of the new account, IDR when empty
+	Currency      string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPin() string {
+	if x != nil {
+		return x.Pin
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RegisterResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pin           string                 `protobuf:"bytes,2,opt,name=pin,proto3" json:"pin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LoginRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPin() string {
+	if x != nil {
+		return x.Pin
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Account       *Account               `protobuf:"bytes,3,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LoginResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type LogoutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogoutRequest) Reset() {
+	*x = LogoutRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutRequest) ProtoMessage() {}
+
+func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
+func (*LogoutRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{6}
+}
+
+type LogoutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogoutResponse) Reset() {
+	*x = LogoutResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutResponse) ProtoMessage() {}
+
+func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutResponse.ProtoReflect.Descriptor instead.
+func (*LogoutResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{7}
+}
+
+type GetAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountRequest) Reset() {
+	*x = GetAccountRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountRequest) ProtoMessage() {}
+
+func (x *GetAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{8}
+}
+
+type GetAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountResponse) Reset() {
+	*x = GetAccountResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountResponse) ProtoMessage() {}
+
+func (x *GetAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountResponse.ProtoReflect.Descriptor instead.
+func (*GetAccountResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetAccountResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type GetBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalanceRequest) Reset() {
+	*x = GetBalanceRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalanceRequest) ProtoMessage() {}
+
+func (x *GetBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{10}
+}
+
+type GetBalanceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balance       *Balance               `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalanceResponse) Reset() {
+	*x = GetBalanceResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalanceResponse) ProtoMessage() {}
+
+func (x *GetBalanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalanceResponse.ProtoReflect.Descriptor instead.
+func (*GetBalanceResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetBalanceResponse) GetBalance() *Balance {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+type DepositRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepositRequest) Reset() {
+	*x = DepositRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepositRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepositRequest) ProtoMessage() {}
+
+func (x *DepositRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepositRequest.ProtoReflect.Descriptor instead.
+func (*DepositRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DepositRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type DepositResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balance       *Balance               `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepositResponse) Reset() {
+	*x = DepositResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepositResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepositResponse) ProtoMessage() {}
+
+func (x *DepositResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepositResponse.ProtoReflect.Descriptor instead.
+func (*DepositResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DepositResponse) GetBalance() *Balance {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+type WithdrawRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WithdrawRequest) Reset() {
+	*x = WithdrawRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WithdrawRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WithdrawRequest) ProtoMessage() {}
+
+func (x *WithdrawRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WithdrawRequest.ProtoReflect.Descriptor instead.
+func (*WithdrawRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WithdrawRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type WithdrawResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balance       *Balance               `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WithdrawResponse) Reset() {
+	*x = WithdrawResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WithdrawResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WithdrawResponse) ProtoMessage() {}
+
+func (x *WithdrawResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WithdrawResponse.ProtoReflect.Descriptor instead.
+func (*WithdrawResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WithdrawResponse) GetBalance() *Balance {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+type TransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TargetId      int64                  `protobuf:"varint,1,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferRequest) Reset() {
+	*x = TransferRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferRequest) ProtoMessage() {}
+
+func (x *TransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferRequest.ProtoReflect.Descriptor instead.
+func (*TransferRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TransferRequest) GetTargetId() int64 {
+	if x != nil {
+		return x.TargetId
+	}
+	return 0
+}
+
+func (x *TransferRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type TransferResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Balance *Balance               `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	// Set when the transfer waits for the approval of a supervisor instead of being posted
+	PendingTransferId *int64 `protobuf:"varint,2,opt,name=pending_transfer_id,json=pendingTransferId,proto3,oneof" json:"pending_transfer_id,omitempty"`
+	// When the held transfer expires unless approved
+	ExpiresAt     string `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TransferResponse) GetBalance() *Balance {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *TransferResponse) GetPendingTransferId() int64 {
+	if x != nil && x.PendingTransferId != nil {
+		return *x.PendingTransferId
+	}
+	return 0
+}
+
+func (x *TransferResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+type CancelTransferRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	PendingTransferId int64                  `protobuf:"varint,1,opt,name=pending_transfer_id,json=pendingTransferId,proto3" json:"pending_transfer_id,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CancelTransferRequest) Reset() {
+	*x = CancelTransferRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTransferRequest) ProtoMessage() {}
+
+func (x *CancelTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTransferRequest.ProtoReflect.Descriptor instead.
+func (*CancelTransferRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CancelTransferRequest) GetPendingTransferId() int64 {
+	if x != nil {
+		return x.PendingTransferId
+	}
+	return 0
+}
+
+type CancelTransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balance       *Balance               `protobuf:"bytes,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTransferResponse) Reset() {
+	*x = CancelTransferResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTransferResponse) ProtoMessage() {}
+
+func (x *CancelTransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTransferResponse.ProtoReflect.Descriptor instead.
+func (*CancelTransferResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CancelTransferResponse) GetBalance() *Balance {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          TransactionType        `protobuf:"varint,1,opt,name=type,proto3,enum=atm.v1.TransactionType" json:"type,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	TargetId      *int64                 `protobuf:"varint,3,opt,name=target_id,json=targetId,proto3,oneof" json:"target_id,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_atm_v1_atm_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Transaction) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func (x *Transaction) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transaction) GetTargetId() int64 {
+	if x != nil && x.TargetId != nil {
+		return *x.TargetId
+	}
+	return 0
+}
+
+func (x *Transaction) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+type StreamHistoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only transactions of this type are streamed; unspecified streams all of them.
+	Type          TransactionType `protobuf:"varint,1,opt,name=type,proto3,enum=atm.v1.TransactionType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamHistoryRequest) Reset() {
+	*x = StreamHistoryRequest{}
+	mi := &file_atm_v1_atm_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamHistoryRequest) ProtoMessage() {}
+
+func (x *StreamHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamHistoryRequest.ProtoReflect.Descriptor instead.
+func (*StreamHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *StreamHistoryRequest) GetType() TransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+type StreamHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transaction   *Transaction           `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamHistoryResponse) Reset() {
+	*x = StreamHistoryResponse{}
+	mi := &file_atm_v1_atm_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamHistoryResponse) ProtoMessage() {}
+
+func (x *StreamHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_atm_v1_atm_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamHistoryResponse.ProtoReflect.Descriptor instead.
+func (*StreamHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_atm_v1_atm_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StreamHistoryResponse) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+var File_atm_v1_atm_proto protoreflect.FileDescriptor
+
+const file_atm_v1_atm_proto_rawDesc = "" +
+	"\n" +
+	"\x10atm/v1/atm.proto\x12\x06atm.v1\"h\n" +
+	"\aAccount\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\tR\tcreatedAt\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\"\x8b\x01\n" +
+	"\aBalance\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12+\n" +
+	"\x11available_balance\x18\x04 \x01(\x01R\x10availableBalance\"S\n" +
+	"\x0fRegisterRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03pin\x18\x02 \x01(\tR\x03pin\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\"=\n" +
+	"\x10RegisterResponse\x12)\n" +
+	"\aaccount\x18\x01 \x01(\v2\x0f.atm.v1.AccountR\aaccount\"4\n" +
+	"\fLoginRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03pin\x18\x02 \x01(\tR\x03pin\"o\n" +
+	"\rLoginResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\tR\texpiresAt\x12)\n" +
+	"\aaccount\x18\x03 \x01(\v2\x0f.atm.v1.AccountR\aaccount\"\x0f\n" +
+	"\rLogoutRequest\"\x10\n" +
+	"\x0eLogoutResponse\"\x13\n" +
+	"\x11GetAccountRequest\"?\n" +
+	"\x12GetAccountResponse\x12)\n" +
+	"\aaccount\x18\x01 \x01(\v2\x0f.atm.v1.AccountR\aaccount\"\x13\n" +
+	"\x11GetBalanceRequest\"?\n" +
+	"\x12GetBalanceResponse\x12)\n" +
+	"\abalance\x18\x01 \x01(\v2\x0f.atm.v1.BalanceR\abalance\"(\n" +
+	"\x0eDepositRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\"<\n" +
+	"\x0fDepositResponse\x12)\n" +
+	"\abalance\x18\x01 \x01(\v2\x0f.atm.v1.BalanceR\abalance\")\n" +
+	"\x0fWithdrawRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\"=\n" +
+	"\x10WithdrawResponse\x12)\n" +
+	"\abalance\x18\x01 \x01(\v2\x0f.atm.v1.BalanceR\abalance\"F\n" +
+	"\x0fTransferRequest\x12\x1b\n" +
+	"\ttarget_id\x18\x01 \x01(\x03R\btargetId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\"\xa9\x01\n" +
+	"\x10TransferResponse\x12)\n" +
+	"\abalance\x18\x01 \x01(\v2\x0f.atm.v1.BalanceR\abalance\x123\n" +
+	"\x13pending_transfer_id\x18\x02 \x01(\x03H\x00R\x11pendingTransferId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\tR\texpiresAtB\x16\n" +
+	"\x14_pending_transfer_id\"G\n" +
+	"\x15CancelTransferRequest\x12.\n" +
+	"\x13pending_transfer_id\x18\x01 \x01(\x03R\x11pendingTransferId\"C\n" +
+	"\x16CancelTransferResponse\x12)\n" +
+	"\abalance\x18\x01 \x01(\v2\x0f.atm.v1.BalanceR\abalance\"\xa1\x01\n" +
+	"\vTransaction\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.atm.v1.TransactionTypeR\x04type\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12 \n" +
+	"\ttarget_id\x18\x03 \x01(\x03H\x00R\btargetId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\tR\tcreatedAtB\f\n" +
+	"\n" +
+	"_target_id\"C\n" +
+	"\x14StreamHistoryRequest\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.atm.v1.TransactionTypeR\x04type\"N\n" +
+	"\x15StreamHistoryResponse\x125\n" +
+	"\vtransaction\x18\x01 \x01(\v2\x13.atm.v1.TransactionR\vtransaction*\xc6\x04\n" +
+	"\x0fTransactionType\x12 \n" +
+	"\x1cTRANSACTION_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18TRANSACTION_TYPE_DEPOSIT\x10\x01\x12\x1d\n" +
+	"\x19TRANSACTION_TYPE_WITHDRAW\x10\x02\x12 \n" +
+	"\x1cTRANSACTION_TYPE_TRANSFER_IN\x10\x03\x12!\n" +
+	"\x1dTRANSACTION_TYPE_TRANSFER_OUT\x10\x04\x12 \n" +
+	"\x1cTRANSACTION_TYPE_REVERSAL_IN\x10\x05\x12!\n" +
+	"\x1dTRANSACTION_TYPE_REVERSAL_OUT\x10\x06\x12\"\n" +
+	"\x1eTRANSACTION_TYPE_INTERBANK_OUT\x10\a\x12!\n" +
+	"\x1dTRANSACTION_TYPE_INTERBANK_IN\x10\b\x12%\n" +
+	"!TRANSACTION_TYPE_INTERBANK_REFUND\x10\t\x12\x1c\n" +
+	"\x18TRANSACTION_TYPE_CAPTURE\x10\n" +
+	"\x12\x19\n" +
+	"\x15TRANSACTION_TYPE_HOLD\x10\v\x12\x1d\n" +
+	"\x19TRANSACTION_TYPE_INTEREST\x10\f\x12\x18\n" +
+	"\x14TRANSACTION_TYPE_FEE\x10\r\x12&\n" +
+	"\"TRANSACTION_TYPE_ADJUSTMENT_CREDIT\x10\x0e\x12%\n" +
+	"!TRANSACTION_TYPE_ADJUSTMENT_DEBIT\x10\x0f\x12\x1b\n" +
+	"\x17TRANSACTION_TYPE_PAYOUT\x10\x102\xa3\x05\n" +
+	"\x0eBankingService\x12=\n" +
+	"\bRegister\x12\x17.atm.v1.RegisterRequest\x1a\x18.atm.v1.RegisterResponse\x124\n" +
+	"\x05Login\x12\x14.atm.v1.LoginRequest\x1a\x15.atm.v1.LoginResponse\x127\n" +
+	"\x06Logout\x12\x15.atm.v1.LogoutRequest\x1a\x16.atm.v1.LogoutResponse\x12C\n" +
+	"\n" +
+	"GetAccount\x12\x19.atm.v1.GetAccountRequest\x1a\x1a.atm.v1.GetAccountResponse\x12C\n" +
+	"\n" +
+	"GetBalance\x12\x19.atm.v1.GetBalanceRequest\x1a\x1a.atm.v1.GetBalanceResponse\x12:\n" +
+	"\aDeposit\x12\x16.atm.v1.DepositRequest\x1a\x17.atm.v1.DepositResponse\x12=\n" +
+	"\bWithdraw\x12\x17.atm.v1.WithdrawRequest\x1a\x18.atm.v1.WithdrawResponse\x12=\n" +
+	"\bTransfer\x12\x17.atm.v1.TransferRequest\x1a\x18.atm.v1.TransferResponse\x12O\n" +
+	"\x0eCancelTransfer\x12\x1d.atm.v1.CancelTransferRequest\x1a\x1e.atm.v1.CancelTransferResponse\x12N\n" +
+	"\rStreamHistory\x12\x1c.atm.v1.StreamHistoryRequest\x1a\x1d.atm.v1.StreamHistoryResponse0\x01B'Z%atm-simulation/pkg/atmpb/atm/v1;atmv1b\x06proto3"
+
+var (
+	file_atm_v1_atm_proto_rawDescOnce sync.Once
+	file_atm_v1_atm_proto_rawDescData []byte
+)
+
+func file_atm_v1_atm_proto_rawDescGZIP() []byte {
+	file_atm_v1_atm_proto_rawDescOnce.Do(func() {
+		file_atm_v1_atm_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_atm_v1_atm_proto_rawDesc), len(file_atm_v1_atm_proto_rawDesc)))
+	})
+	return file_atm_v1_atm_proto_rawDescData
+}
+
+var file_atm_v1_atm_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_atm_v1_atm_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_atm_v1_atm_proto_goTypes = []any{
+	(TransactionType)(0),           // 0: atm.v1.TransactionType
+	(*Account)(nil),                // 1: atm.v1.Account
+	(*Balance)(nil),                // 2: atm.v1.Balance
+	(*RegisterRequest)(nil),        // 3: atm.v1.RegisterRequest
+	(*RegisterResponse)(nil),       // 4: atm.v1.RegisterResponse
+	(*LoginRequest)(nil),           // 5: atm.v1.LoginRequest
+	(*LoginResponse)(nil),          // 6: atm.v1.LoginResponse
+	(*LogoutRequest)(nil),          // 7: atm.v1.LogoutRequest
+	(*LogoutResponse)(nil),         // 8: atm.v1.LogoutResponse
+	(*GetAccountRequest)(nil),      // 9: atm.v1.GetAccountRequest
+	(*GetAccountResponse)(nil),     // 10: atm.v1.GetAccountResponse
+	(*GetBalanceRequest)(nil),      // 11: atm.v1.GetBalanceRequest
+	(*GetBalanceResponse)(nil),     // 12: atm.v1.GetBalanceResponse
+	(*DepositRequest)(nil),         // 13: atm.v1.DepositRequest
+	(*DepositResponse)(nil),        // 14: atm.v1.DepositResponse
+	(*WithdrawRequest)(nil),        // 15: atm.v1.WithdrawRequest
+	(*WithdrawResponse)(nil),       // 16: atm.v1.WithdrawResponse
+	(*TransferRequest)(nil),        // 17: atm.v1.TransferRequest
+	(*TransferResponse)(nil),       // 18: atm.v1.TransferResponse
+	(*CancelTransferRequest)(nil),  // 19: atm.v1.CancelTransferRequest
+	(*CancelTransferResponse)(nil), // 20: atm.v1.CancelTransferResponse
+	(*Transaction)(nil),            // 21: atm.v1.Transaction
+	(*StreamHistoryRequest)(nil),   // 22: atm.v1.StreamHistoryRequest
+	(*StreamHistoryResponse)(nil),  // 23: atm.v1.StreamHistoryResponse
+}
+var file_atm_v1_atm_proto_depIdxs = []int32{
+	1,  // 0: atm.v1.RegisterResponse.account:type_name -> atm.v1.Account
+	1,  // 1: atm.v1.LoginResponse.account:type_name -> atm.v1.Account
+	1,  // 2: atm.v1.GetAccountResponse.account:type_name -> atm.v1.Account
+	2,  // 3: atm.v1.GetBalanceResponse.balance:type_name -> atm.v1.Balance
+	2,  // 4: atm.v1.DepositResponse.balance:type_name -> atm.v1.Balance
+	2,  // 5: atm.v1.WithdrawResponse.balance:type_name -> atm.v1.Balance
+	2,  // 6: atm.v1.TransferResponse.balance:type_name -> atm.v1.Balance
+	2,  // 7: atm.v1.CancelTransferResponse.balance:type_name -> atm.v1.Balance
+	0,  // 8: atm.v1.Transaction.type:type_name -> atm.v1.TransactionType
+	0,  // 9: atm.v1.StreamHistoryRequest.type:type_name -> atm.v1.TransactionType
+	21, // 10: atm.v1.StreamHistoryResponse.transaction:type_name -> atm.v1.Transaction
+	3,  // 11: atm.v1.BankingService.Register:input_type -> atm.v1.RegisterRequest
+	5,  // 12: atm.v1.BankingService.Login:input_type -> atm.v1.LoginRequest
+	7,  // 13: atm.v1.BankingService.Logout:input_type -> atm.v1.LogoutRequest
+	9,  // 14: atm.v1.BankingService.GetAccount:input_type -> atm.v1.GetAccountRequest
+	11, // 15: atm.v1.BankingService.GetBalance:input_type -> atm.v1.GetBalanceRequest
+	13, // 16: atm.v1.BankingService.Deposit:input_type -> atm.v1.DepositRequest
+	15, // 17: atm.v1.BankingService.Withdraw:input_type -> atm.v1.WithdrawRequest
+	17, // 18: atm.v1.BankingService.Transfer:input_type -> atm.v1.TransferRequest
+	19, // 19: atm.v1.BankingService.CancelTransfer:input_type -> atm.v1.CancelTransferRequest
+	22, // 20: atm.v1.BankingService.StreamHistory:input_type -> atm.v1.StreamHistoryRequest
+	4,  // 21: atm.v1.BankingService.Register:output_type -> atm.v1.RegisterResponse
+	6,  // 22: atm.v1.BankingService.Login:output_type -> atm.v1.LoginResponse
+	8,  // 23: atm.v1.BankingService.Logout:output_type -> atm.v1.LogoutResponse
+	10, // 24: atm.v1.BankingService.GetAccount:output_type -> atm.v1.GetAccountResponse
+	12, // 25: atm.v1.BankingService.GetBalance:output_type -> atm.v1.GetBalanceResponse
+	14, // 26: atm.v1.BankingService.Deposit:output_type -> atm.v1.DepositResponse
+	16, // 27: atm.v1.BankingService.Withdraw:output_type -> atm.v1.WithdrawResponse
+	18, // 28: atm.v1.BankingService.Transfer:output_type -> atm.v1.TransferResponse
+	20, // 29: atm.v1.BankingService.CancelTransfer:output_type -> atm.v1.CancelTransferResponse
+	23, // 30: atm.v1.BankingService.StreamHistory:output_type -> atm.v1.StreamHistoryResponse
+	21, // [21:31] is the sub-list for method output_type
+	11, // [11:21] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_atm_v1_atm_proto_init() }
+func file_atm_v1_atm_proto_init() {
+	if File_atm_v1_atm_proto != nil {
+		return
+	}
+	file_atm_v1_atm_proto_msgTypes[17].OneofWrappers = []any{}
+	file_atm_v1_atm_proto_msgTypes[20].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_atm_v1_atm_proto_rawDesc), len(file_atm_v1_atm_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_atm_v1_atm_proto_goTypes,
+		DependencyIndexes: file_atm_v1_atm_proto_depIdxs,
+		EnumInfos:         file_atm_v1_atm_proto_enumTypes,
+		MessageInfos:      file_atm_v1_atm_proto_msgTypes,
+	}.Build()
+	File_atm_v1_atm_proto = out.File
+	file_atm_v1_atm_proto_goTypes = nil
+	file_atm_v1_atm_proto_depIdxs = nil
+}
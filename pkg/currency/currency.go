@@ -0,0 +1,118 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Base is the currency of the bank's books; rates are quoted against it
+const Base = "IDR"
+
+// ErrUnknownCurrency is returned for a code that is not in the currency table
+var ErrUnknownCurrency error = &keyedError{key: "error.unknown_currency", text: "mata uang tidak dikenal"}
+
+// keyedError is an error that carries the catalog key of its translation
+// It satisfies i18n.Keyed without importing it, so the package stays usable outside the terminal
+type keyedError struct {
+	key  string
+	text string
+}
+
+func (e *keyedError) Error() string {
+	return e.text
+}
+
+func (e *keyedError) MessageKey() (string, []interface{}) {
+	return e.key, nil
+}
+
+// Currency describes how amounts of an ISO 4217 currency are written
+type Currency struct {
+	Code        string
+	Numeric     string // ISO 4217 numeric code, as in field 49 of ISO 8583 messages
+	Symbol      string
+	Decimals    int
+	Thousands   string
+	Decimal     string
+	SymbolSpace bool // put a space between the symbol and the amount, e.g. "Rp 1.000"
+}
+
+// currencies are the ISO 4217 currencies accounts can be denominated in
+var currencies = map[string]Currency{
+	"IDR": {Code: "IDR", Numeric: "360", Symbol: "Rp", Decimals: 0, Thousands: ".", Decimal: ",", SymbolSpace: true},
+	"USD": {Code: "USD", Numeric: "840", Symbol: "$", Decimals: 2, Thousands: ",", Decimal: "."},
+	"EUR": {Code: "EUR", Numeric: "978", Symbol: "€", Decimals: 2, Thousands: ".", Decimal: ","},
+	"GBP": {Code: "GBP", Numeric: "826", Symbol: "£", Decimals: 2, Thousands: ",", Decimal: "."},
+	"SGD": {Code: "SGD", Numeric: "702", Symbol: "S$", Decimals: 2, Thousands: ",", Decimal: "."},
+	"MYR": {Code: "MYR", Numeric: "458", Symbol: "RM", Decimals: 2, Thousands: ",", Decimal: "."},
+	"JPY": {Code: "JPY", Numeric: "392", Symbol: "¥", Decimals: 0, Thousands: ",", Decimal: "."},
+}
+
+// Lookup returns the currency with the given ISO 4217 code
+func Lookup(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// Codes returns the codes of all supported currencies in alphabetical order
+func Codes() []string {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Round rounds an amount to the minor unit of the currency
+func (c Currency) Round(amount float64) float64 {
+	factor := math.Pow10(c.Decimals)
+	return math.Round(amount*factor) / factor
+}
+
+// Format writes an amount with the symbol, decimals and separators of the currency,
+// e.g. "Rp 1.500.000" or "$1,250.50"
+func (c Currency) Format(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%.*f", c.Decimals, amount)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	// Insert the thousands separator every three digits from the right
+	var b strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(c.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	if fraction != "" {
+		b.WriteString(c.Decimal)
+		b.WriteString(fraction)
+	}
+
+	space := ""
+	if c.SymbolSpace {
+		space = " "
+	}
+	return sign + c.Symbol + space + b.String()
+}
+
+// Format writes an amount in the currency with the given code
+// Unknown codes are written as the code followed by the plain amount
+func Format(amount float64, code string) string {
+	c, err := Lookup(code)
+	if err != nil {
+		return fmt.Sprintf("%s %.2f", code, amount)
+	}
+	return c.Format(amount)
+}
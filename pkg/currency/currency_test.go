@@ -0,0 +1,77 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount float64
+		code   string
+		want   string
+	}{
+		{1500000, "IDR", "Rp 1.500.000"},
+		{100000, "IDR", "Rp 100.000"},
+		{0, "IDR", "Rp 0"},
+		{1500.6, "IDR", "Rp 1.501"},
+		{-25000, "IDR", "-Rp 25.000"},
+		{1250.5, "USD", "$1,250.50"},
+		{999, "USD", "$999.00"},
+		{5, "usd", "$5.00"},
+		{1234.5, "EUR", "€1.234,50"},
+		{1000, "JPY", "¥1,000"},
+		{1234567.89, "SGD", "S$1,234,567.89"},
+		{12.5, "XYZ", "XYZ 12.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code+" "+tt.want, func(t *testing.T) {
+			if got := Format(tt.amount, tt.code); got != tt.want {
+				t.Errorf("Format(%v, %s) = %q, want %q", tt.amount, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	rates := &Rates{Base: "IDR", Rates: map[string]Rate{
+		"USD": {Buy: 16150, Sell: 16450},
+		"EUR": {Buy: 17500, Sell: 17900},
+	}}
+
+	tests := []struct {
+		name      string
+		from, to  string
+		amount    float64
+		rate      float64
+		converted float64
+		err       error
+	}{
+		{"base to foreign at the sell rate", "IDR", "USD", 1645000, 1.0 / 16450, 100, nil},
+		{"foreign to base at the buy rate", "USD", "IDR", 100, 16150, 1615000, nil},
+		{"between foreign currencies", "USD", "EUR", 100, 16150.0 / 17900, 90.22, nil},
+		{"rounded to the target currency", "EUR", "IDR", 0.01, 17500, 175, nil},
+		{"same currency", "USD", "USD", 100, 1, 100, nil},
+		{"no rate", "IDR", "JPY", 100000, 0, 0, ErrNoRate},
+		{"unknown source", "XYZ", "IDR", 100, 0, 0, ErrUnknownCurrency},
+		{"unknown target", "IDR", "XYZ", 100, 0, 0, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := rates.Quote(tt.from, tt.to, tt.amount)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Quote(%s, %s) = %v, want %v", tt.from, tt.to, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if q.Rate != tt.rate || q.Converted != tt.converted {
+				t.Errorf("Quote(%s, %s, %v) = rate %v converted %v, want rate %v converted %v",
+					tt.from, tt.to, tt.amount, q.Rate, q.Converted, tt.rate, tt.converted)
+			}
+			if q.From != tt.from || q.To != tt.to || q.Amount != tt.amount {
+				t.Errorf("Quote(%s, %s, %v) = %+v", tt.from, tt.to, tt.amount, q)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoRate is returned when no rate is quoted for a currency
+var ErrNoRate error = &keyedError{key: "error.no_rate", text: "kurs untuk mata uang ini tidak tersedia"}
+
+// Rate is the price of one unit of a currency in the base currency
+// The bank buys the currency from customers at Buy and sells it at Sell
+type Rate struct {
+	Buy  float64 `json:"buy"`
+	Sell float64 `json:"sell"`
+}
+
+// Rates is the rate table of the bank
+type Rates struct {
+	Base  string          `json:"base"`
+	Rates map[string]Rate `json:"rates"`
+}
+
+// LoadRates reads a rate table from a JSON file such as
+//
+//	{"base": "IDR", "rates": {"USD": {"buy": 16150, "sell": 16450}}}
+func LoadRates(path string) (*Rates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Rates
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Base == "" {
+		r.Base = Base
+	}
+	if r.Base != Base {
+		return nil, fmt.Errorf("%s: kurs harus dinyatakan dalam %s, bukan %s", path, Base, r.Base)
+	}
+
+	rates := make(map[string]Rate, len(r.Rates))
+	for code, rate := range r.Rates {
+		code = strings.ToUpper(code)
+		if _, err := Lookup(code); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if rate.Buy <= 0 || rate.Sell <= 0 || rate.Buy > rate.Sell {
+			return nil, fmt.Errorf("%s: kurs %s tidak valid (beli %v, jual %v)", path, code, rate.Buy, rate.Sell)
+		}
+		rates[code] = rate
+	}
+	r.Rates = rates
+	return &r, nil
+}
+
+// rate returns the rate of a currency, the base currency always being 1
+func (r *Rates) rate(code string) (Rate, error) {
+	if code == r.Base {
+		return Rate{Buy: 1, Sell: 1}, nil
+	}
+	rate, ok := r.Rates[code]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s", ErrNoRate, code)
+	}
+	return rate, nil
+}
+
+// Quote is the conversion of an amount from one currency into another
+type Quote struct {
+	From      string
+	To        string
+	Amount    float64
+	Rate      float64 // units of To received for one unit of From
+	Converted float64
+}
+
+// Quote converts an amount: the bank buys the source currency at its buy rate and
+// sells the target currency at its sell rate, so the spread of both applies
+func (r *Rates) Quote(from, to string, amount float64) (Quote, error) {
+	target, err := Lookup(to)
+	if err != nil {
+		return Quote{}, err
+	}
+	if _, err := Lookup(from); err != nil {
+		return Quote{}, err
+	}
+	if from == to {
+		return Quote{From: from, To: to, Amount: amount, Rate: 1, Converted: amount}, nil
+	}
+
+	buy, err := r.rate(from)
+	if err != nil {
+		return Quote{}, err
+	}
+	sell, err := r.rate(to)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	rate := buy.Buy / sell.Sell
+	return Quote{From: from, To: to, Amount: amount, Rate: rate, Converted: target.Round(amount * rate)}, nil
+}
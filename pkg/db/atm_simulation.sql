@@ -30,8 +30,314 @@ SET time_zone = "+00:00";
 CREATE TABLE `accounts` (
   `id` int NOT NULL,
   `name` varchar(100) DEFAULT NULL,
-  `pin` varchar(20) DEFAULT NULL,
+  `pin` varchar(60) DEFAULT NULL,
   `balance` decimal(15,2) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `failed_attempts` int NOT NULL DEFAULT '0',
+  `locked_at` timestamp NULL DEFAULT NULL,
+  `bank_code` char(3) NOT NULL DEFAULT '001',
+  `currency` char(3) NOT NULL DEFAULT 'IDR',
+  `status` enum('active','frozen','dormant','closed') NOT NULL DEFAULT 'active',
+  `closed_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `account_status_history`
+--
+
+CREATE TABLE `account_status_history` (
+  `id` int NOT NULL,
+  `account_id` int NOT NULL,
+  `from_status` enum('active','frozen','dormant','closed') NOT NULL,
+  `to_status` enum('active','frozen','dormant','closed') NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `actor` varchar(100) NOT NULL,
+  `source` varchar(100) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `approvals`
+--
+
+CREATE TABLE `approvals` (
+  `id` int NOT NULL,
+  `operation` enum('adjust_balance','close_account','reconcile_balance') NOT NULL,
+  `account_id` int NOT NULL,
+  `amount` decimal(15,2) NOT NULL DEFAULT '0.00',
+  `reason` varchar(255) NOT NULL,
+  `maker` varchar(100) NOT NULL,
+  `status` enum('pending','approved','rejected') NOT NULL DEFAULT 'pending',
+  `checker` varchar(100) DEFAULT NULL,
+  `note` varchar(255) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `decided_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `audit_logs`
+--
+
+CREATE TABLE `audit_logs` (
+  `id` int NOT NULL,
+  `actor` varchar(100) NOT NULL,
+  `action` varchar(50) NOT NULL,
+  `target_account_id` int DEFAULT NULL,
+  `outcome` enum('success','failure') NOT NULL,
+  `source` varchar(100) NOT NULL DEFAULT '',
+  `detail` varchar(255) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `banks`
+--
+
+CREATE TABLE `banks` (
+  `code` char(3) NOT NULL,
+  `name` varchar(100) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+--
+-- Dumping data untuk tabel `banks`
+--
+
+INSERT INTO `banks` (`code`, `name`) VALUES
+('001', 'Bank Simulasi'),
+('002', 'Bank Nusantara'),
+('003', 'Bank Merdeka');
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `business_dates`
+--
+
+CREATE TABLE `business_dates` (
+  `business_date` date NOT NULL,
+  `status` enum('open','closing','closed') NOT NULL DEFAULT 'open',
+  `cutoff_at` datetime NOT NULL,
+  `opened_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `closed_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `clearing_transfers`
+--
+
+CREATE TABLE `clearing_transfers` (
+  `id` int NOT NULL,
+  `reference` varchar(64) NOT NULL,
+  `source_bank` char(3) NOT NULL,
+  `source_account_id` int NOT NULL,
+  `dest_bank` char(3) NOT NULL,
+  `dest_account_id` int NOT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `status` enum('pending','settled','rejected') NOT NULL DEFAULT 'pending',
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `settlement_id` int DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `processed_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `eod_steps`
+--
+
+CREATE TABLE `eod_steps` (
+  `business_date` date NOT NULL,
+  `step` varchar(32) NOT NULL,
+  `status` enum('running','done','failed') NOT NULL DEFAULT 'running',
+  `detail` varchar(255) NOT NULL DEFAULT '',
+  `started_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `finished_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `holds`
+--
+
+CREATE TABLE `holds` (
+  `id` int NOT NULL,
+  `account_id` int NOT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `captured` decimal(15,2) NOT NULL DEFAULT '0.00',
+  `reference` varchar(100) NOT NULL DEFAULT '',
+  `status` enum('active','captured','released','expired') NOT NULL DEFAULT 'active',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` timestamp NOT NULL,
+  `closed_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `interest_accruals`
+--
+
+CREATE TABLE `interest_accruals` (
+  `account_id` int NOT NULL,
+  `business_date` date NOT NULL,
+  `balance` decimal(15,2) NOT NULL,
+  `rate` decimal(7,4) NOT NULL,
+  `amount` decimal(15,4) NOT NULL,
+  `posted_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `operators`
+--
+
+CREATE TABLE `operators` (
+  `id` int NOT NULL,
+  `username` varchar(100) NOT NULL,
+  `password_hash` varchar(100) NOT NULL,
+  `role` enum('teller','supervisor','auditor','cash_replenisher') NOT NULL DEFAULT 'teller',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `pending_transfers`
+--
+
+CREATE TABLE `pending_transfers` (
+  `id` int NOT NULL,
+  `account_id` int NOT NULL,
+  `target_id` int NOT NULL,
+  `dest_bank` char(3) DEFAULT NULL,
+  `reference` varchar(64) DEFAULT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `credit` decimal(15,2) NOT NULL,
+  `status` enum('pending_approval','approved','rejected','expired','cancelled') NOT NULL DEFAULT 'pending_approval',
+  `decided_by` varchar(100) DEFAULT NULL,
+  `note` varchar(255) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` timestamp NOT NULL,
+  `decided_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `pin_history`
+--
+
+CREATE TABLE `pin_history` (
+  `id` int NOT NULL,
+  `account_id` int NOT NULL,
+  `pin` varchar(60) NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `reconciliation_checkpoints`
+--
+
+CREATE TABLE `reconciliation_checkpoints` (
+  `account_id` int NOT NULL,
+  `transaction_id` int NOT NULL DEFAULT '0',
+  `balance` decimal(15,2) NOT NULL,
+  `checked_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `reconciliation_mismatches`
+--
+
+CREATE TABLE `reconciliation_mismatches` (
+  `account_id` int NOT NULL,
+  `difference` decimal(15,2) NOT NULL,
+  `seen_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `reversal_advices`
+--
+
+CREATE TABLE `reversal_advices` (
+  `reference` varchar(64) NOT NULL,
+  `received_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `settlements`
+--
+
+CREATE TABLE `settlements` (
+  `id` int NOT NULL,
+  `transfers` int NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `settlement_positions`
+--
+
+CREATE TABLE `settlement_positions` (
+  `settlement_id` int NOT NULL,
+  `bank_code` char(3) NOT NULL,
+  `net_amount` decimal(15,2) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `sessions`
+--
+
+CREATE TABLE `sessions` (
+  `id` char(32) NOT NULL,
+  `account_id` int NOT NULL,
+  `terminal` varchar(100) NOT NULL,
+  `started_at` timestamp NOT NULL,
+  `ended_at` timestamp NULL DEFAULT NULL,
+  `end_reason` enum('logout','idle_timeout','absolute_timeout','shutdown') DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `standing_orders`
+--
+
+CREATE TABLE `standing_orders` (
+  `id` int NOT NULL,
+  `account_id` int NOT NULL,
+  `target_id` int NOT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `day_of_month` tinyint NOT NULL,
+  `reference` varchar(100) NOT NULL DEFAULT '',
+  `status` enum('active','cancelled') NOT NULL DEFAULT 'active',
+  `last_run_date` date DEFAULT NULL,
+  `last_result` varchar(255) NOT NULL DEFAULT '',
   `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
@@ -44,10 +350,12 @@ CREATE TABLE `accounts` (
 CREATE TABLE `transactions` (
   `id` int NOT NULL,
   `account_id` int DEFAULT NULL,
-  `type` enum('deposit','withdraw','transfer_in','transfer_out') DEFAULT NULL,
+  `type` enum('deposit','withdraw','transfer_in','transfer_out','reversal_in','reversal_out','interbank_out','interbank_in','interbank_refund','adjustment_credit','adjustment_debit','payout','capture','interest','fee') DEFAULT NULL,
   `amount` decimal(15,2) DEFAULT NULL,
   `target_id` int DEFAULT NULL,
-  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP
+  `reference` varchar(64) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `business_date` date DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 --
@@ -58,15 +366,156 @@ CREATE TABLE `transactions` (
 -- Indeks untuk tabel `accounts`
 --
 ALTER TABLE `accounts`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `bank_code` (`bank_code`);
+
+--
+-- Indeks untuk tabel `account_status_history`
+--
+ALTER TABLE `account_status_history`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`);
+
+--
+-- Indeks untuk tabel `approvals`
+--
+ALTER TABLE `approvals`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`),
+  ADD KEY `status` (`status`);
+
+--
+-- Indeks untuk tabel `audit_logs`
+--
+ALTER TABLE `audit_logs`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `target_account_id` (`target_account_id`),
+  ADD KEY `action` (`action`),
+  ADD KEY `created_at` (`created_at`);
+
+--
+-- Indeks untuk tabel `banks`
+--
+ALTER TABLE `banks`
+  ADD PRIMARY KEY (`code`);
+
+--
+-- Indeks untuk tabel `business_dates`
+--
+ALTER TABLE `business_dates`
+  ADD PRIMARY KEY (`business_date`),
+  ADD KEY `status` (`status`);
+
+--
+-- Indeks untuk tabel `clearing_transfers`
+--
+ALTER TABLE `clearing_transfers`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `reference` (`reference`),
+  ADD KEY `status` (`status`),
+  ADD KEY `settlement_id` (`settlement_id`);
+
+--
+-- Indeks untuk tabel `eod_steps`
+--
+ALTER TABLE `eod_steps`
+  ADD PRIMARY KEY (`business_date`,`step`);
+
+--
+-- Indeks untuk tabel `holds`
+--
+ALTER TABLE `holds`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`),
+  ADD KEY `status` (`status`,`expires_at`);
+
+--
+-- Indeks untuk tabel `interest_accruals`
+--
+ALTER TABLE `interest_accruals`
+  ADD PRIMARY KEY (`account_id`,`business_date`),
+  ADD KEY `posted_at` (`posted_at`);
+
+--
+-- Indeks untuk tabel `operators`
+--
+ALTER TABLE `operators`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `username` (`username`);
+
+--
+-- Indeks untuk tabel `pending_transfers`
+--
+ALTER TABLE `pending_transfers`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `reference` (`reference`),
+  ADD KEY `account_id` (`account_id`),
+  ADD KEY `target_id` (`target_id`),
+  ADD KEY `status` (`status`,`expires_at`);
+
+--
+-- Indeks untuk tabel `pin_history`
+--
+ALTER TABLE `pin_history`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`);
+
+--
+-- Indeks untuk tabel `reconciliation_checkpoints`
+--
+ALTER TABLE `reconciliation_checkpoints`
+  ADD PRIMARY KEY (`account_id`);
+
+--
+-- Indeks untuk tabel `reconciliation_mismatches`
+--
+ALTER TABLE `reconciliation_mismatches`
+  ADD PRIMARY KEY (`account_id`);
+
+--
+-- Indeks untuk tabel `reversal_advices`
+--
+ALTER TABLE `reversal_advices`
+  ADD PRIMARY KEY (`reference`);
+
+--
+-- Indeks untuk tabel `settlements`
+--
+ALTER TABLE `settlements`
   ADD PRIMARY KEY (`id`);
 
+--
+-- Indeks untuk tabel `settlement_positions`
+--
+ALTER TABLE `settlement_positions`
+  ADD PRIMARY KEY (`settlement_id`,`bank_code`);
+
+--
+-- Indeks untuk tabel `sessions`
+--
+ALTER TABLE `sessions`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`),
+  ADD KEY `started_at` (`started_at`);
+
+--
+-- Indeks untuk tabel `standing_orders`
+--
+ALTER TABLE `standing_orders`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `account_id` (`account_id`),
+  ADD KEY `target_id` (`target_id`),
+  ADD KEY `status` (`status`,`day_of_month`);
+
 --
 -- Indeks untuk tabel `transactions`
 --
 ALTER TABLE `transactions`
   ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `reference` (`reference`),
   ADD KEY `account_id` (`account_id`),
-  ADD KEY `target_id` (`target_id`);
+  ADD KEY `target_id` (`target_id`),
+  ADD KEY `business_date` (`business_date`);
 
 --
 -- AUTO_INCREMENT untuk tabel yang dibuang
@@ -78,6 +527,66 @@ ALTER TABLE `transactions`
 ALTER TABLE `accounts`
   MODIFY `id` int NOT NULL AUTO_INCREMENT, AUTO_INCREMENT=7;
 
+--
+-- AUTO_INCREMENT untuk tabel `account_status_history`
+--
+ALTER TABLE `account_status_history`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `approvals`
+--
+ALTER TABLE `approvals`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `audit_logs`
+--
+ALTER TABLE `audit_logs`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `clearing_transfers`
+--
+ALTER TABLE `clearing_transfers`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `holds`
+--
+ALTER TABLE `holds`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `operators`
+--
+ALTER TABLE `operators`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `pending_transfers`
+--
+ALTER TABLE `pending_transfers`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `pin_history`
+--
+ALTER TABLE `pin_history`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `settlements`
+--
+ALTER TABLE `settlements`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `standing_orders`
+--
+ALTER TABLE `standing_orders`
+  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT untuk tabel `transactions`
 --
@@ -88,6 +597,87 @@ ALTER TABLE `transactions`
 -- Ketidakleluasaan untuk tabel pelimpahan (Dumped Tables)
 --
 
+--
+-- Ketidakleluasaan untuk tabel `accounts`
+--
+ALTER TABLE `accounts`
+  ADD CONSTRAINT `accounts_ibfk_1` FOREIGN KEY (`bank_code`) REFERENCES `banks` (`code`);
+
+--
+-- Ketidakleluasaan untuk tabel `account_status_history`
+--
+ALTER TABLE `account_status_history`
+  ADD CONSTRAINT `account_status_history_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `approvals`
+--
+ALTER TABLE `approvals`
+  ADD CONSTRAINT `approvals_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `clearing_transfers`
+--
+ALTER TABLE `clearing_transfers`
+  ADD CONSTRAINT `clearing_transfers_ibfk_1` FOREIGN KEY (`source_account_id`) REFERENCES `accounts` (`id`),
+  ADD CONSTRAINT `clearing_transfers_ibfk_2` FOREIGN KEY (`settlement_id`) REFERENCES `settlements` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `holds`
+--
+ALTER TABLE `holds`
+  ADD CONSTRAINT `holds_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `interest_accruals`
+--
+ALTER TABLE `interest_accruals`
+  ADD CONSTRAINT `interest_accruals_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `pending_transfers`
+--
+ALTER TABLE `pending_transfers`
+  ADD CONSTRAINT `pending_transfers_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`),
+  ADD CONSTRAINT `pending_transfers_ibfk_2` FOREIGN KEY (`target_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `pin_history`
+--
+ALTER TABLE `pin_history`
+  ADD CONSTRAINT `pin_history_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `reconciliation_checkpoints`
+--
+ALTER TABLE `reconciliation_checkpoints`
+  ADD CONSTRAINT `reconciliation_checkpoints_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `reconciliation_mismatches`
+--
+ALTER TABLE `reconciliation_mismatches`
+  ADD CONSTRAINT `reconciliation_mismatches_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `settlement_positions`
+--
+ALTER TABLE `settlement_positions`
+  ADD CONSTRAINT `settlement_positions_ibfk_1` FOREIGN KEY (`settlement_id`) REFERENCES `settlements` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `sessions`
+--
+ALTER TABLE `sessions`
+  ADD CONSTRAINT `sessions_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`);
+
+--
+-- Ketidakleluasaan untuk tabel `standing_orders`
+--
+ALTER TABLE `standing_orders`
+  ADD CONSTRAINT `standing_orders_ibfk_1` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`),
+  ADD CONSTRAINT `standing_orders_ibfk_2` FOREIGN KEY (`target_id`) REFERENCES `accounts` (`id`);
+
 --
 -- Ketidakleluasaan untuk tabel `transactions`
 --
@@ -1,8 +1,9 @@
 package db
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
@@ -10,16 +11,30 @@ import (
 
 var DB *sqlx.DB
 
-func InitDB() {
+// InitDB opens the connection pool and checks that the database can be reached
+// DB is set even when the ping fails, so a caller running in stand-in mode can
+// keep pinging until the database comes back
+func InitDB() error {
 	var err error
 	dsn := "root:@tcp(127.0.0.1:3306)/atm_simulation"
 	DB, err = sqlx.Open("mysql", dsn)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 	err = DB.Ping()
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
-	fmt.Println("Database connected successfully")
+	log.Println("Database connected successfully")
+	return nil
+}
+
+// Online reports whether the database answers within the timeout
+func Online(timeout time.Duration) bool {
+	if DB == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return DB.PingContext(ctx) == nil
 }
@@ -0,0 +1,279 @@
+package iso8583
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Message type indicators used by the simulator
+const (
+	MTIAuthorizationRequest  = "0100"
+	MTIAuthorizationResponse = "0110"
+	MTIFinancialRequest      = "0200"
+	MTIFinancialResponse     = "0210"
+	MTIReversalAdvice        = "0420"
+	MTIReversalResponse      = "0430"
+	MTINetworkRequest        = "0800"
+	MTINetworkResponse       = "0810"
+)
+
+// Message is an ISO 8583 message with its fields held as strings
+// Binary fields are held as upper case hex strings
+type Message struct {
+	MTI    string
+	Fields map[int]string
+}
+
+// NewMessage creates an empty message of the given type
+func NewMessage(mti string) *Message {
+	return &Message{MTI: mti, Fields: make(map[int]string)}
+}
+
+// Set sets the value of a field
+func (m *Message) Set(field int, value string) {
+	m.Fields[field] = value
+}
+
+// Get returns the value of a field, or an empty string when it is absent
+func (m *Message) Get(field int) string {
+	return m.Fields[field]
+}
+
+// Has reports whether the field is present
+func (m *Message) Has(field int) bool {
+	_, ok := m.Fields[field]
+	return ok
+}
+
+// ResponseMTI returns the MTI of the response to a request, e.g. 0210 for 0200
+func ResponseMTI(mti string) string {
+	if len(mti) != 4 {
+		return mti
+	}
+	function := mti[2] - '0'
+	return mti[:2] + strconv.Itoa(int(function+1)) + mti[3:]
+}
+
+// Response creates the response to the message, echoing the given request fields
+func (m *Message) Response(echo ...int) *Message {
+	resp := NewMessage(ResponseMTI(m.MTI))
+	for _, field := range echo {
+		if value, ok := m.Fields[field]; ok {
+			resp.Fields[field] = value
+		}
+	}
+	return resp
+}
+
+// Pack encodes the message using the field spec
+func (m *Message) Pack(spec Spec) ([]byte, error) {
+	if len(m.MTI) != 4 || !isDigits(m.MTI) {
+		return nil, fmt.Errorf("MTI %q tidak valid", m.MTI)
+	}
+
+	numbers := make([]int, 0, len(m.Fields))
+	for number := range m.Fields {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	// The secondary bitmap is only sent when a field above 64 is present
+	bitmap := make([]byte, 8)
+	if len(numbers) > 0 && numbers[len(numbers)-1] > 64 {
+		bitmap = make([]byte, 16)
+		bitmap[0] |= 0x80
+	}
+
+	var body []byte
+	for _, number := range numbers {
+		field, ok := spec[number]
+		if !ok {
+			return nil, fmt.Errorf("field %d tidak ada dalam spec", number)
+		}
+		encoded, err := field.encode(m.Fields[number])
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", number, err)
+		}
+		bitmap[(number-1)/8] |= 0x80 >> ((number - 1) % 8)
+		body = append(body, encoded...)
+	}
+
+	out := append([]byte(m.MTI), bitmap...)
+	return append(out, body...), nil
+}
+
+// Unpack decodes a message using the field spec
+func Unpack(spec Spec, data []byte) (*Message, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("pesan terlalu pendek")
+	}
+	m := NewMessage(string(data[:4]))
+	if !isDigits(m.MTI) {
+		return nil, fmt.Errorf("MTI %q tidak valid", m.MTI)
+	}
+
+	bitmap := data[4:12]
+	pos := 12
+	if bitmap[0]&0x80 != 0 {
+		if len(data) < 20 {
+			return nil, fmt.Errorf("bitmap sekunder terpotong")
+		}
+		bitmap = data[4:20]
+		pos = 20
+	}
+
+	for number := 2; number <= len(bitmap)*8; number++ {
+		if bitmap[(number-1)/8]&(0x80>>((number-1)%8)) == 0 {
+			continue
+		}
+		field, ok := spec[number]
+		if !ok {
+			return nil, fmt.Errorf("field %d tidak ada dalam spec", number)
+		}
+		value, n, err := field.decode(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", number, err)
+		}
+		m.Fields[number] = value
+		pos += n
+	}
+
+	if pos != len(data) {
+		return nil, fmt.Errorf("%d byte sisa setelah field terakhir", len(data)-pos)
+	}
+	return m, nil
+}
+
+// encode formats a field value according to the spec
+func (f FieldSpec) encode(value string) ([]byte, error) {
+	raw := []byte(value)
+	if f.Type == TypeBinary {
+		var err error
+		raw, err = hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("nilai biner bukan hex: %w", err)
+		}
+	}
+	if err := f.check(raw); err != nil {
+		return nil, err
+	}
+
+	if f.Prefix == Fixed {
+		if len(raw) > f.Length {
+			return nil, fmt.Errorf("panjang %d melebihi %d", len(raw), f.Length)
+		}
+		switch f.Type {
+		case TypeNumeric:
+			raw = []byte(strings.Repeat("0", f.Length-len(raw)) + string(raw))
+		case TypeBinary:
+			if len(raw) != f.Length {
+				return nil, fmt.Errorf("panjang biner %d, seharusnya %d", len(raw), f.Length)
+			}
+		default:
+			raw = []byte(string(raw) + strings.Repeat(" ", f.Length-len(raw)))
+		}
+		return raw, nil
+	}
+
+	if len(raw) > f.Length {
+		return nil, fmt.Errorf("panjang %d melebihi maksimum %d", len(raw), f.Length)
+	}
+	prefix := fmt.Sprintf("%0*d", len(f.Prefix), len(raw))
+	return append([]byte(prefix), raw...), nil
+}
+
+// decode reads a field value and returns it with the number of bytes consumed
+func (f FieldSpec) decode(data []byte) (string, int, error) {
+	length, offset := f.Length, 0
+	if f.Prefix != Fixed {
+		offset = len(f.Prefix)
+		if len(data) < offset {
+			return "", 0, fmt.Errorf("prefix panjang terpotong")
+		}
+		n, err := strconv.Atoi(string(data[:offset]))
+		if err != nil {
+			return "", 0, fmt.Errorf("prefix panjang tidak valid: %w", err)
+		}
+		if n > f.Length {
+			return "", 0, fmt.Errorf("panjang %d melebihi maksimum %d", n, f.Length)
+		}
+		length = n
+	}
+	if len(data) < offset+length {
+		return "", 0, fmt.Errorf("nilai terpotong")
+	}
+
+	raw := data[offset : offset+length]
+	if err := f.check(raw); err != nil {
+		return "", 0, err
+	}
+	if f.Type == TypeBinary {
+		return strings.ToUpper(hex.EncodeToString(raw)), offset + length, nil
+	}
+	value := string(raw)
+	if f.Prefix == Fixed && f.Type != TypeNumeric {
+		value = strings.TrimRight(value, " ")
+	}
+	return value, offset + length, nil
+}
+
+// check validates the characters of a raw field value against the field type
+func (f FieldSpec) check(raw []byte) error {
+	for _, c := range raw {
+		ok := true
+		switch f.Type {
+		case TypeNumeric:
+			ok = c >= '0' && c <= '9'
+		case TypeAlpha:
+			ok = c == ' ' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+		case TypeAlphaNumeric:
+			ok = c == ' ' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+		case TypeAlphaSpecial:
+			ok = c >= 0x20 && c <= 0x7e
+		}
+		if !ok {
+			return fmt.Errorf("karakter %q tidak sesuai tipe %s", c, f.Type)
+		}
+	}
+	return nil
+}
+
+// isDigits reports whether the string only consists of decimal digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// WriteFrame writes a packed message preceded by its 2-byte big-endian length
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > 0xffff {
+		return fmt.Errorf("pesan terlalu panjang: %d byte", len(data))
+	}
+	frame := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[2:], data)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a packed message preceded by its 2-byte big-endian length
+func ReadFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(header))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,188 @@
+package iso8583
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestPackBitmap(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[int]string
+		bitmap string
+	}{
+		{"primary only", map[int]string{7: "0101120000", 11: "000001"}, "0220000000000000"},
+		{"secondary for a field above 64", map[int]string{7: "0101120000", 11: "000001", 70: "301"}, "82200000000000000400000000000000"},
+		{"first and last primary field", map[int]string{2: "1", 64: ""}, "4000000000000001"},
+	}
+	spec := DefaultSpec()
+	spec[64] = FieldSpec{Name: "MAC", Type: TypeAlphaSpecial, Length: 8, Prefix: LLVar}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMessage("0800")
+			for number, value := range tt.fields {
+				m.Set(number, value)
+			}
+			data, err := m.Pack(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := hex.EncodeToString(data[4 : 4+len(tt.bitmap)/2])
+			if got != tt.bitmap {
+				t.Errorf("bitmap %s, want %s", got, tt.bitmap)
+			}
+		})
+	}
+}
+
+func TestFieldEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   FieldSpec
+		value   string
+		encoded string
+	}{
+		{"fixed numeric is zero padded", FieldSpec{Type: TypeNumeric, Length: 12}, "100", "000000000100"},
+		{"fixed ans is space padded", FieldSpec{Type: TypeAlphaSpecial, Length: 8}, "ATM-1", "ATM-1   "},
+		{"LLVAR", FieldSpec{Type: TypeNumeric, Length: 19, Prefix: LLVar}, "1234", "041234"},
+		{"empty LLVAR", FieldSpec{Type: TypeAlphaSpecial, Length: 28, Prefix: LLVar}, "", "00"},
+		{"LLLVAR", FieldSpec{Type: TypeAlphaSpecial, Length: 999, Prefix: LLLVar}, "name=Budi", "009name=Budi"},
+		{"binary", FieldSpec{Type: TypeBinary, Length: 8}, "041225EEEEEEEEEE", "\x04\x12\x25\xee\xee\xee\xee\xee"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.field.encode(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != tt.encoded {
+				t.Errorf("encode(%q) = %q, want %q", tt.value, encoded, tt.encoded)
+			}
+
+			// Decoding reads the value back and consumes exactly the encoded bytes
+			decoded, n, err := tt.field.decode(append(encoded, "rest"...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(encoded) {
+				t.Errorf("decode consumed %d bytes, want %d", n, len(encoded))
+			}
+			want := tt.value
+			if tt.field.Type == TypeNumeric && tt.field.Prefix == Fixed {
+				want = strings.Repeat("0", tt.field.Length-len(want)) + want
+			}
+			if decoded != want {
+				t.Errorf("decode = %q, want %q", decoded, want)
+			}
+		})
+	}
+}
+
+func TestFieldEncodingErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		field FieldSpec
+		value string
+	}{
+		{"fixed too long", FieldSpec{Type: TypeAlphaSpecial, Length: 8}, "ATM-00001"},
+		{"LLVAR too long", FieldSpec{Type: TypeNumeric, Length: 3, Prefix: LLVar}, "1234"},
+		{"letter in numeric", FieldSpec{Type: TypeNumeric, Length: 6}, "12a"},
+		{"control character in ans", FieldSpec{Type: TypeAlphaSpecial, Length: 8}, "ATM\n1"},
+		{"binary not hex", FieldSpec{Type: TypeBinary, Length: 8}, "XYZ"},
+		{"binary of the wrong length", FieldSpec{Type: TypeBinary, Length: 8}, "0412"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.field.encode(tt.value); err == nil {
+				t.Errorf("encode(%q) succeeded, want an error", tt.value)
+			}
+		})
+	}
+}
+
+func TestFieldDecodingErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		field FieldSpec
+		data  string
+	}{
+		{"fixed truncated", FieldSpec{Type: TypeNumeric, Length: 6}, "123"},
+		{"prefix truncated", FieldSpec{Type: TypeAlphaSpecial, Length: 999, Prefix: LLLVar}, "01"},
+		{"prefix not a number", FieldSpec{Type: TypeAlphaSpecial, Length: 99, Prefix: LLVar}, "x1abc"},
+		{"prefix above the maximum", FieldSpec{Type: TypeNumeric, Length: 3, Prefix: LLVar}, "041234"},
+		{"value shorter than the prefix", FieldSpec{Type: TypeNumeric, Length: 19, Prefix: LLVar}, "10123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.field.decode([]byte(tt.data)); err == nil {
+				t.Errorf("decode(%q) succeeded, want an error", tt.data)
+			}
+		})
+	}
+}
+
+func TestPackUnpackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		mti    string
+		fields map[int]string
+	}{
+		{"balance inquiry", "0100", map[int]string{2: "1", 3: "310000", 4: "000000000000", 11: "000042", 41: "ATM-001", 49: "360", 52: "041225EEEEEEEEEE"}},
+		{"login response with field 48", "0110", map[int]string{2: "12", 39: "00", 48: "currency=IDR&name=Budi&status=active", 102: "12"}},
+		{"network management", "0800", map[int]string{7: "0101120000", 11: "000001", 70: "301"}},
+	}
+	spec := DefaultSpec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMessage(tt.mti)
+			for number, value := range tt.fields {
+				m.Set(number, value)
+			}
+			data, err := m.Pack(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Unpack(spec, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MTI != tt.mti {
+				t.Errorf("MTI %s, want %s", got.MTI, tt.mti)
+			}
+			if len(got.Fields) != len(tt.fields) {
+				t.Errorf("%d fields, want %d", len(got.Fields), len(tt.fields))
+			}
+			for number, value := range tt.fields {
+				if got.Get(number) != value {
+					t.Errorf("field %d = %q, want %q", number, got.Get(number), value)
+				}
+			}
+		})
+	}
+}
+
+func TestUnpackErrors(t *testing.T) {
+	spec := DefaultSpec()
+	valid, err := (&Message{MTI: "0800", Fields: map[int]string{11: "000001", 70: "301"}}).Pack(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"shorter than a bitmap", []byte("0800")},
+		{"MTI not numeric", append([]byte("08X0"), valid[4:]...)},
+		{"secondary bitmap truncated", valid[:16]},
+		{"field truncated", valid[:len(valid)-1]},
+		{"bytes after the last field", append(append([]byte{}, valid...), '9')},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unpack(spec, tt.data); err == nil {
+				t.Error("Unpack succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package iso8583
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// panBlock builds the PAN part of an ISO 9564 format 0 PIN block:
+// four zeros followed by the rightmost 12 PAN digits excluding the check digit
+func panBlock(pan string) ([]byte, error) {
+	if !isDigits(pan) {
+		return nil, fmt.Errorf("PAN harus berupa angka")
+	}
+	digits := pan[:len(pan)-1]
+	if len(digits) > 12 {
+		digits = digits[len(digits)-12:]
+	}
+	return hex.DecodeString("0000" + strings.Repeat("0", 12-len(digits)) + digits)
+}
+
+// PINBlock encodes the PIN as an ISO 9564 format 0 PIN block for the PAN
+// The simulator sends the block in the clear, there is no PIN encryption key
+func PINBlock(pin, pan string) (string, error) {
+	if len(pin) < 4 || len(pin) > 12 || !isDigits(pin) {
+		return "", fmt.Errorf("PIN harus 4-12 digit angka")
+	}
+	pinField, err := hex.DecodeString(fmt.Sprintf("0%X%s%s", len(pin), pin, strings.Repeat("F", 14-len(pin))))
+	if err != nil {
+		return "", err
+	}
+	panField, err := panBlock(pan)
+	if err != nil {
+		return "", err
+	}
+	for i := range pinField {
+		pinField[i] ^= panField[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(pinField)), nil
+}
+
+// DecodePINBlock recovers the PIN from an ISO 9564 format 0 PIN block
+func DecodePINBlock(block, pan string) (string, error) {
+	raw, err := hex.DecodeString(block)
+	if err != nil || len(raw) != 8 {
+		return "", fmt.Errorf("PIN block tidak valid")
+	}
+	panField, err := panBlock(pan)
+	if err != nil {
+		return "", err
+	}
+	for i := range raw {
+		raw[i] ^= panField[i]
+	}
+
+	clear := strings.ToUpper(hex.EncodeToString(raw))
+	if clear[0] != '0' {
+		return "", fmt.Errorf("format PIN block tidak didukung")
+	}
+	var length int
+	fmt.Sscanf(clear[1:2], "%X", &length)
+	if length < 4 || length > 12 || !isDigits(clear[2:2+length]) {
+		return "", fmt.Errorf("PIN block tidak valid")
+	}
+	return clear[2 : 2+length], nil
+}
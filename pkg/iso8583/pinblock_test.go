@@ -0,0 +1,78 @@
+package iso8583
+
+import "testing"
+
+func TestPINBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		pin   string
+		pan   string
+		block string
+	}{
+		{"ISO 9564 format 0 example", "1234", "4111111111111111", "041225EEEEEEEEEE"},
+		{"short account number", "123456", "7", "06123456FFFFFFFF"},
+		{"PAN longer than 12 digits uses the rightmost", "1234", "99994111111111111111", "041225EEEEEEEEEE"},
+		{"12-digit PIN", "123456789012", "4111111111111111", "0C122547698103EE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := PINBlock(tt.pin, tt.pan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block != tt.block {
+				t.Errorf("PINBlock(%q, %q) = %s, want %s", tt.pin, tt.pan, block, tt.block)
+			}
+			pin, err := DecodePINBlock(block, tt.pan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pin != tt.pin {
+				t.Errorf("DecodePINBlock = %q, want %q", pin, tt.pin)
+			}
+		})
+	}
+}
+
+func TestPINBlockErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  string
+		pan  string
+	}{
+		{"PIN too short", "123", "1"},
+		{"PIN too long", "1234567890123", "1"},
+		{"PIN not numeric", "12a4", "1"},
+		{"PAN not numeric", "1234", "12x"},
+		{"empty PAN", "1234", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PINBlock(tt.pin, tt.pan); err == nil {
+				t.Errorf("PINBlock(%q, %q) succeeded, want an error", tt.pin, tt.pan)
+			}
+		})
+	}
+}
+
+func TestDecodePINBlockErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+		pan   string
+	}{
+		{"not hex", "ZZ1225EEEEEEEEEE", "4111111111111111"},
+		{"wrong length", "041225EE", "4111111111111111"},
+		{"not format 0", "141225EEEEEEEEEE", "4111111111111111"},
+		{"PIN length out of range", "021225EEEEEEEEEE", "4111111111111111"},
+		{"padding read as PIN digits", "061225EEEEEEEEEE", "4111111111111111"},
+		{"wrong PAN", "041225EEEEEEEEEE", "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodePINBlock(tt.block, tt.pan); err == nil {
+				t.Errorf("DecodePINBlock(%q, %q) succeeded, want an error", tt.block, tt.pan)
+			}
+		})
+	}
+}